@@ -1,6 +1,6 @@
 module github.com/cry999/gopenapi
 
-go 1.15
+go 1.16
 
 require (
 	github.com/spf13/cobra v1.1.3
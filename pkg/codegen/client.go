@@ -0,0 +1,402 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// GenerateClient generates the types of the component schemas and a Client
+// with one method per operation, returning the decoded JSON responses by
+// status code.
+func GenerateClient(spec *openapi.OpenAPI, pkg string, cfg *Config) ([]byte, error) {
+	f := newFile(spec, pkg, cfg)
+	f.componentTypes()
+
+	for _, path := range []string{"bytes", "context", "encoding", "encoding/json", "fmt", "io", "io/ioutil", "mime", "net/http", "net/url", "reflect", "strings"} {
+		f.use(path)
+	}
+	f.printf("// defaultServerURL is the URL of the first server.\n")
+	f.printf("const defaultServerURL = %q\n\n", serverURL(spec))
+	f.printf("%s", clientRuntime)
+	f.securityOptions()
+
+	for _, op := range operations(spec) {
+		f.clientOperation(op, f.signature(op))
+	}
+	return f.source()
+}
+
+// serverURL returns the URL of the first server, filling its variables with
+// their default values.
+func serverURL(spec *openapi.OpenAPI) string {
+	if len(spec.Servers) == 0 || spec.Servers[0].URL == nil {
+		return ""
+	}
+	server := spec.Servers[0]
+	u := server.URL.String()
+	for name, v := range server.Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// securityOptions generates a ClientOption per security scheme setting the
+// credentials of every request.
+func (f *file) securityOptions() {
+	if f.spec.Components == nil {
+		return
+	}
+	names := make([]string, 0, len(f.spec.Components.SecuritySchemes))
+	for name := range f.spec.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		scheme := f.spec.Components.SecuritySchemes[name]
+		if scheme == nil || scheme.IsRef() {
+			continue
+		}
+		option := "With" + goName(name)
+		doc := fmt.Sprintf("authenticates requests with the `%s` security scheme", name)
+
+		switch {
+		case scheme.Type == "apiKey":
+			f.printf("// %s %s, sending key in the %s %s.\n", option, doc, scheme.In, scheme.Name)
+			f.printf("func %s(key string) ClientOption {\nreturn WithRequestEditor(func(ctx context.Context, req *http.Request) error {\n", option)
+			switch scheme.In {
+			case "query":
+				f.printf("q := req.URL.Query()\nq.Set(%q, key)\nreq.URL.RawQuery = q.Encode()\n", scheme.Name)
+			case "cookie":
+				f.printf("req.AddCookie(&http.Cookie{Name: %q, Value: key})\n", scheme.Name)
+			default:
+				f.printf("req.Header.Set(%q, key)\n", scheme.Name)
+			}
+			f.printf("return nil\n})\n}\n\n")
+
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			f.printf("// %s %s.\n", option, doc)
+			f.printf("func %s(username, password string) ClientOption {\nreturn WithRequestEditor(func(ctx context.Context, req *http.Request) error {\n", option)
+			f.printf("req.SetBasicAuth(username, password)\nreturn nil\n})\n}\n\n")
+
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+			f.printf("// %s %s.\n", option, doc)
+			f.printf("func %s(token string) ClientOption {\nreturn WithRequestEditor(func(ctx context.Context, req *http.Request) error {\n", option)
+			f.printf("req.Header.Set(\"Authorization\", \"Bearer \"+token)\nreturn nil\n})\n}\n\n")
+
+		case scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+			f.printf("// %s %s, with the access tokens of ts.\n", option, doc)
+			f.printf("func %s(ts TokenSource) ClientOption {\nreturn WithRequestEditor(func(ctx context.Context, req *http.Request) error {\n", option)
+			f.printf("token, err := ts.Token(ctx)\nif err != nil {\nreturn err\n}\n")
+			f.printf("req.Header.Set(\"Authorization\", \"Bearer \"+token)\nreturn nil\n})\n}\n\n")
+		}
+	}
+}
+
+// clientOperation generates the response type and the method of an
+// operation.
+func (f *file) clientOperation(op *operation, sig *signature) {
+	responseType := f.responseType(op)
+
+	args := []string{"ctx context.Context"}
+	for _, p := range sig.pathParams {
+		args = append(args, p.local+" "+p.typ)
+	}
+	if sig.paramsType != "" {
+		args = append(args, "params "+sig.paramsType)
+	}
+	if sig.body != nil {
+		args = append(args, "body "+sig.body.goType())
+	}
+
+	f.printf("%s", comment(op.name, fmt.Sprintf("calls `%s %s`.", op.method, op.path)))
+	if op.op.Summary != "" {
+		f.printf("//\n%s", comment("", op.op.Summary))
+	}
+	f.printf("func (c *Client) %s(%s) (*%s, error) {\n", op.name, strings.Join(args, ", "), responseType.name)
+
+	// path
+	path := fmt.Sprintf("%q", op.path)
+	for _, p := range sig.pathParams {
+		path = strings.Replace(path, "{"+p.Name+"}", fmt.Sprintf(`" + url.PathEscape(paramValue(%s, %q)) + "`, p.local, p.sep), 1)
+	}
+	f.printf("path := %s\n", strings.TrimSuffix(strings.TrimPrefix(path, `"" + `), ` + ""`))
+
+	// query, headers and cookies
+	f.printf("query := url.Values{}\nheader := http.Header{}\nvar cookies []*http.Cookie\n")
+	for _, p := range sig.params {
+		value := "params." + p.field
+		optional := !p.Required
+		if optional && pointer(p.typ) != p.typ {
+			f.printf("if %s != nil {\n", value)
+			value = "*" + value
+		} else if optional {
+			f.printf("if %s != nil {\n", value)
+		}
+		switch p.In {
+		case "query":
+			f.printf("query[%q] = paramValues(%s, %v, %q)\n", p.Name, value, p.explode, p.sep)
+		case "header":
+			f.printf("header.Set(%q, paramValue(%s, \",\"))\n", p.Name, value)
+		case "cookie":
+			f.printf("cookies = append(cookies, &http.Cookie{Name: %q, Value: paramValue(%s, \",\")})\n", p.Name, value)
+		}
+		if optional {
+			f.printf("}\n")
+		}
+	}
+
+	// body
+	if sig.body != nil {
+		f.printf("var reqBody io.Reader\n")
+		if !sig.body.required {
+			f.printf("if body != nil {\n")
+		}
+		f.printf("b, err := json.Marshal(body)\nif err != nil {\nreturn nil, err\n}\n")
+		f.printf("reqBody = bytes.NewReader(b)\nheader.Set(\"Content-Type\", %q)\n", sig.body.mediaType)
+		if !sig.body.required {
+			f.printf("}\n")
+		}
+	} else {
+		f.printf("var reqBody io.Reader\n")
+	}
+
+	f.printf("res, raw, err := c.do(ctx, %q, path, query, header, cookies, reqBody)\nif err != nil {\nreturn nil, err\n}\n", op.method)
+	f.printf("out := &%s{HTTPResponse: res, Body: raw}\n", responseType.name)
+	if len(responseType.fields) > 0 {
+		f.printf("if !isJSON(res) {\nreturn out, nil\n}\n")
+		f.printf("switch {\n")
+		for _, field := range responseType.fields {
+			f.printf("case %s:\n", field.cond)
+			f.printf("var v %s\nif err := json.Unmarshal(raw, &v); err != nil {\nreturn out, err\n}\n", field.typ)
+			f.printf("out.%s = &v\n", field.name)
+		}
+		f.printf("}\n")
+	}
+	f.printf("return out, nil\n}\n\n")
+}
+
+type responseType struct {
+	name   string
+	fields []*responseField
+}
+
+type responseField struct {
+	name string
+	typ  string
+	// cond is the Go condition on the status code selecting the field.
+	cond string
+}
+
+// responseType declares the response of an operation, with a field per
+// status code documenting JSON content. Exact codes are matched before
+// ranges such as `4XX`, and the default response last.
+func (f *file) responseType(op *operation) *responseType {
+	rt := &responseType{name: op.name + "Response"}
+
+	var codes []string
+	if op.op.Responses != nil {
+		for code := range *op.op.Responses {
+			codes = append(codes, code)
+		}
+	}
+	rank := func(code string) int {
+		switch {
+		case code == "default":
+			return 2
+		case strings.HasSuffix(strings.ToUpper(code), "XX"):
+			return 1
+		}
+		return 0
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if rank(codes[i]) != rank(codes[j]) {
+			return rank(codes[i]) < rank(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("struct {\nHTTPResponse *http.Response\n// Body is the raw body of the response.\nBody []byte\n")
+	for _, code := range codes {
+		res := f.spec.ResolveResponse((*op.op.Responses)[code])
+		if res == nil {
+			continue
+		}
+		schema := jsonSchema(res.Content)
+		if schema == nil {
+			continue
+		}
+		suffix := strings.ToUpper(code)
+		if code == "default" {
+			suffix = "Default"
+		}
+		field := &responseField{
+			name: "JSON" + suffix,
+			typ:  f.goType(schema, op.name+"JSON"+suffix+"Response"),
+		}
+		switch rank(code) {
+		case 0:
+			field.cond = "res.StatusCode == " + code
+		case 1:
+			field.cond = fmt.Sprintf("res.StatusCode/100 == %s", code[:1])
+		default:
+			field.cond = "true"
+		}
+		rt.fields = append(rt.fields, field)
+		fmt.Fprintf(&b, "%s *%s\n", field.name, field.typ)
+	}
+	b.WriteString("}")
+
+	f.declared[rt.name] = true
+	f.types.WriteString(comment(rt.name, "is the response of "+op.name+"."))
+	f.types.WriteString("type " + rt.name + " " + b.String() + "\n\n")
+	return rt
+}
+
+// jsonSchema returns the schema of the first JSON media type of content.
+func jsonSchema(content map[string]*openapi.MediaType) *openapi.SchemaOrRef {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if media := content[mediaType]; isJSON(mediaType) && media != nil {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// clientRuntime is the part of the generated client which does not depend
+// on the document.
+const clientRuntime = `// Client calls the operations of the API.
+type Client struct {
+	baseURL string
+	doer    HTTPDoer
+	editors []RequestEditor
+}
+
+// HTTPDoer sends requests, as *http.Client does.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RequestEditor modifies requests before they are sent, for instance to
+// authenticate them.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// TokenSource supplies OAuth2 access tokens. A token source of
+// golang.org/x/oauth2 is adapted with TokenSourceFunc.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc is a TokenSource calling a function.
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token ...
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ClientOption configures NewClient.
+type ClientOption func(*Client)
+
+// WithBaseURL replaces the URL of the first server.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(baseURL, "/") }
+}
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(doer HTTPDoer) ClientOption {
+	return func(c *Client) { c.doer = doer }
+}
+
+// WithRequestEditor applies fn to every request.
+func WithRequestEditor(fn RequestEditor) ClientOption {
+	return func(c *Client) { c.editors = append(c.editors, fn) }
+}
+
+// NewClient ...
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{baseURL: defaultServerURL, doer: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, cookies []*http.Cookie, body io.Reader) (*http.Response, []byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	for _, edit := range c.editors {
+		if err := edit(ctx, req); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res, err := c.doer.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	return res, raw, nil
+}
+
+func isJSON(res *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// paramValues serializes a parameter. Unless exploded, the items of arrays
+// are joined by sep.
+func paramValues(v interface{}, explode bool, sep string) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return []string{formatValue(v)}
+	}
+	values := make([]string, rv.Len())
+	for i := range values {
+		values[i] = formatValue(rv.Index(i).Interface())
+	}
+	if explode {
+		return values
+	}
+	return []string{strings.Join(values, sep)}
+}
+
+func formatValue(v interface{}) string {
+	if m, ok := v.(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func paramValue(v interface{}, sep string) string {
+	return paramValues(v, false, sep)[0]
+}
+
+`
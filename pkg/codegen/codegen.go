@@ -0,0 +1,225 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// header marks generated files, see https://golang.org/s/generatedcode.
+const header = "// Code generated by gopenapi. DO NOT EDIT.\n\n"
+
+// file accumulates the declarations of a generated Go file.
+type file struct {
+	spec    *openapi.OpenAPI
+	pkg     string
+	cfg     *Config
+	imports map[string]bool
+
+	// types holds the declarations of the types, which come first, and
+	// body the rest of the file.
+	types    bytes.Buffer
+	body     bytes.Buffer
+	declared map[string]bool
+	// inline names the types declared for inline schemas.
+	inline map[*openapi.SchemaOrRef]string
+}
+
+func newFile(spec *openapi.OpenAPI, pkg string, cfg *Config) *file {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return &file{
+		spec:     spec,
+		pkg:      pkg,
+		cfg:      cfg,
+		imports:  map[string]bool{},
+		declared: map[string]bool{},
+		inline:   map[*openapi.SchemaOrRef]string{},
+	}
+}
+
+func (f *file) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+func (f *file) use(path string) {
+	f.imports[path] = true
+}
+
+// source returns the formatted file.
+func (f *file) source() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", f.pkg)
+	if len(f.imports) > 0 {
+		imports := make([]string, 0, len(f.imports))
+		for path := range f.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		b.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(f.types.Bytes())
+	b.Write(f.body.Bytes())
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return b.Bytes(), fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return src, nil
+}
+
+// operation is an operation of the document along with its location.
+type operation struct {
+	name   string
+	method string
+	path   string
+	item   *openapi.PathItem
+	op     *openapi.Operation
+	params []*openapi.Parameter
+}
+
+// operations lists the operations of the document ordered by path and
+// method. Operations are named after their operationId, or their method and
+// path when they have none.
+func operations(spec *openapi.OpenAPI) []*operation {
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ops []*operation
+	for _, path := range paths {
+		item := spec.Paths[path]
+		for _, method := range openapi.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			name := op.OperationID
+			if name == "" {
+				name = method + " " + strings.NewReplacer("{", " ", "}", " ").Replace(path)
+			}
+			ops = append(ops, &operation{
+				name:   goName(name),
+				method: strings.ToUpper(method),
+				path:   path,
+				item:   item,
+				op:     op,
+				params: spec.OperationParameters(item, op),
+			})
+		}
+	}
+	return ops
+}
+
+// initialisms are kept upper case in Go names, as golint expects.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true,
+	"HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "SQL": true,
+	"TCP": true, "TLS": true, "TTL": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// goName converts names such as `pet_id` or `petId` into exported Go names
+// such as `PetID`.
+func goName(s string) string {
+	var b strings.Builder
+	for _, word := range splitWords(s) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		} else if strings.HasSuffix(upper, "S") && initialisms[upper[:len(upper)-1]] {
+			// plurals such as `IDs`
+			b.WriteString(upper[:len(upper)-1] + "s")
+			continue
+		}
+		runes := []rune(word)
+		b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// splitWords splits a name on separators and case changes, keeping runs of
+// upper case letters such as `HTTP` in `HTTPServer` together.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// localName converts an exported Go name into an unexported one, such as
+// `PetID` into `petID` or `URLPath` into `urlPath`.
+func localName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		// keep the first letter of the next word
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	local := string(runes)
+	if token.Lookup(local).IsKeyword() {
+		local += "_"
+	}
+	return local
+}
+
+// comment formats a description as a Go comment.
+func comment(prefix, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("// "+line, " ")
+	}
+	if prefix != "" && !strings.HasPrefix(text, prefix+" ") {
+		lines[0] = "// " + prefix + " " + strings.TrimPrefix(lines[0], "// ")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
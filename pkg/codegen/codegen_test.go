@@ -0,0 +1,443 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"pet_id":       "PetID",
+		"petId":        "PetID",
+		"HTTPServer":   "HTTPServer",
+		"X-Request-Id": "XRequestID",
+		"ids":          "IDs",
+		"2fa":          "X2fa",
+		"list pets":    "ListPets",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+const testSpec = `
+servers:
+- url: http://localhost/v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: limit
+        in: query
+        schema:
+          type: integer
+          format: int32
+      responses:
+        "200":
+          description: ok
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: created
+  /pets/{petId}:
+    get:
+      operationId: showPetById
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        default:
+          description: error
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  message:
+                    type: string
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+        owner:
+          type: object
+          properties:
+            email:
+              type: string
+`
+
+func loadTestSpec(t *testing.T) *openapi.OpenAPI {
+	t.Helper()
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(testSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return &spec
+}
+
+// typeCheck parses and type checks generated code.
+func typeCheck(t *testing.T, src []byte) *types.Package {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "generated.go", src, 0)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v\n%s", err, src)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("api", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("Check() error = %v\n%s", err, src)
+	}
+	return pkg
+}
+
+func TestGenerateServer(t *testing.T) {
+	spec := loadTestSpec(t)
+
+	src, err := GenerateServer(spec, "api", nil)
+	if err != nil {
+		t.Fatalf("GenerateServer() error = %v", err)
+	}
+	pkg := typeCheck(t, src)
+
+	iface := pkg.Scope().Lookup("ServerInterface").Type().Underlying().(*types.Interface)
+	want := map[string]string{
+		"ListPets":    "func(w net/http.ResponseWriter, r *net/http.Request, params api.ListPetsParams)",
+		"CreatePet":   "func(w net/http.ResponseWriter, r *net/http.Request, body api.Pet)",
+		"ShowPetByID": "func(w net/http.ResponseWriter, r *net/http.Request, petID int64)",
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if got := m.Type().String(); got != want[m.Name()] {
+			t.Errorf("%s = %s, want %s", m.Name(), got, want[m.Name()])
+		}
+	}
+	for _, name := range []string{"PetOwner", "Handler", "HandlerWithOptions", "BindError"} {
+		if pkg.Scope().Lookup(name) == nil {
+			t.Errorf("%s is not declared", name)
+		}
+	}
+
+	again, _ := GenerateServer(loadTestSpec(t), "api", nil)
+	if string(again) != string(src) {
+		t.Errorf("GenerateServer() is not deterministic")
+	}
+}
+
+const routesSpec = `
+openapi: 3.0.0
+paths:
+  /photos/{name}.{ext}:
+    get:
+      operationId: getPhoto
+      parameters:
+      - name: name
+        in: path
+        required: true
+        schema:
+          type: string
+      - name: ext
+        in: path
+        required: true
+        schema:
+          type: string
+  /photos/{id}:
+    delete:
+      operationId: deletePhoto
+      parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+`
+
+// routesTest serves requests through the generated handler.
+const routesTest = `package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type server struct{ got string }
+
+func (s *server) GetPhoto(w http.ResponseWriter, r *http.Request, name string, ext string) {
+	s.got = fmt.Sprintf("get %s %s", name, ext)
+}
+
+func (s *server) DeletePhoto(w http.ResponseWriter, r *http.Request, id int64) {
+	s.got = fmt.Sprintf("delete %d", id)
+}
+
+func TestHandler(t *testing.T) {
+	for path, want := range map[string]string{
+		"GET /photos/cat.png":    "get cat png",
+		"GET /photos/a%20b.jpg":  "get a b jpg",
+		"DELETE /photos/42":      "delete 42",
+		"GET /photos/cat":        "",
+		"GET /photos/cat.png/hd": "",
+	} {
+		s := &server{}
+		var method, target string
+		fmt.Sscan(path, &method, &target)
+		Handler(s).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, nil))
+		if s.got != want {
+			t.Errorf("%s: got %q, want %q", path, s.got, want)
+		}
+	}
+}
+`
+
+func TestGenerateServer_Routes(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(routesSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	src, err := GenerateServer(&spec, "api", nil)
+	if err != nil {
+		t.Fatalf("GenerateServer() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":         "module api\n\ngo 1.16\n",
+		"server.go":      string(src),
+		"server_test.go": routesTest,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "test", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test error = %v\n%s", err, out)
+	}
+}
+
+func TestGenerateClient(t *testing.T) {
+	spec := loadTestSpec(t)
+
+	src, err := GenerateClient(spec, "api", nil)
+	if err != nil {
+		t.Fatalf("GenerateClient() error = %v", err)
+	}
+	pkg := typeCheck(t, src)
+
+	client := types.NewPointer(pkg.Scope().Lookup("Client").Type())
+	want := map[string]string{
+		"ListPets":    "func(ctx context.Context, params api.ListPetsParams) (*api.ListPetsResponse, error)",
+		"CreatePet":   "func(ctx context.Context, body api.Pet) (*api.CreatePetResponse, error)",
+		"ShowPetByID": "func(ctx context.Context, petID int64) (*api.ShowPetByIDResponse, error)",
+	}
+	for name, sig := range want {
+		obj, _, _ := types.LookupFieldOrMethod(client, true, pkg, name)
+		if obj == nil {
+			t.Errorf("Client.%s is not declared", name)
+			continue
+		}
+		if got := obj.Type().String(); got != sig {
+			t.Errorf("Client.%s = %s, want %s", name, got, sig)
+		}
+	}
+
+	res := pkg.Scope().Lookup("ShowPetByIDResponse").Type().Underlying().(*types.Struct)
+	fields := map[string]string{}
+	for i := 0; i < res.NumFields(); i++ {
+		fields[res.Field(i).Name()] = res.Field(i).Type().String()
+	}
+	if fields["JSON200"] != "*api.Pet" || fields["JSONDefault"] != "*api.ShowPetByIDJSONDefaultResponse" {
+		t.Errorf("ShowPetByIDResponse fields = %v", fields)
+	}
+	if pkg.Scope().Lookup("WithBearerAuth") == nil {
+		t.Errorf("WithBearerAuth is not declared")
+	}
+}
+
+const modelsSpec = `
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [available, sold-out]
+    Cat:
+      type: object
+      properties:
+        petType:
+          type: string
+    Dog:
+      type: object
+      properties:
+        petType:
+          type: string
+    Pet:
+      oneOf:
+      - $ref: '#/components/schemas/Cat'
+      - $ref: '#/components/schemas/Dog'
+      discriminator:
+        propertyName: petType
+    Money:
+      type: string
+    Owner:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: string
+          format: uuid
+        born:
+          type: string
+          format: date-time
+        pet:
+          $ref: '#/components/schemas/Pet'
+        balance:
+          $ref: '#/components/schemas/Money'
+`
+
+func TestGenerateModels(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(modelsSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	cfg := &Config{Types: map[string]string{"Money": "encoding/json.Number"}}
+
+	src, err := GenerateModels(&spec, "models", cfg)
+	if err != nil {
+		t.Fatalf("GenerateModels() error = %v", err)
+	}
+	pkg := typeCheck(t, src)
+
+	if c, ok := pkg.Scope().Lookup("StatusSoldOut").(*types.Const); !ok || c.Type().String() != "api.Status" {
+		t.Errorf("StatusSoldOut = %v, want an api.Status constant", c)
+	}
+	if pkg.Scope().Lookup("Money") != nil {
+		t.Errorf("Money is declared although it is mapped to json.Number")
+	}
+
+	owner := pkg.Scope().Lookup("Owner").Type().Underlying().(*types.Struct)
+	fields := map[string]string{}
+	for i := 0; i < owner.NumFields(); i++ {
+		fields[owner.Field(i).Name()] = owner.Field(i).Type().String()
+	}
+	want := map[string]string{
+		"ID":      "api.UUID",
+		"Born":    "*time.Time",
+		"Pet":     "*api.Pet",
+		"Balance": "*encoding/json.Number",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Owner fields = %v, want %v", fields, want)
+	}
+
+	value := pkg.Scope().Lookup("PetValue").Type().Underlying().(*types.Interface)
+	for _, variant := range []string{"Cat", "Dog"} {
+		if !types.Implements(pkg.Scope().Lookup(variant).Type(), value) {
+			t.Errorf("%s does not implement PetValue", variant)
+		}
+	}
+	ptr := types.NewPointer(pkg.Scope().Lookup("Pet").Type())
+	if obj, _, _ := types.LookupFieldOrMethod(ptr, true, pkg, "UnmarshalJSON"); obj == nil {
+		t.Errorf("Pet does not implement json.Unmarshaler")
+	}
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(modelsSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	spec.Paths = loadTestSpec(t).Paths
+
+	src, err := GenerateTypeScript(&spec, "", nil)
+	if err != nil {
+		t.Fatalf("GenerateTypeScript() error = %v", err)
+	}
+	for _, want := range []string{
+		`export type Status = "available" | "sold-out";`,
+		`export type Pet = Cat | Dog;`,
+		"export interface Owner {\n  balance?: Money;\n  born?: string;\n  id: string;\n  pet?: Pet;\n}",
+		"export interface ListPetsParams {\n  limit?: number;\n}",
+		"export class DefaultClient extends BaseClient {",
+		"async listPets(params: ListPetsParams = {}, init?: RequestInit): Promise<void> {",
+		"async createPet(body: Pet, init?: RequestInit): Promise<void> {",
+		"async showPetByID(petId: number, init?: RequestInit): Promise<Pet> {",
+		"return this.request<Pet>(\"GET\", `/pets/${encodeURIComponent(String(petId))}`, { init });",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code does not contain %q\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateEmbed(t *testing.T) {
+	src, err := GenerateEmbed("spec")
+	if err != nil {
+		t.Fatalf("GenerateEmbed() error = %v", err)
+	}
+	pkg := typeCheck(t, src)
+
+	want := map[string]string{
+		"Spec": "func() *github.com/cry999/gopenapi/pkg/openapi.OpenAPI",
+		"YAML": "func() []byte",
+		"JSON": "func() []byte",
+	}
+	for name, typ := range want {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			t.Errorf("%s is not declared", name)
+			continue
+		}
+		if got := obj.Type().String(); got != typ {
+			t.Errorf("%s = %s, want %s", name, got, typ)
+		}
+	}
+	for _, file := range []string{EmbedYAML, EmbedJSON} {
+		if !strings.Contains(string(src), "//go:embed "+file+"\n") {
+			t.Errorf("GenerateEmbed() does not embed %s", file)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config customizes the generated types. Go types are written with their
+// import path, such as `github.com/shopspring/decimal.Decimal`.
+type Config struct {
+	// Types maps component schemas to existing Go types, which are then not
+	// generated.
+	Types map[string]string `yaml:"types,omitempty"`
+	// Formats maps the formats of strings to Go types. `date-time` is
+	// time.Time and `uuid` a generated UUID type by default.
+	Formats map[string]string `yaml:"formats,omitempty"`
+}
+
+// LoadConfig ...
+func LoadConfig(filename string) (*Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %v", filename, err)
+	}
+	return &cfg, nil
+}
+
+// defaultFormats are the Go types of string formats, unless configured.
+var defaultFormats = map[string]string{
+	"date-time": "time.Time",
+	"uuid":      "UUID",
+	"byte":      "[]byte",
+}
+
+// qualify imports the package of a configured Go type and returns how the
+// type is written in the generated file.
+func (f *file) qualify(typ string) string {
+	prefix := ""
+	for _, p := range []string{"*", "[]"} {
+		for strings.HasPrefix(typ, p) {
+			prefix, typ = prefix+p, strings.TrimPrefix(typ, p)
+		}
+	}
+	dot := strings.LastIndex(typ, ".")
+	if dot < 0 || dot < strings.LastIndex(typ, "/") {
+		return prefix + typ
+	}
+	importPath := typ[:dot]
+	f.use(importPath)
+	return prefix + path.Base(importPath) + "." + typ[dot+1:]
+}
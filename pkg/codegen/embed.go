@@ -0,0 +1,68 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+)
+
+// The files embedded by the code of GenerateEmbed, which are written next to
+// it.
+const (
+	EmbedYAML = "openapi.yml"
+	EmbedJSON = "openapi.json"
+)
+
+// embedSource is the code of GenerateEmbed, formatted with the package name.
+const embedSource = `package %s
+
+import (
+	_ "embed"
+	"sync"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+var (
+	//go:embed ` + EmbedYAML + `
+	specYAML []byte
+	//go:embed ` + EmbedJSON + `
+	specJSON []byte
+
+	specOnce   sync.Once
+	specParsed *openapi.OpenAPI
+)
+
+// Spec returns the embedded document. It is parsed on the first call only,
+// and shared by all calls: it must not be modified.
+func Spec() *openapi.OpenAPI {
+	specOnce.Do(func() {
+		spec, err := openapi.Parse(specYAML)
+		if err != nil {
+			panic("failed to parse the embedded document: " + err.Error())
+		}
+		specParsed = spec
+	})
+	return specParsed
+}
+
+// YAML returns the embedded document as YAML. It must not be modified.
+func YAML() []byte {
+	return specYAML
+}
+
+// JSON returns the embedded document as JSON. It must not be modified.
+func JSON() []byte {
+	return specJSON
+}
+`
+
+// GenerateEmbed generates a file embedding the bundled document, which must
+// be written next to it as EmbedYAML and EmbedJSON, and declaring accessors
+// returning the parsed document, its YAML and its JSON.
+func GenerateEmbed(pkg string) ([]byte, error) {
+	src, err := format.Source([]byte(header + fmt.Sprintf(embedSource, pkg)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,419 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// GenerateServer generates the types of the component schemas, a
+// ServerInterface with one method per operation, and a Handler decoding the
+// parameters of requests and routing them to it.
+func GenerateServer(spec *openapi.OpenAPI, pkg string, cfg *Config) ([]byte, error) {
+	f := newFile(spec, pkg, cfg)
+	f.componentTypes()
+
+	ops := operations(spec)
+	sigs := make([]*signature, len(ops))
+	for i, op := range ops {
+		sigs[i] = f.signature(op)
+	}
+
+	for _, path := range []string{"encoding", "fmt", "net/http", "net/url", "reflect", "regexp", "sort", "strconv", "strings"} {
+		f.use(path)
+	}
+	f.printf("// ServerInterface is implemented by the handlers of the operations.\n")
+	f.printf("type ServerInterface interface {\n")
+	for i, op := range ops {
+		f.printf("%s", comment(op.name, fmt.Sprintf("handles `%s %s`.", op.method, op.path)))
+		if op.op.Summary != "" {
+			f.printf("//\n%s", comment("", op.op.Summary))
+		}
+		f.printf("%s(%s)\n", op.name, strings.Join(sigs[i].args(), ", "))
+	}
+	f.printf("}\n\n")
+
+	f.serverHandler(ops, sigs)
+	for i, op := range ops {
+		f.serverOperation(op, sigs[i])
+	}
+	f.printf("%s", serverRuntime)
+	return f.source()
+}
+
+// args declares the arguments of the methods of ServerInterface.
+func (sig *signature) args() []string {
+	args := []string{"w http.ResponseWriter", "r *http.Request"}
+	for _, p := range sig.pathParams {
+		args = append(args, p.local+" "+p.typ)
+	}
+	if sig.paramsType != "" {
+		args = append(args, "params "+sig.paramsType)
+	}
+	if sig.body != nil {
+		args = append(args, "body "+sig.body.goType())
+	}
+	return args
+}
+
+func (f *file) serverHandler(ops []*operation, sigs []*signature) {
+	baseURL := ""
+	if len(f.spec.Servers) > 0 && f.spec.Servers[0].URL != nil && f.spec.Servers[0].URL.URL != nil {
+		if path := f.spec.Servers[0].URL.Path; !strings.Contains(path, "{") {
+			baseURL = strings.TrimSuffix(path, "/")
+		}
+	}
+	f.printf("// defaultBaseURL is the path of the first server.\n")
+	f.printf("const defaultBaseURL = %q\n\n", baseURL)
+
+	// group the operations by path, literal segments first
+	byPath := map[string][]*operation{}
+	var paths []string
+	for _, op := range ops {
+		if _, ok := byPath[op.path]; !ok {
+			paths = append(paths, op.path)
+		}
+		byPath[op.path] = append(byPath[op.path], op)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := strings.Split(strings.Trim(paths[i], "/"), "/"), strings.Split(strings.Trim(paths[j], "/"), "/")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if ra, rb := segmentRank(a[k]), segmentRank(b[k]); ra != rb {
+				return ra < rb
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return paths[i] < paths[j]
+	})
+
+	f.printf("// routes are ordered so that literal segments win over templated ones.\n")
+	f.printf("var routes = []route{\n")
+	for _, path := range paths {
+		f.printf("{\nsegments: []segment{\n")
+		for _, s := range strings.Split(strings.Trim(path, "/"), "/") {
+			if s != "" {
+				f.printf("%s,\n", routeSegment(s))
+			}
+		}
+		f.printf("},\n")
+		f.printf("methods: map[string]func(*handler, http.ResponseWriter, *http.Request, map[string]string){\n")
+		for _, op := range byPath[path] {
+			f.printf("%q: (*handler).%s,\n", op.method, localName(op.name))
+		}
+		f.printf("},\n},\n")
+	}
+	f.printf("}\n\n")
+}
+
+// pathVariable matches the templated parts of a path segment.
+var pathVariable = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// routeSegment declares the segment of a route matching a segment of a
+// path: a literal one, or a pattern capturing its variables, as in `{id}` or
+// `{name}.{ext}`.
+func routeSegment(s string) string {
+	locs := pathVariable.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 {
+		return fmt.Sprintf("{literal: %q}", s)
+	}
+	var names []string
+	expr, last := "^", 0
+	for _, loc := range locs {
+		expr += regexp.QuoteMeta(s[last:loc[0]]) + "(.+?)"
+		names = append(names, fmt.Sprintf("%q", s[loc[2]:loc[3]]))
+		last = loc[1]
+	}
+	expr += regexp.QuoteMeta(s[last:]) + "$"
+	return fmt.Sprintf("{pattern: regexp.MustCompile(%q), names: []string{%s}}", expr, strings.Join(names, ", "))
+}
+
+// segmentRank orders literal segments before partly templated ones, and
+// those before wholly templated ones.
+func segmentRank(s string) int {
+	switch locs := pathVariable.FindAllStringIndex(s, -1); {
+	case len(locs) == 0:
+		return 0
+	case len(locs) == 1 && locs[0][0] == 0 && locs[0][1] == len(s):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// serverOperation generates the method of the handler binding the request
+// to the arguments of the operation.
+func (f *file) serverOperation(op *operation, sig *signature) {
+	f.printf("func (h *handler) %s(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {\n", localName(op.name))
+
+	fail := func(in, name string, err string) {
+		if name == "" {
+			f.printf("h.opts.ErrorHandler(w, r, &BindError{In: %q, Err: %s})\nreturn\n", in, err)
+			return
+		}
+		f.printf("h.opts.ErrorHandler(w, r, &BindError{In: %q, Name: %q, Err: %s})\nreturn\n", in, name, err)
+	}
+
+	args := []string{"w", "r"}
+	for _, p := range sig.pathParams {
+		f.printf("var %s %s\n", p.local, p.typ)
+		f.printf("if err := bindParam([]string{pathParams[%q]}, false, %q, &%s); err != nil {\n", p.Name, p.sep, p.local)
+		fail(p.In, p.Name, "err")
+		f.printf("}\n")
+		args = append(args, p.local)
+	}
+
+	if sig.paramsType != "" {
+		f.printf("var params %s\n", sig.paramsType)
+		for _, p := range sig.params {
+			var values string
+			switch p.In {
+			case "query":
+				values = fmt.Sprintf("queryValues(r, %q)", p.Name)
+			case "header":
+				values = fmt.Sprintf("headerValues(r, %q)", p.Name)
+			case "cookie":
+				values = fmt.Sprintf("cookieValues(r, %q)", p.Name)
+			}
+			f.printf("if values, ok := %s; ok {\n", values)
+			target := "params." + p.field
+			if !p.Required && pointer(p.typ) != p.typ {
+				f.printf("var value %s\n", p.typ)
+				target = "value"
+			}
+			f.printf("if err := bindParam(values, %v, %q, &%s); err != nil {\n", p.explode, p.sep, target)
+			fail(p.In, p.Name, "err")
+			f.printf("}\n")
+			if target == "value" {
+				f.printf("params.%s = &value\n", p.field)
+			}
+			if p.Required {
+				f.printf("} else {\n")
+				fail(p.In, p.Name, `fmt.Errorf("is required")`)
+			}
+			f.printf("}\n")
+		}
+		args = append(args, "params")
+	}
+
+	if sig.body != nil {
+		f.use("encoding/json")
+		f.printf("var body %s\n", sig.body.goType())
+		if sig.body.required {
+			f.printf("if err := json.NewDecoder(r.Body).Decode(&body); err != nil {\n")
+		} else {
+			// an empty body leaves the optional body nil
+			f.use("io")
+			f.printf("if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {\n")
+		}
+		fail("body", "", "err")
+		f.printf("}\n")
+		args = append(args, "body")
+	}
+
+	f.printf("h.si.%s(%s)\n}\n\n", op.name, strings.Join(args, ", "))
+}
+
+// serverRuntime is the part of the generated server which does not depend
+// on the document.
+const serverRuntime = `// HandlerOptions configures HandlerWithOptions.
+type HandlerOptions struct {
+	// BaseURL is the path prefix of the operations.
+	BaseURL string
+	// ErrorHandler answers the requests which cannot be bound to the
+	// arguments of their operation, with 400 Bad Request by default.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// BindError is passed to the error handler when a parameter or the body of
+// a request cannot be decoded.
+type BindError struct {
+	In   string
+	Name string
+	Err  error
+}
+
+// Error ...
+func (e *BindError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %s: %v", e.In, e.Err)
+	}
+	return fmt.Sprintf("invalid %s parameter '%s': %v", e.In, e.Name, e.Err)
+}
+
+// Handler routes requests below the path of the first server to si.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, HandlerOptions{BaseURL: defaultBaseURL})
+}
+
+// HandlerWithOptions routes requests to si.
+func HandlerWithOptions(si ServerInterface, opts HandlerOptions) http.Handler {
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+	return &handler{si: si, opts: opts}
+}
+
+type handler struct {
+	si   ServerInterface
+	opts HandlerOptions
+}
+
+type route struct {
+	segments []segment
+	methods  map[string]func(*handler, http.ResponseWriter, *http.Request, map[string]string)
+}
+
+// segment is a literal segment of a path, or a templated one whose pattern
+// captures the values of the variables named by names.
+type segment struct {
+	literal string
+	pattern *regexp.Regexp
+	names   []string
+}
+
+// ServeHTTP ...
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	if path != h.opts.BaseURL && !strings.HasPrefix(path, h.opts.BaseURL+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	segments := splitPath(strings.TrimPrefix(path, h.opts.BaseURL))
+
+	for _, rt := range routes {
+		pathParams, ok := matchRoute(rt.segments, segments)
+		if !ok {
+			continue
+		}
+		handle, ok := rt.methods[r.Method]
+		if !ok {
+			allowed := make([]string, 0, len(rt.methods))
+			for method := range rt.methods {
+				allowed = append(allowed, method)
+			}
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handle(h, w, r, pathParams)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func matchRoute(template []segment, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range template {
+		value, err := url.PathUnescape(segments[i])
+		if err != nil {
+			return nil, false
+		}
+		if segment.pattern == nil {
+			if segment.literal != value {
+				return nil, false
+			}
+			continue
+		}
+		m := segment.pattern.FindStringSubmatch(value)
+		if m == nil {
+			return nil, false
+		}
+		for j, name := range segment.names {
+			params[name] = m[j+1]
+		}
+	}
+	return params, true
+}
+
+func queryValues(r *http.Request, name string) ([]string, bool) {
+	values, ok := r.URL.Query()[name]
+	return values, ok
+}
+
+func headerValues(r *http.Request, name string) ([]string, bool) {
+	values := r.Header.Values(name)
+	return []string{strings.Join(values, ",")}, len(values) > 0
+}
+
+func cookieValues(r *http.Request, name string) ([]string, bool) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil, false
+	}
+	return []string{cookie.Value}, true
+}
+
+// bindParam decodes the values of a parameter into dst. Unless exploded,
+// arrays are sent as a single value separated by sep.
+func bindParam(values []string, explode bool, sep string, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Slice {
+		if len(values) == 0 {
+			return fmt.Errorf("is empty")
+		}
+		return bindValue(values[0], v)
+	}
+	if !explode && len(values) > 0 {
+		values = strings.Split(values[0], sep)
+	}
+	slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := bindValue(value, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func bindValue(raw string, v reflect.Value) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be a boolean")
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("cannot be decoded into %s", v.Type())
+	}
+	return nil
+}
+`
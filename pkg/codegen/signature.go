@@ -0,0 +1,156 @@
+package codegen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// signature is how an operation is exposed in Go: its path parameters as
+// arguments, the other parameters in a struct and its JSON body, if any.
+type signature struct {
+	pathParams []*param
+	// params are the query, header and cookie parameters, gathered in a
+	// struct named paramsType.
+	params     []*param
+	paramsType string
+	body       *body
+}
+
+type param struct {
+	*openapi.Parameter
+	// field is the Go name of the parameter, and local the name of the
+	// argument or variable holding it.
+	field string
+	local string
+	typ   string
+	// explode tells whether arrays are sent as several values, and sep
+	// separates their items otherwise.
+	explode bool
+	sep     string
+}
+
+type body struct {
+	mediaType string
+	typ       string
+	required  bool
+}
+
+// goType is a pointer for optional bodies.
+func (b *body) goType() string {
+	if b.required {
+		return b.typ
+	}
+	return pointer(b.typ)
+}
+
+// signature declares the types of the parameters and body of an operation.
+func (f *file) signature(op *operation) *signature {
+	sig := &signature{}
+	for _, p := range op.params {
+		prm := &param{
+			Parameter: p,
+			field:     goName(p.Name),
+			typ:       f.paramType(p, op.name+goName(p.Name)),
+			sep:       ",",
+		}
+		prm.local = localName(prm.field)
+		switch prm.local {
+		case "w", "r", "params", "body", "ctx", "err":
+			prm.local += "Param"
+		}
+
+		style := p.Style
+		if style == "" && (p.In == "query" || p.In == "cookie") {
+			style = "form"
+		}
+		prm.explode = style == "form"
+		if p.Explode != nil {
+			prm.explode = *p.Explode
+		}
+		switch style {
+		case "spaceDelimited":
+			prm.sep = " "
+		case "pipeDelimited":
+			prm.sep = "|"
+		}
+
+		if p.In == "path" {
+			sig.pathParams = append(sig.pathParams, prm)
+		} else {
+			sig.params = append(sig.params, prm)
+		}
+	}
+
+	if len(sig.params) > 0 {
+		sig.paramsType = op.name + "Params"
+		var b strings.Builder
+		b.WriteString("struct {\n")
+		for _, p := range sig.params {
+			b.WriteString(comment("", p.Description))
+			typ := p.typ
+			if !p.Required {
+				typ = pointer(typ)
+			}
+			b.WriteString(p.field + " " + typ + "\n")
+		}
+		b.WriteString("}")
+		f.declared[sig.paramsType] = true
+		f.types.WriteString(comment(sig.paramsType, "holds the query, header and cookie parameters of "+op.name+"."))
+		f.types.WriteString("type " + sig.paramsType + " " + b.String() + "\n\n")
+	}
+
+	if rb := f.spec.ResolveRequestBody(op.op.RequestBody); rb != nil {
+		mediaTypes := make([]string, 0, len(rb.Content))
+		for mediaType := range rb.Content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+		for _, mediaType := range mediaTypes {
+			media := rb.Content[mediaType]
+			if !isJSON(mediaType) || media == nil {
+				continue
+			}
+			sig.body = &body{
+				mediaType: mediaType,
+				typ:       f.goType(media.Schema, op.name+"JSONBody"),
+				required:  rb.Required,
+			}
+			break
+		}
+	}
+	return sig
+}
+
+// paramType returns the Go type of a parameter. Only primitives and arrays
+// of primitives are decoded, other parameters are kept as strings.
+func (f *file) paramType(p *openapi.Parameter, hint string) string {
+	schema := f.spec.ResolveSchema(p.Schema)
+	if schema == nil || len(p.Content) > 0 {
+		return "string"
+	}
+	if schema.Type == "array" {
+		if items := f.spec.ResolveSchema(schema.Items); items != nil && isPrimitive(items.Type) {
+			return f.goType(schema, hint)
+		}
+		return "string"
+	}
+	if isPrimitive(schema.Type) {
+		return f.goType(p.Schema, hint)
+	}
+	return "string"
+}
+
+func isPrimitive(typ string) bool {
+	switch typ {
+	case "string", "integer", "number", "boolean":
+		return true
+	}
+	return false
+}
+
+func isJSON(mediaType string) bool {
+	mediaType = strings.SplitN(mediaType, ";", 2)[0]
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,384 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+const refSchemas = "#/components/schemas/"
+
+// GenerateModels generates the types of the component schemas only.
+func GenerateModels(spec *openapi.OpenAPI, pkg string, cfg *Config) ([]byte, error) {
+	f := newFile(spec, pkg, cfg)
+	f.componentTypes()
+	return f.source()
+}
+
+// componentTypes declares a type for every schema of the components, except
+// those mapped to existing types by the config.
+func (f *file) componentTypes() {
+	if f.spec.Components == nil {
+		return
+	}
+	names := make([]string, 0, len(f.spec.Components.Schemas))
+	for name := range f.spec.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := f.cfg.Types[name]; ok {
+			continue
+		}
+		f.declareSchema(goName(name), f.spec.Components.Schemas[name])
+	}
+}
+
+// reserved tells whether a name is taken by a component schema, so that
+// inline schemas do not use it.
+func (f *file) reserved(name string) bool {
+	if f.spec.Components == nil {
+		return false
+	}
+	for component := range f.spec.Components.Schemas {
+		if goName(component) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// declareSchema declares the named type of a schema, unless it is already.
+func (f *file) declareSchema(name string, sor *openapi.SchemaOrRef) {
+	if f.declared[name] {
+		return
+	}
+	f.declared[name] = true
+
+	if f.isUnion(sor) {
+		f.declareUnion(name, sor)
+		return
+	}
+
+	var typ string
+	switch {
+	case isStruct(sor):
+		typ = f.structType(name, sor)
+	case isEnum(sor):
+		typ = f.primitiveType(&sor.Schema)
+	default:
+		typ = f.goType(sor, name)
+	}
+	// nested types are declared while the type is built, before it
+	f.types.WriteString(comment(name, sor.Description))
+	fmt.Fprintf(&f.types, "type %s %s\n\n", name, typ)
+
+	if isEnum(sor) && (typ == "string" || typ == "int32" || typ == "int64") {
+		f.enumConstants(name, sor)
+	}
+}
+
+// goType returns the Go type of a schema, declaring the types of inline
+// objects, enums and unions after hint.
+func (f *file) goType(sor *openapi.SchemaOrRef, hint string) string {
+	if sor == nil {
+		return "interface{}"
+	}
+	if sor.IsRef() {
+		if !strings.HasPrefix(sor.Ref, refSchemas) {
+			return "interface{}"
+		}
+		name := openapi.RefName(sor.Ref)
+		if typ, ok := f.cfg.Types[name]; ok {
+			return f.qualify(typ)
+		}
+		return goName(name)
+	}
+	if isStruct(sor) || isEnum(sor) || f.isUnion(sor) {
+		return f.declareInline(sor, hint)
+	}
+
+	s := &sor.Schema
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "interface{}"
+	}
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+		return f.primitiveType(s)
+	case "array":
+		return "[]" + f.goType(s.Items, hint+"Item")
+	case "object":
+		if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+			return "map[string]" + f.goType(ap.Schema, hint+"Value")
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// declareInline declares the type of an inline schema once.
+func (f *file) declareInline(sor *openapi.SchemaOrRef, hint string) string {
+	if name, ok := f.inline[sor]; ok {
+		return name
+	}
+	name := hint
+	for i := 2; f.declared[name] || f.reserved(name); i++ {
+		name = fmt.Sprintf("%s%d", hint, i)
+	}
+	f.inline[sor] = name
+	f.declareSchema(name, sor)
+	return name
+}
+
+func (f *file) primitiveType(s *openapi.Schema) string {
+	switch s.Type {
+	case "string":
+		if typ, ok := f.cfg.Formats[s.Format]; ok {
+			return f.qualify(typ)
+		}
+		switch typ := defaultFormats[s.Format]; typ {
+		case "":
+			return "string"
+		case "UUID":
+			f.declareUUID()
+			return typ
+		default:
+			return f.qualify(typ)
+		}
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "interface{}"
+}
+
+// structType builds a struct from the properties of a schema. The
+// referenced parts of `allOf` are embedded.
+func (f *file) structType(name string, sor *openapi.SchemaOrRef) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, part := range sor.AllOf {
+		if part.IsRef() && strings.HasPrefix(part.Ref, refSchemas) {
+			b.WriteString(f.goType(part, "") + "\n")
+			continue
+		}
+		f.fields(&b, name, part)
+	}
+	f.fields(&b, name, sor)
+	b.WriteString("}")
+	return b.String()
+}
+
+func (f *file) fields(b *strings.Builder, name string, sor *openapi.SchemaOrRef) {
+	if sor == nil || sor.IsRef() {
+		return
+	}
+	required := map[string]bool{}
+	for _, prop := range sor.Required {
+		required[prop] = true
+	}
+	props := make([]string, 0, len(sor.Properties))
+	for prop := range sor.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	for _, prop := range props {
+		schema := sor.Properties[prop]
+		field := goName(prop)
+		typ := f.goType(schema, name+field)
+
+		tag := prop
+		optional := !required[prop]
+		if resolved := f.spec.ResolveSchema(schema); resolved != nil && resolved.Nullable {
+			optional = true
+		}
+		if optional {
+			typ = pointer(typ)
+			tag += ",omitempty"
+		}
+		if !schema.IsRef() {
+			b.WriteString(comment("", schema.Description))
+		}
+		fmt.Fprintf(b, "%s %s `json:%q`\n", field, typ, tag)
+	}
+}
+
+// enumConstants declares a constant per value of an enum.
+func (f *file) enumConstants(name string, sor *openapi.SchemaOrRef) {
+	f.types.WriteString("// values of " + name + "\nconst (\n")
+	for _, value := range sor.Enum {
+		if value == nil {
+			continue
+		}
+		s := fmt.Sprint(value)
+		constant := name + goName(s)
+		if s != "" && (s[0] >= '0' && s[0] <= '9') {
+			// `X` prefixes names starting with a digit
+			constant = name + strings.TrimPrefix(goName(s), "X")
+		}
+		for i := 2; f.declared[constant]; i++ {
+			constant = fmt.Sprintf("%s%d", strings.TrimRight(constant, "0123456789"), i)
+		}
+		f.declared[constant] = true
+
+		literal := s
+		if sor.Type == "string" {
+			literal = fmt.Sprintf("%q", s)
+		}
+		fmt.Fprintf(&f.types, "%s %s = %s\n", constant, name, literal)
+	}
+	f.types.WriteString(")\n\n")
+}
+
+// isUnion tells whether a schema is a `oneOf` told apart by a discriminator
+// whose variants can be declared as Go types implementing an interface.
+func (f *file) isUnion(sor *openapi.SchemaOrRef) bool {
+	if sor == nil || sor.IsRef() || len(sor.OneOf) == 0 || sor.Discriminator == nil {
+		return false
+	}
+	for _, variant := range sor.OneOf {
+		if !variant.IsRef() || !strings.HasPrefix(variant.Ref, refSchemas) {
+			return false
+		}
+		if _, ok := f.cfg.Types[openapi.RefName(variant.Ref)]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// declareUnion declares a sealed interface implemented by the variants of a
+// `oneOf`, and a struct holding one of them which decodes the variant named
+// by the discriminator.
+func (f *file) declareUnion(name string, sor *openapi.SchemaOrRef) {
+	f.use("encoding/json")
+	f.use("fmt")
+
+	property := sor.Discriminator.PropertyName
+	marker := "is" + name
+	var variants []string
+	values := map[string][]string{}
+	for _, variant := range sor.OneOf {
+		typ := f.goType(variant, "")
+		variants = append(variants, typ)
+
+		for value, ref := range sor.Discriminator.Mapping {
+			if ref == variant.Ref || ref == openapi.RefName(variant.Ref) {
+				values[typ] = append(values[typ], value)
+			}
+		}
+		if len(values[typ]) == 0 {
+			// without mapping, the discriminator holds the component name
+			values[typ] = []string{openapi.RefName(variant.Ref)}
+		}
+		sort.Strings(values[typ])
+	}
+
+	b := &f.types
+	fmt.Fprintf(b, "// %sValue is implemented by the variants of %s: %s.\n", name, name, strings.Join(variants, ", "))
+	fmt.Fprintf(b, "type %sValue interface {\n%s()\n}\n\n", name, marker)
+	for _, typ := range variants {
+		fmt.Fprintf(b, "func (%s) %s() {}\n\n", typ, marker)
+	}
+
+	doc := fmt.Sprintf("is one of %s, told apart by their `%s` property.", strings.Join(variants, ", "), property)
+	if sor.Description != "" {
+		doc = sor.Description + "\n\nIt " + doc
+	}
+	b.WriteString(comment(name, doc))
+	fmt.Fprintf(b, "type %s struct {\nValue %sValue\n}\n\n", name, name)
+
+	fmt.Fprintf(b, "// MarshalJSON ...\nfunc (u %s) MarshalJSON() ([]byte, error) {\nreturn json.Marshal(u.Value)\n}\n\n", name)
+	fmt.Fprintf(b, "// UnmarshalJSON ...\nfunc (u *%s) UnmarshalJSON(data []byte) error {\n", name)
+	fmt.Fprintf(b, "var discriminator struct {\nValue string `json:%q`\n}\n", property)
+	b.WriteString("if err := json.Unmarshal(data, &discriminator); err != nil {\nreturn err\n}\n")
+	b.WriteString("switch discriminator.Value {\n")
+	for _, typ := range variants {
+		quoted := make([]string, len(values[typ]))
+		for i, value := range values[typ] {
+			quoted[i] = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(b, "case %s:\nvar v %s\nif err := json.Unmarshal(data, &v); err != nil {\nreturn err\n}\nu.Value = v\n", strings.Join(quoted, ", "), typ)
+	}
+	fmt.Fprintf(b, "default:\nreturn fmt.Errorf(\"unknown %s '%%s'\", discriminator.Value)\n}\nreturn nil\n}\n\n", property)
+}
+
+// declareUUID declares the type of `uuid` strings.
+func (f *file) declareUUID() {
+	if f.declared["UUID"] {
+		return
+	}
+	f.declared["UUID"] = true
+	f.use("encoding/hex")
+	f.use("fmt")
+	f.use("strings")
+	f.body.WriteString(uuidType)
+}
+
+const uuidType = `// UUID is a universally unique identifier such as
+// ` + "`123e4567-e89b-12d3-a456-426614174000`" + `.
+type UUID [16]byte
+
+// String ...
+func (u UUID) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// MarshalText ...
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText ...
+func (u *UUID) UnmarshalText(text []byte) error {
+	s := string(text)
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return fmt.Errorf("invalid UUID '%s'", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(strings.Replace(s, "-", "", -1))); err != nil {
+		return fmt.Errorf("invalid UUID '%s'", s)
+	}
+	return nil
+}
+
+`
+
+// pointer makes optional values distinguishable from zero values. Slices,
+// maps and interfaces are already.
+func pointer(typ string) string {
+	if strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || typ == "interface{}" {
+		return typ
+	}
+	return "*" + typ
+}
+
+// isStruct tells whether a schema is declared as a struct.
+func isStruct(sor *openapi.SchemaOrRef) bool {
+	if sor == nil || sor.IsRef() {
+		return false
+	}
+	if len(sor.AllOf) > 0 {
+		return true
+	}
+	return (sor.Type == "object" || sor.Type == "") && len(sor.Properties) > 0
+}
+
+// isEnum tells whether a schema is declared as a type with constants.
+func isEnum(sor *openapi.SchemaOrRef) bool {
+	if sor == nil || sor.IsRef() || len(sor.Enum) == 0 {
+		return false
+	}
+	return sor.Type == "string" || sor.Type == "integer"
+}
@@ -0,0 +1,657 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// tsFile is a TypeScript file being generated.
+type tsFile struct {
+	spec *openapi.OpenAPI
+	b    strings.Builder
+}
+
+func (ts *tsFile) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&ts.b, format, args...)
+}
+
+// GenerateTypeScript generates TypeScript types for the component schemas
+// and a fetch based client class per tag. The package and the config are
+// not used.
+func GenerateTypeScript(spec *openapi.OpenAPI, _ string, _ *Config) ([]byte, error) {
+	ts := &tsFile{spec: spec}
+	ts.b.WriteString(header)
+	ts.componentTypes()
+
+	ops := operations(spec)
+	for _, op := range ops {
+		ts.paramsType(op)
+	}
+	ts.printf("const defaultBaseUrl = %s;\n\n", tsString(serverURL(spec)))
+	ts.b.WriteString(tsRuntime)
+	for _, group := range tagGroups(ops) {
+		ts.clientClass(group)
+	}
+	return []byte(ts.b.String()), nil
+}
+
+// componentTypes declares an interface for every object of the components
+// and a type alias for the other schemas.
+func (ts *tsFile) componentTypes() {
+	if ts.spec.Components == nil {
+		return
+	}
+	names := make([]string, 0, len(ts.spec.Components.Schemas))
+	for name := range ts.spec.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sor := ts.spec.Components.Schemas[name]
+		ts.b.WriteString(tsDoc("", sor.Description))
+		if !sor.IsRef() && isPlainObject(&sor.Schema) && !sor.Nullable {
+			ts.printf("export interface %s %s\n\n", tsTypeName(name), ts.objectType(&sor.Schema, ""))
+			continue
+		}
+		ts.printf("export type %s = %s;\n\n", tsTypeName(name), ts.tsType(sor, ""))
+	}
+}
+
+// isPlainObject tells whether a schema can be declared as an interface.
+func isPlainObject(s *openapi.Schema) bool {
+	return len(s.Properties) > 0 && len(s.Enum) == 0 &&
+		len(s.AllOf) == 0 && len(s.OneOf) == 0 && len(s.AnyOf) == 0
+}
+
+// tsType returns the TypeScript type of a schema. indent is the indentation
+// of the line the type starts on, for object literals spanning lines.
+func (ts *tsFile) tsType(sor *openapi.SchemaOrRef, indent string) string {
+	if sor == nil {
+		return "unknown"
+	}
+	if sor.IsRef() {
+		if !strings.HasPrefix(sor.Ref, refSchemas) {
+			return "unknown"
+		}
+		return tsTypeName(openapi.RefName(sor.Ref))
+	}
+	typ := ts.baseType(&sor.Schema, indent)
+	if sor.Nullable && typ != "unknown" {
+		typ += " | null"
+	}
+	return typ
+}
+
+func (ts *tsFile) baseType(s *openapi.Schema, indent string) string {
+	switch {
+	case len(s.Enum) > 0:
+		literals := make([]string, 0, len(s.Enum))
+		for _, value := range s.Enum {
+			literals = append(literals, tsLiteral(value))
+		}
+		return strings.Join(literals, " | ")
+	case len(s.OneOf) > 0:
+		return ts.join(s.OneOf, " | ", indent)
+	case len(s.AnyOf) > 0:
+		return ts.join(s.AnyOf, " | ", indent)
+	case len(s.AllOf) > 0:
+		typ := ts.join(s.AllOf, " & ", indent)
+		if len(s.Properties) > 0 {
+			typ += " & " + ts.objectType(s, indent)
+		}
+		return typ
+	}
+
+	switch s.Type {
+	case "string":
+		if s.Format == "binary" {
+			return "globalThis.Blob"
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := ts.tsType(s.Items, indent)
+		if isCombined(item) {
+			return "Array<" + item + ">"
+		}
+		return item + "[]"
+	case "object", "":
+		if len(s.Properties) > 0 {
+			return ts.objectType(s, indent)
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+			return "Record<string, " + ts.tsType(ap.Schema, indent) + ">"
+		}
+		if s.Type == "object" {
+			return "Record<string, unknown>"
+		}
+	}
+	return "unknown"
+}
+
+// join combines the types of schemas, parenthesizing combined ones.
+func (ts *tsFile) join(schemas []*openapi.SchemaOrRef, sep, indent string) string {
+	types := make([]string, 0, len(schemas))
+	for _, sor := range schemas {
+		typ := ts.tsType(sor, indent)
+		if isCombined(typ) {
+			typ = "(" + typ + ")"
+		}
+		types = append(types, typ)
+	}
+	return strings.Join(types, sep)
+}
+
+// isCombined tells whether a type is a union or an intersection, outside of
+// the object literals and type arguments it may contain.
+func isCombined(typ string) bool {
+	depth := 0
+	for i, r := range typ {
+		switch r {
+		case '{', '(', '<', '[':
+			depth++
+		case '}', ')', '>', ']':
+			depth--
+		case '|', '&':
+			if depth == 0 && i > 0 && typ[i-1] == ' ' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// objectType returns an object literal type with the properties of a schema,
+// the optional ones being those which are not required.
+func (ts *tsFile) objectType(s *openapi.Schema, indent string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	inner := indent + "  "
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		prop := s.Properties[name]
+		b.WriteString(tsDoc(inner, ts.description(prop)))
+		b.WriteString(inner)
+		if !prop.IsRef() && prop.ReadOnly {
+			b.WriteString("readonly ")
+		}
+		b.WriteString(tsProperty(name))
+		if !required[name] {
+			b.WriteString("?")
+		}
+		fmt.Fprintf(&b, ": %s;\n", ts.tsType(prop, inner))
+	}
+	if ap := s.AdditionalProperties; ap != nil && (ap.Bool || ap.Schema != nil) {
+		// properties have to be assignable to the index signature
+		fmt.Fprintf(&b, "%s[key: string]: unknown;\n", inner)
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func (ts *tsFile) description(sor *openapi.SchemaOrRef) string {
+	if sor == nil || sor.IsRef() {
+		return ""
+	}
+	return sor.Description
+}
+
+// paramsType declares the interface of the query and header parameters of
+// an operation. Cookies are left to the browser.
+func (ts *tsFile) paramsType(op *operation) {
+	params := tsParams(op)
+	if len(params) == 0 {
+		return
+	}
+	ts.b.WriteString(tsDoc("", "Parameters of "+tsMethodName(op)+"."))
+	ts.printf("export interface %sParams {\n", op.name)
+	for _, p := range params {
+		ts.b.WriteString(tsDoc("  ", p.Description))
+		optional := "?"
+		if p.Required {
+			optional = ""
+		}
+		ts.printf("  %s%s: %s;\n", tsProperty(p.Name), optional, ts.tsType(p.Schema, "  "))
+	}
+	ts.printf("}\n\n")
+}
+
+func tsParams(op *operation) []*openapi.Parameter {
+	var params []*openapi.Parameter
+	for _, p := range op.params {
+		if p.In == "query" || p.In == "header" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// tagGroup holds the operations of a client class.
+type tagGroup struct {
+	name string
+	ops  []*operation
+}
+
+// tagGroups groups operations by their first tag, sorted by name, followed
+// by a default group for untagged operations.
+func tagGroups(ops []*operation) []*tagGroup {
+	groups := map[string]*tagGroup{}
+	var names []string
+	for _, op := range ops {
+		name := ""
+		if len(op.op.Tags) > 0 {
+			name = op.op.Tags[0]
+		}
+		group, ok := groups[name]
+		if !ok {
+			group = &tagGroup{name: name}
+			groups[name] = group
+			names = append(names, name)
+		}
+		group.ops = append(group.ops, op)
+	}
+	sort.Strings(names)
+
+	result := make([]*tagGroup, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			result = append(result, groups[name])
+		}
+	}
+	if group, ok := groups[""]; ok {
+		result = append(result, group)
+	}
+	return result
+}
+
+// clientClass declares the client of a tag, with a method per operation.
+func (ts *tsFile) clientClass(group *tagGroup) {
+	name := "DefaultClient"
+	description := "calls the untagged operations."
+	if group.name != "" {
+		name = goName(group.name) + "Client"
+		description = "calls the operations tagged " + group.name + "."
+		for _, tag := range ts.spec.Tags {
+			if tag != nil && tag.Name == group.name && tag.Description != "" {
+				description += "\n\n" + tag.Description
+			}
+		}
+	}
+	ts.b.WriteString(tsDoc("", name+" "+description))
+	ts.printf("export class %s extends BaseClient {\n", name)
+	for i, op := range group.ops {
+		if i > 0 {
+			ts.b.WriteString("\n")
+		}
+		ts.clientMethod(op)
+	}
+	ts.printf("}\n\n")
+}
+
+func (ts *tsFile) clientMethod(op *operation) {
+	var (
+		args    []string
+		path    = op.path
+		options []string
+	)
+
+	for _, p := range op.params {
+		if p.In != "path" {
+			continue
+		}
+		local := tsLocal(p.Name)
+		args = append(args, fmt.Sprintf("%s: %s", local, ts.tsType(p.Schema, "  ")))
+		path = strings.Replace(path, "{"+p.Name+"}", "${encodeURIComponent(String("+local+"))}", 1)
+	}
+
+	if rb := ts.spec.ResolveRequestBody(op.op.RequestBody); rb != nil && len(rb.Content) > 0 {
+		mediaType, typ := ts.requestBody(&rb.RequestBody)
+		optional := "?"
+		if rb.Required {
+			optional = ""
+		}
+		args = append(args, fmt.Sprintf("body%s: %s", optional, typ))
+		options = append(options, "body")
+		if !isJSON(mediaType) {
+			options = append(options, "contentType: "+tsString(mediaType))
+		}
+	}
+
+	if params := tsParams(op); len(params) > 0 {
+		required := false
+		var query, headers []string
+		for _, p := range params {
+			required = required || p.Required
+			if p.In == "query" {
+				query = append(query, ts.paramEntry(p))
+			} else {
+				headers = append(headers, ts.paramEntry(p))
+			}
+		}
+		arg := "params: " + op.name + "Params"
+		if !required {
+			arg += " = {}"
+		}
+		args = append(args, arg)
+		if len(query) > 0 {
+			options = append(options, "query: { "+strings.Join(query, ", ")+" }")
+		}
+		if len(headers) > 0 {
+			options = append(options, "headers: { "+strings.Join(headers, ", ")+" }")
+		}
+	}
+	args = append(args, "init?: RequestInit")
+	options = append(options, "init")
+
+	doc := strings.TrimSpace(op.op.Summary + "\n\n" + op.op.Description)
+	if op.op.Deprecated {
+		doc += "\n\n@deprecated"
+	}
+	ts.b.WriteString(tsDoc("  ", doc))
+	result := ts.resultType(op)
+	ts.printf("  async %s(%s): Promise<%s> {\n", tsMethodName(op), strings.Join(args, ", "), result)
+	ts.printf("    return this.request<%s>(%s, `%s`, { %s });\n", result, tsString(op.method), path, strings.Join(options, ", "))
+	ts.printf("  }\n")
+}
+
+// requestBody returns the media type and type of a request body, preferring
+// JSON.
+func (ts *tsFile) requestBody(rb *openapi.RequestBody) (string, string) {
+	mediaTypes := make([]string, 0, len(rb.Content))
+	for mediaType := range rb.Content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if media := rb.Content[mediaType]; isJSON(mediaType) && media != nil {
+			return mediaType, ts.tsType(media.Schema, "  ")
+		}
+	}
+	return mediaTypes[0], "BodyInit"
+}
+
+// resultType is the union of the types of the successful responses, void
+// standing for those without JSON content.
+func (ts *tsFile) resultType(op *operation) string {
+	if op.op.Responses == nil {
+		return "unknown"
+	}
+	codes := make([]string, 0, len(*op.op.Responses))
+	for code := range *op.op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	var types []string
+	seen := map[string]bool{}
+	for _, code := range codes {
+		typ := "void"
+		if res := ts.spec.ResolveResponse((*op.op.Responses)[code]); res != nil {
+			if schema := jsonSchema(res.Content); schema != nil {
+				typ = ts.tsType(schema, "  ")
+			}
+		}
+		if !seen[typ] {
+			seen[typ] = true
+			types = append(types, typ)
+		}
+	}
+	if len(types) == 0 {
+		return "unknown"
+	}
+	return strings.Join(types, " | ")
+}
+
+// paramEntry returns the entry of a parameter in the query or headers of a
+// request. Arrays are joined unless exploded into repeated query parameters,
+// and objects are spread into a parameter per property when exploded.
+func (ts *tsFile) paramEntry(p *openapi.Parameter) string {
+	value := "params." + p.Name
+	if !isIdentifier(p.Name) {
+		value = "params[" + tsString(p.Name) + "]"
+	}
+	entry := tsProperty(p.Name) + ": " + value
+
+	schema := ts.spec.ResolveSchema(p.Schema)
+	if schema == nil || (schema.Type != "array" && schema.Type != "object") {
+		return entry
+	}
+	explode := p.Style == "" || p.Style == "form"
+	if p.Explode != nil {
+		explode = *p.Explode
+	}
+	sep := ","
+	switch p.Style {
+	case "spaceDelimited":
+		sep = " "
+	case "pipeDelimited":
+		sep = "|"
+	}
+
+	switch {
+	case p.Style == "deepObject":
+		return "...deepObject(" + tsString(p.Name) + ", " + value + ")"
+	case p.In == "query" && explode && schema.Type == "object":
+		return "..." + value
+	case p.In == "query" && explode:
+		return entry
+	case schema.Type == "object":
+		return entry + " && Object.entries(" + value + ").flat().join(" + tsString(sep) + ")"
+	}
+	return entry + "?.join(" + tsString(sep) + ")"
+}
+
+// tsTypeName returns the name of the type of a component schema. Names of the
+// runtime get a suffix, while the DOM classes it uses are qualified by
+// globalThis so that schemas such as Error or Response keep their name.
+func tsTypeName(name string) string {
+	name = goName(name)
+	switch name {
+	case "ClientOptions", "ApiError", "RequestOptions", "BaseClient":
+		name += "Model"
+	}
+	return name
+}
+
+func tsMethodName(op *operation) string {
+	return localName(op.name)
+}
+
+// tsLocal returns the name of a variable for a parameter.
+func tsLocal(name string) string {
+	if isIdentifier(name) && !tsReserved[name] {
+		return name
+	}
+	local := localName(goName(name))
+	if tsReserved[local] {
+		local += "_"
+	}
+	return local
+}
+
+// tsProperty returns a property name, quoted unless it is an identifier.
+func tsProperty(name string) string {
+	if isIdentifier(name) {
+		return name
+	}
+	return tsString(name)
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && r != '$' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') &&
+			(i == 0 || !(r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "let": true, "static": true, "yield": true,
+	"await": true, "implements": true, "interface": true, "package": true,
+	"private": true, "protected": true, "public": true,
+	// names of the generated methods
+	"body": true, "params": true, "init": true,
+}
+
+// tsLiteral returns a value as a literal type.
+func tsLiteral(v interface{}) string {
+	b, err := json.Marshal(openapi.NormalizeAny(v))
+	if err != nil {
+		return "unknown"
+	}
+	return string(b)
+}
+
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// tsDoc formats a description as a JSDoc comment.
+func tsDoc(indent, text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "*/", "*\\/"))
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return indent + "/** " + text + " */\n"
+	}
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
+
+// tsRuntime is the part of the generated client which does not depend on
+// the document.
+const tsRuntime = `/** ClientOptions configures the clients. */
+export interface ClientOptions {
+  /** Base URL of the API, the first server of the document by default. */
+  baseUrl?: string;
+  /** fetch implementation, the global one by default. */
+  fetch?: typeof fetch;
+  /** Headers sent with every request, such as credentials. */
+  headers?: Record<string, string> | (() => Record<string, string> | Promise<Record<string, string>>);
+}
+
+/** ApiError is thrown for responses whose status is not successful. */
+export class ApiError extends globalThis.Error {
+  readonly status: number;
+  /** Body of the response, parsed if JSON. */
+  readonly body: unknown;
+  readonly response: globalThis.Response;
+
+  constructor(response: globalThis.Response, body: unknown) {
+    super(` + "`" + `request failed with status ${response.status}` + "`" + `);
+    this.name = "ApiError";
+    this.status = response.status;
+    this.body = body;
+    this.response = response;
+  }
+}
+
+interface RequestOptions {
+  query?: Record<string, unknown>;
+  headers?: Record<string, unknown>;
+  body?: unknown;
+  contentType?: string;
+  init?: RequestInit;
+}
+
+function deepObject(name: string, value: object | undefined): Record<string, unknown> {
+  const entries: Record<string, unknown> = {};
+  for (const [key, v] of Object.entries(value ?? {})) {
+    entries[` + "`" + `${name}[${key}]` + "`" + `] = v;
+  }
+  return entries;
+}
+
+class BaseClient {
+  protected readonly options: ClientOptions;
+
+  constructor(options: ClientOptions = {}) {
+    this.options = options;
+  }
+
+  protected async request<T>(method: string, path: string, opts: RequestOptions): Promise<T> {
+    let url = (this.options.baseUrl ?? defaultBaseUrl).replace(/\/+$/, "") + path;
+    const query = new globalThis.URLSearchParams();
+    for (const [name, value] of Object.entries(opts.query ?? {})) {
+      for (const v of Array.isArray(value) ? value : [value]) {
+        if (v !== undefined && v !== null) {
+          query.append(name, String(v));
+        }
+      }
+    }
+    if (query.toString() !== "") {
+      url += "?" + query.toString();
+    }
+
+    const defaults = typeof this.options.headers === "function" ? await this.options.headers() : this.options.headers;
+    const headers = new globalThis.Headers(defaults);
+    for (const [name, value] of Object.entries(opts.headers ?? {})) {
+      if (value !== undefined && value !== null) {
+        headers.set(name, String(value));
+      }
+    }
+    let body: BodyInit | undefined;
+    if (opts.body !== undefined) {
+      if (opts.contentType === undefined) {
+        headers.set("Content-Type", "application/json");
+        body = JSON.stringify(opts.body);
+      } else {
+        // multipart bodies get their content type, with its boundary, from fetch
+        if (!(opts.body instanceof globalThis.FormData)) {
+          headers.set("Content-Type", opts.contentType);
+        }
+        body = opts.body as BodyInit;
+      }
+    }
+
+    const doFetch = this.options.fetch ?? fetch;
+    const res = await doFetch(url, { ...opts.init, method, headers, body });
+    const text = await res.text();
+    let data: unknown = text === "" ? undefined : text;
+    if (text !== "" && /\bjson\b/.test(res.headers.get("Content-Type") ?? "")) {
+      data = JSON.parse(text);
+    }
+    if (!res.ok) {
+      throw new ApiError(res, data);
+    }
+    return data as T;
+  }
+}
+
+`
@@ -0,0 +1,155 @@
+// Package config reads gopenapi.yml, which holds the settings of the
+// commands run in a repository.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cry999/gopenapi/pkg/codegen"
+	"github.com/cry999/gopenapi/pkg/filter"
+	"github.com/cry999/gopenapi/pkg/lint"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+// Filename is the name of the configuration file.
+const Filename = "gopenapi.yml"
+
+// Config holds the settings of the commands, which their flags override.
+// Relative paths are relative to the directory of the configuration file.
+type Config struct {
+	// Project is the project directory.
+	Project string `yaml:"project,omitempty"`
+	// Layout names the directories and files of the project.
+	Layout   openapi.Layout `yaml:"layout,omitempty"`
+	Bundle   Bundle         `yaml:"bundle,omitempty"`
+	Lint     Lint           `yaml:"lint,omitempty"`
+	Generate Generate       `yaml:"generate,omitempty"`
+}
+
+// Bundle holds the settings of the bundle command.
+type Bundle struct {
+	Output string `yaml:"output,omitempty"`
+	// Format is either openapi or swagger2.
+	Format string `yaml:"format,omitempty"`
+	// Targets are the variants of the bundled document, by name.
+	Targets map[string]*Target `yaml:"targets,omitempty"`
+}
+
+// Target is a variant of the bundled document, such as its public part.
+type Target struct {
+	// Output is the file the variant is written to.
+	Output string `yaml:"output"`
+	// Format is the format of the bundle unless set.
+	Format string `yaml:"format,omitempty"`
+	// The operations and schemas of the variant.
+	filter.Filter `yaml:",inline"`
+}
+
+// Lint holds the settings of the validate command.
+type Lint struct {
+	// Rules enables or disables rules by name. Rules are enabled unless
+	// disabled.
+	Rules map[string]bool `yaml:"rules,omitempty"`
+}
+
+// Generate holds the settings of the generate commands.
+type Generate struct {
+	// Package is the package name of the generated code.
+	Package string `yaml:"package,omitempty"`
+	// Outputs maps generators, such as server or models, to the files they
+	// generate.
+	Outputs map[string]string `yaml:"outputs,omitempty"`
+	// The types and formats mapped to existing Go types.
+	codegen.Config `yaml:",inline"`
+}
+
+// Find looks for the configuration file in dir and its parents, and returns
+// its name, or an empty string when there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		filename := filepath.Join(dir, Filename)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a configuration file.
+func Load(filename string) (*Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %v", filename, err)
+	}
+	if err := cfg.Layout.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: layout: %v", filename, err)
+	}
+	for rule := range cfg.Lint.Rules {
+		if !contains(lint.Rules, rule) {
+			return nil, fmt.Errorf("%s: lint: unknown rule '%s'", filename, rule)
+		}
+	}
+
+	dir := filepath.Dir(filename)
+	cfg.Project = resolve(dir, cfg.Project)
+	cfg.Bundle.Output = resolve(dir, cfg.Bundle.Output)
+	for name, target := range cfg.Bundle.Targets {
+		if target == nil || target.Output == "" {
+			return nil, fmt.Errorf("%s: bundle: target '%s' has no output", filename, name)
+		}
+		if err := target.Filter.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: bundle: target '%s': %v", filename, name, err)
+		}
+		target.Output = resolve(dir, target.Output)
+	}
+	for generator, output := range cfg.Generate.Outputs {
+		cfg.Generate.Outputs[generator] = resolve(dir, output)
+	}
+	return &cfg, nil
+}
+
+// LintRules lists the enabled rules.
+func (c *Config) LintRules() []string {
+	var rules []string
+	for _, rule := range lint.Rules {
+		if enabled, ok := c.Lint.Rules[rule]; !ok || enabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// resolve makes a relative path relative to dir.
+func resolve(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, Filename), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{root, sub} {
+		got, err := Find(dir)
+		if err != nil {
+			t.Fatalf("Find(%s) error = %v", dir, err)
+		}
+		if want := filepath.Join(root, Filename); got != want {
+			t.Errorf("Find(%s) = %s, want %s", dir, got, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `
+project: api
+layout:
+  components: shared
+  extension: .yaml
+bundle:
+  output: dist/openapi.yml
+  format: swagger2
+  targets:
+    public:
+      output: dist/public.yml
+      excludeInternal: true
+lint:
+  rules:
+    operation-ids: false
+generate:
+  package: petapi
+  outputs:
+    server: /abs/server.go
+  types:
+    Money: github.com/shopspring/decimal.Decimal
+`},
+		{name: "unknown field", content: "bundel: {}\n", wantErr: true},
+		{name: "target without output", content: "bundle:\n  targets:\n    public: {}\n", wantErr: true},
+		{name: "invalid target pattern", content: "bundle:\n  targets:\n    public:\n      output: a.yml\n      excludePaths: ['/a/[']\n", wantErr: true},
+		{name: "unknown rule", content: "lint:\n  rules:\n    nope: false\n", wantErr: true},
+		{name: "unsupported extension", content: "layout:\n  extension: .json\n", wantErr: true},
+		{name: "same names", content: "layout:\n  info: tags\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, Filename)
+			if err := ioutil.WriteFile(filename, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if want := filepath.Join(dir, "api"); cfg.Project != want {
+				t.Errorf("Project = %s, want %s", cfg.Project, want)
+			}
+			if want := filepath.Join(dir, "dist", "openapi.yml"); cfg.Bundle.Output != want {
+				t.Errorf("Bundle.Output = %s, want %s", cfg.Bundle.Output, want)
+			}
+			if public := cfg.Bundle.Targets["public"]; public.Output != filepath.Join(dir, "dist", "public.yml") || !public.ExcludeInternal {
+				t.Errorf("Bundle.Targets[public] = %+v", public)
+			}
+			if got := cfg.Generate.Outputs["server"]; got != "/abs/server.go" {
+				t.Errorf("Generate.Outputs[server] = %s, want /abs/server.go", got)
+			}
+			if got := cfg.Generate.Types["Money"]; got != "github.com/shopspring/decimal.Decimal" {
+				t.Errorf("Generate.Types[Money] = %s", got)
+			}
+			if got := cfg.Layout.Complete().Schemas; got != "schemas" {
+				t.Errorf("Layout.Schemas = %s, want schemas", got)
+			}
+			want := []string{"info", "refs", "responses", "path-params", "security"}
+			if got := cfg.LintRules(); !reflect.DeepEqual(got, want) {
+				t.Errorf("LintRules() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// untagged is the group of operations without any tag.
+const untagged = "Other"
+
+// WriteChangelog renders the changes as markdown release notes grouped by tag
+// and operation. Operations with several tags are listed under each of them.
+func WriteChangelog(w io.Writer, title string, changes []*Change) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "No API changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	if n := len(Breaking(changes)); n > 0 {
+		fmt.Fprintf(&b, "**%d breaking changes.**\n\n", n)
+	}
+
+	groups := map[string][]*Change{}
+	for _, c := range changes {
+		tags := c.Tags
+		if len(tags) == 0 {
+			tags = []string{untagged}
+		}
+		for _, tag := range tags {
+			groups[tag] = append(groups[tag], c)
+		}
+	}
+
+	var tags []string
+	for tag := range groups {
+		if tag != untagged {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	if _, ok := groups[untagged]; ok {
+		tags = append(tags, untagged)
+	}
+
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "## %s\n", tag)
+
+		operation := ""
+		for _, c := range groups[tag] {
+			if c.Operation() != operation {
+				operation = c.Operation()
+				fmt.Fprintf(&b, "\n### `%s`", operation)
+				if c.Summary != "" {
+					fmt.Fprintf(&b, " %s", c.Summary)
+				}
+				fmt.Fprintf(&b, "\n\n")
+			}
+
+			line := strings.ToUpper(c.Message[:1]) + c.Message[1:]
+			if c.Location != "" {
+				line += fmt.Sprintf(" (`%s`)", c.Location)
+			}
+			if c.Breaking {
+				line = "**Breaking:** " + line
+			}
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	_, err := io.WriteString(w, strings.TrimSuffix(b.String(), "\n"))
+	return err
+}
@@ -0,0 +1,317 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// ChangeType ...
+type ChangeType string
+
+// change types
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+	// Unresolved reports a reference which cannot be resolved, so that
+	// what it refers to cannot be compared.
+	Unresolved ChangeType = "unresolved"
+)
+
+// Change is a single semantic difference between two documents.
+type Change struct {
+	Path     string     `json:"path,omitempty"`
+	Method   string     `json:"method,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	Summary  string     `json:"summary,omitempty"`
+	Location string     `json:"location,omitempty"`
+	Type     ChangeType `json:"type"`
+	Breaking bool       `json:"breaking"`
+	Message  string     `json:"message"`
+}
+
+// Operation returns `METHOD /path`, or only the path for path level changes.
+func (c *Change) Operation() string {
+	if c.Method == "" {
+		return c.Path
+	}
+	return strings.ToUpper(c.Method) + " " + c.Path
+}
+
+// Compare returns the changes needed to go from base to revision. Changes are
+// ordered by path, method and location.
+func Compare(base, revision *openapi.OpenAPI) []*Change {
+	d := &differ{base: base, revision: revision}
+
+	for _, path := range unionKeys(base.Paths, revision.Paths) {
+		baseItem, revItem := base.Paths[path], revision.Paths[path]
+		switch {
+		case baseItem == nil && !hasOperations(revItem):
+			d.add(&Change{Path: path, Type: Added, Message: "path added"})
+		case revItem == nil && !hasOperations(baseItem):
+			d.add(&Change{Path: path, Type: Removed, Breaking: true, Message: "path removed"})
+		default:
+			// operations of added or removed paths are reported one by one
+			// so that they can be grouped by tag
+			if baseItem == nil {
+				baseItem = &openapi.PathItem{}
+			}
+			if revItem == nil {
+				revItem = &openapi.PathItem{}
+			}
+			d.pathItem(path, baseItem, revItem)
+		}
+	}
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return methodIndex(a.Method) < methodIndex(b.Method)
+		}
+		return a.Location < b.Location
+	})
+	return d.changes
+}
+
+// Breaking filters the breaking changes.
+func Breaking(changes []*Change) []*Change {
+	var breaking []*Change
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+type differ struct {
+	base, revision *openapi.OpenAPI
+	changes        []*Change
+
+	// context of the operation being compared
+	path, method, summary string
+	tags                  []string
+}
+
+func (d *differ) add(c *Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) change(location string, typ ChangeType, breaking bool, format string, args ...interface{}) {
+	d.add(&Change{
+		Path:     d.path,
+		Method:   d.method,
+		Tags:     d.tags,
+		Summary:  d.summary,
+		Location: location,
+		Type:     typ,
+		Breaking: breaking,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (d *differ) pathItem(path string, base, revision *openapi.PathItem) {
+	for _, method := range openapi.Methods {
+		baseOp, revOp := base.Operation(method), revision.Operation(method)
+		switch {
+		case baseOp == nil && revOp == nil:
+			continue
+		case baseOp == nil:
+			d.add(&Change{Path: path, Method: method, Tags: revOp.Tags, Summary: revOp.Summary, Type: Added, Message: "operation added"})
+		case revOp == nil:
+			d.add(&Change{Path: path, Method: method, Tags: baseOp.Tags, Summary: baseOp.Summary, Type: Removed, Breaking: true, Message: "operation removed"})
+		default:
+			d.path, d.method, d.summary, d.tags = path, method, revOp.Summary, revOp.Tags
+			d.operation(base, baseOp, revision, revOp)
+			d.path, d.method, d.summary, d.tags = "", "", "", nil
+		}
+	}
+}
+
+func (d *differ) operation(baseItem *openapi.PathItem, base *openapi.Operation, revItem *openapi.PathItem, revision *openapi.Operation) {
+	if !base.Deprecated && revision.Deprecated {
+		d.change("deprecated", Modified, false, "operation deprecated")
+	}
+	if base.OperationID != revision.OperationID {
+		d.change("operationId", Modified, false, "operationId changed from '%s' to '%s'", base.OperationID, revision.OperationID)
+	}
+
+	d.parameters(d.base.OperationParameters(baseItem, base), d.revision.OperationParameters(revItem, revision))
+	d.requestBody(base.RequestBody, revision.RequestBody)
+	d.responses(base.Responses, revision.Responses)
+}
+
+func (d *differ) parameters(base, revision []*openapi.Parameter) {
+	key := func(p *openapi.Parameter) string {
+		if p.In == "header" {
+			// header names are case insensitive
+			return p.In + "." + strings.ToLower(p.Name)
+		}
+		return p.In + "." + p.Name
+	}
+	baseParams := map[string]*openapi.Parameter{}
+	for _, p := range base {
+		baseParams[key(p)] = p
+	}
+	revParams := map[string]*openapi.Parameter{}
+	for _, p := range revision {
+		revParams[key(p)] = p
+	}
+
+	for _, k := range unionKeys(baseParams, revParams) {
+		location := "parameters." + k
+		b, r := baseParams[k], revParams[k]
+		switch {
+		case b == nil && r.Required:
+			d.change(location, Added, true, "required %s parameter '%s' added", r.In, r.Name)
+		case b == nil:
+			d.change(location, Added, false, "optional %s parameter '%s' added", r.In, r.Name)
+		case r == nil:
+			d.change(location, Removed, true, "%s parameter '%s' removed", b.In, b.Name)
+		default:
+			if !b.Required && r.Required {
+				d.change(location, Modified, true, "%s parameter '%s' became required", r.In, r.Name)
+			}
+			if b.Required && !r.Required {
+				d.change(location, Modified, false, "%s parameter '%s' became optional", r.In, r.Name)
+			}
+			if b.Style != r.Style || !reflect.DeepEqual(b.Explode, r.Explode) {
+				d.change(location, Modified, true, "serialization of %s parameter '%s' changed", r.In, r.Name)
+			}
+			d.schema(location+".schema", b.Schema, r.Schema, request)
+		}
+	}
+}
+
+func (d *differ) requestBody(baseRef, revisionRef *openapi.RequestBodyOrRef) {
+	const location = "requestBody"
+	base, revision := d.base.ResolveRequestBody(baseRef), d.revision.ResolveRequestBody(revisionRef)
+	baseUnresolved, revisionUnresolved := baseRef != nil && base == nil, revisionRef != nil && revision == nil
+	if baseUnresolved {
+		d.unresolved(location, baseRef.Ref, true)
+	}
+	if revisionUnresolved {
+		d.unresolved(location, revisionRef.Ref, false)
+	}
+	if baseUnresolved || revisionUnresolved {
+		return
+	}
+	switch {
+	case base == nil && revision == nil:
+		return
+	case base == nil:
+		d.change(location, Added, revision.Required, "request body added")
+		return
+	case revision == nil:
+		d.change(location, Removed, true, "request body removed")
+		return
+	}
+
+	if !base.Required && revision.Required {
+		d.change(location, Modified, true, "request body became required")
+	}
+	d.content(location, base.Content, revision.Content, request)
+}
+
+func (d *differ) responses(base, revision *openapi.Responses) {
+	var b, r openapi.Responses
+	if base != nil {
+		b = *base
+	}
+	if revision != nil {
+		r = *revision
+	}
+
+	for _, code := range unionKeys(b, r) {
+		location := "responses." + code
+		switch {
+		case b[code] == nil:
+			d.change(location, Added, false, "response %s added", code)
+		case r[code] == nil:
+			d.change(location, Removed, true, "response %s removed", code)
+		default:
+			baseRes, revRes := d.base.ResolveResponse(b[code]), d.revision.ResolveResponse(r[code])
+			if baseRes == nil {
+				d.unresolved(location, b[code].Ref, true)
+			}
+			if revRes == nil {
+				d.unresolved(location, r[code].Ref, false)
+			}
+			if baseRes == nil || revRes == nil {
+				continue
+			}
+			d.content(location, baseRes.Content, revRes.Content, response)
+		}
+	}
+}
+
+// unresolved reports a reference of the base or the revision which cannot be
+// resolved. Those of the revision are breaking, as clients cannot rely on
+// what they refer to.
+func (d *differ) unresolved(location, ref string, inBase bool) {
+	if inBase {
+		d.change(location, Unresolved, false, "reference '%s' of the base cannot be resolved", ref)
+		return
+	}
+	d.change(location, Unresolved, true, "reference '%s' cannot be resolved", ref)
+}
+
+func (d *differ) content(location string, base, revision map[string]*openapi.MediaType, dir direction) {
+	for _, mime := range unionKeys(base, revision) {
+		loc := location + ".content." + mime
+		b, r := base[mime], revision[mime]
+		switch {
+		case b == nil:
+			d.change(loc, Added, false, "media type '%s' added", mime)
+		case r == nil:
+			d.change(loc, Removed, true, "media type '%s' removed", mime)
+		default:
+			d.schema(loc+".schema", b.Schema, r.Schema, dir)
+		}
+	}
+}
+
+// unionKeys returns the sorted union of the keys of two maps with string keys.
+func unionKeys(a, b interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []interface{}{a, b} {
+		v := reflect.ValueOf(m)
+		if !v.IsValid() {
+			continue
+		}
+		for _, key := range v.MapKeys() {
+			if !seen[key.String()] {
+				seen[key.String()] = true
+				keys = append(keys, key.String())
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hasOperations(item *openapi.PathItem) bool {
+	for _, method := range openapi.Methods {
+		if item.Operation(method) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func methodIndex(method string) int {
+	for i, m := range openapi.Methods {
+		if m == method {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func mustLoad(t *testing.T, doc string) *openapi.OpenAPI {
+	t.Helper()
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(doc)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return &spec
+}
+
+func TestCompare(t *testing.T) {
+	base := mustLoad(t, `
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: status
+        in: query
+        schema:
+          type: string
+          enum: [available, sold]
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        "404":
+          description: not found
+  /stores:
+    get:
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+`)
+	revision := mustLoad(t, `
+paths:
+  /pets:
+    get:
+      parameters:
+      - name: status
+        in: query
+        schema:
+          type: string
+          enum: [available]
+      - name: owner
+        in: query
+        required: true
+        schema:
+          type: string
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /owners:
+    get:
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: string
+        name:
+          type: string
+`)
+
+	want := []struct {
+		operation string
+		location  string
+		breaking  bool
+	}{
+		{"GET /owners", "", false},
+		{"GET /pets", "parameters.query.owner", true},
+		{"GET /pets", "parameters.query.status.schema.enum", true},
+		{"GET /pets", "responses.200.content.application/json.schema.properties.id.type", true},
+		{"GET /pets", "responses.200.content.application/json.schema.properties.name", false},
+		{"GET /pets", "responses.404", true},
+		{"GET /stores", "", true},
+	}
+
+	got := Compare(base, revision)
+	if len(got) != len(want) {
+		for _, c := range got {
+			t.Logf("%s %s: %s", c.Operation(), c.Location, c.Message)
+		}
+		t.Fatalf("len(Compare()) = %d, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Operation() != w.operation || got[i].Location != w.location || got[i].Breaking != w.breaking {
+			t.Errorf("Compare()[%d] = %s %s (breaking %v), want %s %s (breaking %v)",
+				i, got[i].Operation(), got[i].Location, got[i].Breaking, w.operation, w.location, w.breaking)
+		}
+	}
+}
+
+func TestCompare_Unresolved(t *testing.T) {
+	base := mustLoad(t, `
+paths:
+  /pets:
+    post:
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+      responses:
+        "200":
+          $ref: '#/components/responses/Pet'
+components:
+  requestBodies:
+    Pet:
+      content:
+        application/json: {}
+`)
+	revision := mustLoad(t, `
+paths:
+  /pets:
+    post:
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+      responses:
+        "200":
+          $ref: '#/components/responses/Pet'
+`)
+
+	got := Compare(base, revision)
+	want := []struct {
+		location string
+		breaking bool
+	}{
+		{"requestBody", true},
+		{"responses.200", false},
+		{"responses.200", true},
+	}
+	if len(got) != len(want) {
+		for _, c := range got {
+			t.Logf("%s %s: %s", c.Operation(), c.Location, c.Message)
+		}
+		t.Fatalf("len(Compare()) = %d, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Type != Unresolved || got[i].Location != w.location || got[i].Breaking != w.breaking {
+			t.Errorf("Compare()[%d] = %s %s (breaking %v), want unresolved %s (breaking %v)",
+				i, got[i].Type, got[i].Location, got[i].Breaking, w.location, w.breaking)
+		}
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	changes := []*Change{
+		{Path: "/pets", Method: "get", Tags: []string{"Pets"}, Summary: "List pets", Location: "responses.404", Type: Removed, Breaking: true, Message: "response 404 removed"},
+		{Path: "/pets", Method: "post", Type: Added, Message: "operation added"},
+	}
+
+	var b strings.Builder
+	if err := WriteChangelog(&b, "1.1.0", changes); err != nil {
+		t.Fatalf("WriteChangelog() error = %v", err)
+	}
+
+	want := "# 1.1.0\n\n" +
+		"**1 breaking changes.**\n\n" +
+		"## Pets\n\n" +
+		"### `GET /pets` List pets\n\n" +
+		"- **Breaking:** Response 404 removed (`responses.404`)\n\n" +
+		"## Other\n\n" +
+		"### `POST /pets`\n\n" +
+		"- Operation added\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteChangelog() = %q, want %q", got, want)
+	}
+}
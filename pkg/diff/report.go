@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes one line per change.
+func WriteText(w io.Writer, changes []*Change) error {
+	for _, c := range changes {
+		mark := " "
+		if c.Breaking {
+			mark = "!"
+		}
+		location := c.Operation()
+		if c.Location != "" {
+			location += " " + c.Location
+		}
+		if _, err := fmt.Fprintf(w, "%s %-8s %s: %s\n", mark, c.Type, location, c.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMarkdown writes the changes as a markdown document suitable for pull
+// request comments, breaking changes first.
+func WriteMarkdown(w io.Writer, changes []*Change) error {
+	var b strings.Builder
+
+	breaking := Breaking(changes)
+	fmt.Fprintf(&b, "## API changes\n\n")
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "No changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "%d changes, %d breaking.\n", len(changes), len(breaking))
+
+	for _, section := range []struct {
+		title    string
+		breaking bool
+	}{
+		{"Breaking changes", true},
+		{"Non-breaking changes", false},
+	} {
+		var rows []*Change
+		for _, c := range changes {
+			if c.Breaking == section.breaking {
+				rows = append(rows, c)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+		fmt.Fprintf(&b, "| Operation | Location | Change |\n")
+		fmt.Fprintf(&b, "| --- | --- | --- |\n")
+		for _, c := range rows {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", c.Operation(), markdownCode(c.Location), escapeMarkdown(c.Message))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON ...
+func WriteJSON(w io.Writer, changes []*Change) error {
+	if changes == nil {
+		changes = []*Change{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Breaking int       `json:"breaking"`
+		Changes  []*Change `json:"changes"`
+	}{
+		Breaking: len(Breaking(changes)),
+		Changes:  changes,
+	})
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
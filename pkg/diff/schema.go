@@ -0,0 +1,205 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// direction tells whether a schema describes data sent by the client or
+// returned by the server. Narrowing a request schema breaks clients, while
+// for responses it is widening that does.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+func (d *differ) schema(location string, base, revision *openapi.SchemaOrRef, dir direction) {
+	d.compareSchema(location, base, revision, dir, map[[2]*openapi.SchemaOrRef]bool{})
+}
+
+func (d *differ) compareSchema(location string, base, revision *openapi.SchemaOrRef, dir direction, visited map[[2]*openapi.SchemaOrRef]bool) {
+	base, revision = d.base.ResolveSchema(base), d.revision.ResolveSchema(revision)
+	if base == nil || revision == nil {
+		if base != revision {
+			d.change(location, Modified, true, "schema changed")
+		}
+		return
+	}
+
+	// recursive schemas refer to themselves through references
+	pair := [2]*openapi.SchemaOrRef{base, revision}
+	if visited[pair] {
+		return
+	}
+	visited[pair] = true
+
+	if base.Type != revision.Type {
+		d.change(location+".type", Modified, true, "type changed from '%s' to '%s'", base.Type, revision.Type)
+		return
+	}
+	if base.Format != revision.Format {
+		d.change(location+".format", Modified, true, "format changed from '%s' to '%s'", base.Format, revision.Format)
+	}
+	if base.Nullable && !revision.Nullable {
+		d.change(location+".nullable", Modified, dir == request, "no longer nullable")
+	}
+	if !base.Nullable && revision.Nullable {
+		d.change(location+".nullable", Modified, dir == response, "became nullable")
+	}
+
+	d.enum(location+".enum", base.Enum, revision.Enum, dir)
+	d.limits(location, &base.Schema, &revision.Schema, dir)
+	d.properties(location, &base.Schema, &revision.Schema, dir, visited)
+
+	if base.Items != nil || revision.Items != nil {
+		d.compareSchema(location+".items", base.Items, revision.Items, dir, visited)
+	}
+	if base.AdditionalProperties != nil && revision.AdditionalProperties != nil &&
+		base.AdditionalProperties.Schema != nil && revision.AdditionalProperties.Schema != nil {
+		d.compareSchema(location+".additionalProperties",
+			base.AdditionalProperties.Schema, revision.AdditionalProperties.Schema, dir, visited)
+	}
+	for _, composition := range []struct {
+		name           string
+		base, revision []*openapi.SchemaOrRef
+	}{
+		{"allOf", base.AllOf, revision.AllOf},
+		{"oneOf", base.OneOf, revision.OneOf},
+		{"anyOf", base.AnyOf, revision.AnyOf},
+	} {
+		if len(composition.base) != len(composition.revision) {
+			d.change(location+"."+composition.name, Modified, true, "%s changed from %d to %d schemas",
+				composition.name, len(composition.base), len(composition.revision))
+			continue
+		}
+		for i := range composition.base {
+			d.compareSchema(fmt.Sprintf("%s.%s[%d]", location, composition.name, i),
+				composition.base[i], composition.revision[i], dir, visited)
+		}
+	}
+}
+
+func (d *differ) enum(location string, base, revision []openapi.Any, dir direction) {
+	if len(base) == 0 && len(revision) == 0 {
+		return
+	}
+	if len(base) == 0 {
+		d.change(location, Modified, dir == request, "values restricted to %v", revision)
+		return
+	}
+	if len(revision) == 0 {
+		d.change(location, Modified, dir == response, "values no longer restricted")
+		return
+	}
+
+	removed := difference(base, revision)
+	added := difference(revision, base)
+	if len(removed) > 0 {
+		d.change(location, Removed, dir == request, "enum values %v removed", removed)
+	}
+	if len(added) > 0 {
+		d.change(location, Added, dir == response, "enum values %v added", added)
+	}
+}
+
+// limits reports tightened or loosened numeric and length constraints.
+func (d *differ) limits(location string, base, revision *openapi.Schema, dir direction) {
+	for _, limit := range []struct {
+		name           string
+		base, revision *float64
+		upper          bool
+	}{
+		{"maximum", base.Maximum, revision.Maximum, true},
+		{"minimum", base.Minimum, revision.Minimum, false},
+		{"maxLength", uintToFloat(base.MaxLength), uintToFloat(revision.MaxLength), true},
+		{"minLength", uintToFloat(base.MinLength), uintToFloat(revision.MinLength), false},
+		{"maxItems", uintToFloat(base.MaxItems), uintToFloat(revision.MaxItems), true},
+		{"minItems", uintToFloat(base.MinItems), uintToFloat(revision.MinItems), false},
+	} {
+		var narrowed bool
+		switch {
+		case limit.base == nil && limit.revision == nil:
+			continue
+		case limit.base == nil:
+			narrowed = true
+		case limit.revision == nil:
+			narrowed = false
+		case *limit.base == *limit.revision:
+			continue
+		case limit.upper:
+			narrowed = *limit.revision < *limit.base
+		default:
+			narrowed = *limit.revision > *limit.base
+		}
+
+		if narrowed {
+			d.change(location+"."+limit.name, Modified, dir == request, "%s narrowed", limit.name)
+		} else {
+			d.change(location+"."+limit.name, Modified, dir == response, "%s widened", limit.name)
+		}
+	}
+}
+
+func (d *differ) properties(location string, base, revision *openapi.Schema, dir direction, visited map[[2]*openapi.SchemaOrRef]bool) {
+	baseRequired := stringSet(base.Required)
+	revRequired := stringSet(revision.Required)
+
+	for _, name := range unionKeys(base.Properties, revision.Properties) {
+		loc := location + ".properties." + name
+		b, r := base.Properties[name], revision.Properties[name]
+		switch {
+		case b == nil:
+			if dir == request && revRequired[name] {
+				d.change(loc, Added, true, "required property '%s' added", name)
+			} else {
+				d.change(loc, Added, false, "property '%s' added", name)
+			}
+		case r == nil:
+			d.change(loc, Removed, dir == response, "property '%s' removed", name)
+		default:
+			if !baseRequired[name] && revRequired[name] {
+				d.change(loc, Modified, dir == request, "property '%s' became required", name)
+			}
+			if baseRequired[name] && !revRequired[name] {
+				d.change(loc, Modified, dir == response, "property '%s' became optional", name)
+			}
+			d.compareSchema(loc, b, r, dir, visited)
+		}
+	}
+}
+
+func difference(a, b []openapi.Any) []openapi.Any {
+	var diff []openapi.Any
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if fmt.Sprint(x) == fmt.Sprint(y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, x)
+		}
+	}
+	return diff
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func uintToFloat(v *uint64) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
@@ -0,0 +1,33 @@
+(function () {
+  "use strict";
+
+  // expand or collapse all the trees of a section
+  document.querySelectorAll("button.toggle").forEach(function (button) {
+    button.addEventListener("click", function () {
+      var open = button.textContent === "Expand all";
+      document.querySelectorAll("main details").forEach(function (details) {
+        details.open = open;
+      });
+      button.textContent = open ? "Collapse all" : "Expand all";
+    });
+  });
+
+  // filter the navigation
+  var filter = document.querySelector(".sidebar .filter");
+  if (filter) {
+    filter.addEventListener("input", function () {
+      var query = filter.value.toLowerCase();
+      document.querySelectorAll(".sidebar section").forEach(function (section) {
+        var visible = 0;
+        section.querySelectorAll("li").forEach(function (item) {
+          var match = item.textContent.toLowerCase().indexOf(query) >= 0;
+          item.classList.toggle("hidden", !match);
+          if (match) {
+            visible++;
+          }
+        });
+        section.classList.toggle("hidden", visible === 0);
+      });
+    });
+  }
+})();
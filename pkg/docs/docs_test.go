@@ -0,0 +1,199 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `
+info:
+  title: Pet Store
+  version: 1.0.0
+tags:
+- name: pets
+  description: Everything about pets.
+paths:
+  /pets/{petId}:
+    get:
+      operationId: showPetById
+      summary: Info for a specific pet
+      tags: [pets]
+      security:
+      - api_key: []
+      parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+      responses:
+        "200":
+          description: the pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /health:
+    get:
+      responses:
+        "204":
+          description: healthy
+components:
+  securitySchemes:
+    api_key:
+      type: apiKey
+      in: header
+      name: X-API-Key
+  schemas:
+    Pet:
+      type: object
+      description: A pet, with its ` + "`parent`" + `.
+      required: [name]
+      properties:
+        name:
+          type: string
+          maxLength: 64
+        parent:
+          $ref: '#/components/schemas/Pet'
+`
+
+func loadTestSite(t *testing.T) *Site {
+	t.Helper()
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(testSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return NewSite(&spec)
+}
+
+func TestNewSite(t *testing.T) {
+	site := loadTestSite(t)
+
+	var tags []string
+	for _, tag := range site.Tags {
+		tags = append(tags, tag.Slug)
+	}
+	if got, want := strings.Join(tags, ","), "pets,untagged"; got != want {
+		t.Errorf("tags = %s, want %s", got, want)
+	}
+	if got, want := site.Operations[0].Slug, "get-health"; got != want {
+		t.Errorf("slug = %s, want %s", got, want)
+	}
+
+	pet := site.Schemas[0].Node
+	if len(pet.Children) != 2 || !pet.Children[0].Required || !pet.Children[1].Recursive {
+		t.Errorf("Pet node = %+v, want a required name and a recursive parent", pet)
+	}
+	if got := site.Schemas[0].UsedBy; len(got) != 1 || got[0].OperationID != "showPetById" {
+		t.Errorf("UsedBy = %v, want showPetById", got)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	files, err := HTML(loadTestSite(t), "")
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	tests := map[string][]string{
+		"index.html":                  {`href="tags/pets.html"`, "Everything about pets."},
+		"tags/pets.html":              {`href="../operations/showPetById.html"`},
+		"operations/showPetById.html": {"<code>/pets/{petId}</code>", "api_key", `href="../schemas/Pet.html"`},
+		"schemas/Pet.html":            {"<code>parent</code>", "max length: 64"},
+		"assets/style.css":            nil,
+		"assets/script.js":            nil,
+		"operations/get-health.html":  {"healthy"},
+		"tags/untagged.html":          nil,
+	}
+	for name, wants := range tests {
+		content, ok := files[name]
+		if !ok {
+			t.Errorf("%s is not rendered", name)
+			continue
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s does not contain %q\n%s", name, want, content)
+			}
+		}
+	}
+	if len(files) != len(tests) {
+		t.Errorf("len(files) = %d, want %d", len(files), len(tests))
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	site := loadTestSite(t)
+
+	files, err := Markdown(site, false, "")
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	tests := map[string][]string{
+		"README.md": {
+			"# Pet Store 1.0.0",
+			"### [pets](tags/pets.md#tag-pets)",
+			"| GET | [`/pets/{petId}`](tags/pets.md#operation-showPetById) | Info for a specific pet |",
+			"- [Pet](schemas.md#schema-Pet)",
+		},
+		"tags/pets.md": {
+			`<a id="operation-showPetById"></a>`,
+			"- `api_key` (API key in header X-API-Key)",
+			"| `petId` | path | integer | yes |  |",
+			"`application/json`: [Pet](../schemas.md#schema-Pet)",
+		},
+		"tags/untagged.md": {"##### 204 healthy"},
+		"schemas.md": {
+			"A pet, with its `parent`.",
+			"| `name` | string | yes | max length: 64 |",
+			"| `parent` | [Pet](schemas.md#schema-Pet) | no |",
+			"Used by: [Info for a specific pet](tags/pets.md#operation-showPetById)",
+		},
+	}
+	for name, wants := range tests {
+		content, ok := files[name]
+		if !ok {
+			t.Errorf("%s is not rendered", name)
+			continue
+		}
+		for _, want := range wants {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s does not contain %q\n%s", name, want, content)
+			}
+		}
+	}
+	if len(files) != len(tests) {
+		t.Errorf("len(files) = %d, want %d", len(files), len(tests))
+	}
+
+	files, err = Markdown(site, true, "")
+	if err != nil {
+		t.Fatalf("Markdown() error = %v", err)
+	}
+	if got := string(files["README.md"]); len(files) != 1 || !strings.Contains(got, "[Pet](#schema-Pet)") {
+		t.Errorf("Markdown() single = %v, want a README.md with local links", files)
+	}
+}
+
+func TestFields(t *testing.T) {
+	node := &Node{Type: "object", Children: []*Node{
+		{Name: "items", Type: "array of object", Children: []*Node{
+			{Name: "sku", Type: "string"},
+		}},
+		{Name: "owner", Type: "User", Ref: "User", Children: []*Node{
+			{Name: "name", Type: "string"},
+		}},
+		{Name: "payment", Type: "one of", Children: []*Node{
+			{Type: "Card", Ref: "Card"},
+		}},
+	}}
+	var got []string
+	for _, field := range fields(node) {
+		got = append(got, field.Path)
+	}
+	if want := "items,items[].sku,owner,payment,payment one of #1"; strings.Join(got, ",") != want {
+		t.Errorf("fields() = %v, want %s", got, want)
+	}
+}
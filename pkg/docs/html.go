@@ -0,0 +1,164 @@
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//go:embed templates/html/*.html
+var htmlTemplates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// Files maps the slash separated paths of the files of a site to their
+// content.
+type Files map[string][]byte
+
+// Write writes the files under dir.
+func (files Files) Write(dir string) error {
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// page is the data of an HTML page.
+type page struct {
+	Site *Site
+	// Root is the relative path from the page to the root of the site.
+	Root      string
+	Title     string
+	Tag       *Tag
+	Operation *Operation
+	Schema    *Schema
+}
+
+// HTML renders a site as static HTML pages: an index, a page per tag, per
+// operation and per component schema, along with their stylesheet and
+// script. The pages do not refer to anything outside of the site. Templates
+// of the same names found in the templates directory, if any, replace the
+// default ones.
+func HTML(site *Site, templates string) (Files, error) {
+	tmpl, err := template.New("").Funcs(htmlFuncs).ParseFS(htmlTemplates, "templates/html/*.html")
+	if err != nil {
+		return nil, err
+	}
+	if templates != "" {
+		matches, err := filepath.Glob(filepath.Join(templates, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			if tmpl, err = tmpl.ParseFiles(matches...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files := Files{}
+	render := func(name, template string, p *page) error {
+		p.Site = site
+		p.Root = strings.Repeat("../", strings.Count(name, "/"))
+		var b bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&b, template, p); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = b.Bytes()
+		return nil
+	}
+
+	if err := render("index.html", "index.html", &page{}); err != nil {
+		return nil, err
+	}
+	for _, tag := range site.Tags {
+		title := tag.Name
+		if title == "" {
+			title = "Other operations"
+		}
+		if err := render("tags/"+tag.Slug+".html", "tag.html", &page{Title: title, Tag: tag}); err != nil {
+			return nil, err
+		}
+	}
+	for _, op := range site.Operations {
+		if err := render("operations/"+op.Slug+".html", "operation.html", &page{Title: op.Title(), Operation: op}); err != nil {
+			return nil, err
+		}
+	}
+	for _, schema := range site.Schemas {
+		if err := render("schemas/"+schema.Slug+".html", "schema.html", &page{Title: schema.Name, Schema: schema}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := fs.WalkDir(assets, "assets", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := assets.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files[path.Clean(name)] = content
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+var htmlFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"slug":  slug,
+	"text":  paragraphs,
+	"dict":  dict,
+}
+
+// dict builds a map from pairs of keys and values, to pass several values
+// to a template.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict wants pairs of keys and values")
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings")
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+// paragraphs renders a description as HTML paragraphs, with code spans.
+// Descriptions are CommonMark, but the rest of the markup is kept as is.
+func paragraphs(text string) template.HTML {
+	var b strings.Builder
+	for _, p := range regexp.MustCompile(`\n\s*\n`).Split(strings.TrimSpace(text), -1) {
+		if p == "" {
+			continue
+		}
+		escaped := template.HTMLEscapeString(p)
+		escaped = codeSpan.ReplaceAllString(escaped, "<code>$1</code>")
+		b.WriteString("<p>" + strings.Replace(escaped, "\n", "<br>\n", -1) + "</p>\n")
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,168 @@
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/markdown/*.md
+var markdownTemplates embed.FS
+
+// markdownPage is the data of a markdown file.
+type markdownPage struct {
+	Site *Site
+	// Root is the relative path from the file to the root of the output.
+	Root string
+	Tag  *Tag
+}
+
+// Field is a row of the table of the fields of a schema.
+type Field struct {
+	// Path locates the field from the root of the schema, as in
+	// `items[].sku`.
+	Path string
+	*Node
+}
+
+// Markdown renders a site as markdown files: a README.md giving an overview
+// of the API, a file per tag under tags/ and a schemas.md, or a single
+// README.md holding everything. Templates of the same names found in the
+// templates directory, if any, replace the default ones.
+func Markdown(site *Site, single bool, templates string) (Files, error) {
+	// the tag listing an operation
+	tags := map[*Operation]*Tag{}
+	for _, tag := range site.Tags {
+		for _, op := range tag.Operations {
+			if _, ok := tags[op]; !ok {
+				tags[op] = tag
+			}
+		}
+	}
+	link := func(root, file, anchor string) string {
+		if single {
+			return "#" + anchor
+		}
+		return root + file + "#" + anchor
+	}
+	funcs := template.FuncMap{
+		"dict":     dict,
+		"fields":   fields,
+		"cell":     cell,
+		"contains": strings.Contains,
+		"join":     strings.Join,
+		"trim":     strings.TrimSpace,
+		"tagLink": func(root string, tag *Tag) string {
+			return link(root, "tags/"+tag.Slug+".md", "tag-"+tag.Slug)
+		},
+		"operationLink": func(root string, op *Operation) string {
+			return link(root, "tags/"+tags[op].Slug+".md", "operation-"+op.Slug)
+		},
+		"schemaLink": func(root, name string) string {
+			return link(root, "schemas.md", "schema-"+slug(name))
+		},
+		"type": func(root string, n *Node) string {
+			switch {
+			case n.Ref != "":
+				return "[" + n.Type + "](" + link(root, "schemas.md", "schema-"+slug(n.Ref)) + ")"
+			case n.Items != "" && strings.HasSuffix(n.Type, n.Items):
+				return strings.TrimSuffix(n.Type, n.Items) + "[" + n.Items + "](" + link(root, "schemas.md", "schema-"+slug(n.Items)) + ")"
+			}
+			return n.Type
+		},
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(markdownTemplates, "templates/markdown/*.md")
+	if err != nil {
+		return nil, err
+	}
+	if templates != "" {
+		matches, err := filepath.Glob(filepath.Join(templates, "*.md"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			if tmpl, err = tmpl.ParseFiles(matches...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files := Files{}
+	render := func(name, template string, p *markdownPage) error {
+		p.Site = site
+		p.Root = strings.Repeat("../", strings.Count(name, "/"))
+		var b bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&b, template, p); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = b.Bytes()
+		return nil
+	}
+
+	if single {
+		if err := render("README.md", "single.md", &markdownPage{}); err != nil {
+			return nil, err
+		}
+		return files, nil
+	}
+	if err := render("README.md", "README.md", &markdownPage{}); err != nil {
+		return nil, err
+	}
+	for _, tag := range site.Tags {
+		if err := render("tags/"+tag.Slug+".md", "tag.md", &markdownPage{Tag: tag}); err != nil {
+			return nil, err
+		}
+	}
+	if len(site.Schemas) > 0 {
+		if err := render("schemas.md", "schemas.md", &markdownPage{}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// fields lists the fields of a schema, depth first. References to component
+// schemas are not expanded, as they are described on their own.
+func fields(n *Node) []*Field {
+	var result []*Field
+	var walk func(prefix string, n *Node)
+	walk = func(prefix string, n *Node) {
+		if n.Recursive || n.Ref != "" || n.Items != "" {
+			return
+		}
+		kind := n.Type
+		if strings.HasPrefix(kind, "array of ") {
+			prefix += "[]"
+			kind = strings.TrimPrefix(kind, "array of ")
+		}
+		for i, child := range n.Children {
+			path := child.Name
+			if path == "" {
+				path = fmt.Sprintf("%s #%d", kind, i+1)
+				if prefix != "" {
+					path = prefix + " " + path
+				}
+			} else if prefix != "" {
+				path = prefix + "." + path
+			}
+			result = append(result, &Field{Path: path, Node: child})
+			walk(path, child)
+		}
+	}
+	if n != nil {
+		walk("", n)
+	}
+	return result
+}
+
+// cell escapes a text to fit in a cell of a table.
+func cell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.Replace(text, "|", `\|`, -1)
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return strings.Replace(text, "\n", "<br>", -1)
+}
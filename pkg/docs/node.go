@@ -0,0 +1,237 @@
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Node is a schema rendered as a tree. The properties of objects, the
+// variants of unions and the values of maps are its children, while arrays
+// take the children of their items.
+type Node struct {
+	// Name is the name of the property, empty for the root and variants.
+	Name string
+	// Type describes the type, such as `string (date-time)`, `array of Pet`.
+	Type string
+	// Ref is the component schema the node refers to.
+	Ref string
+	// Items is the component schema of the items of an array or the values
+	// of a map, if they refer to one.
+	Items       string
+	Description string
+	Required    bool
+	Nullable    bool
+	ReadOnly    bool
+	WriteOnly   bool
+	Deprecated  bool
+	Constraints []string
+	Children    []*Node
+	// Recursive marks a reference to a schema being expanded, whose
+	// children are omitted.
+	Recursive bool
+}
+
+// newNode builds the tree of a schema, nil if there is no schema.
+func newNode(spec *openapi.OpenAPI, sor *openapi.SchemaOrRef) *Node {
+	if sor == nil {
+		return nil
+	}
+	return build(spec, "", sor, map[string]bool{})
+}
+
+// build builds a node, expanding references unless they are already being
+// expanded in stack.
+func build(spec *openapi.OpenAPI, name string, sor *openapi.SchemaOrRef, stack map[string]bool) *Node {
+	node := &Node{Name: name}
+	if sor == nil {
+		node.Type = "any"
+		return node
+	}
+	if sor.IsRef() {
+		ref := openapi.RefName(sor.Ref)
+		resolved := spec.ResolveSchema(sor)
+		node.Type, node.Ref = ref, ref
+		if resolved == nil {
+			return node
+		}
+		if stack[ref] {
+			node.Recursive = true
+			return node
+		}
+		stack[ref] = true
+		defer delete(stack, ref)
+
+		expanded := build(spec, name, resolved, stack)
+		expanded.Type, expanded.Ref = ref, ref
+		if isArray(resolved) || isMap(resolved) {
+			expanded.Type = ref + " (" + label(spec, resolved) + ")"
+		}
+		return expanded
+	}
+
+	s := &sor.Schema
+	node.Type = label(spec, sor)
+	node.Description = s.Description
+	node.Nullable = s.Nullable
+	node.ReadOnly = s.ReadOnly
+	node.WriteOnly = s.WriteOnly
+	node.Deprecated = s.Deprecated
+	node.Constraints = constraints(s)
+
+	switch {
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0:
+		for _, variants := range [][]*openapi.SchemaOrRef{s.OneOf, s.AnyOf, s.AllOf} {
+			for _, variant := range variants {
+				node.Children = append(node.Children, build(spec, "", variant, stack))
+			}
+		}
+	case isArray(sor):
+		items := build(spec, "", s.Items, stack)
+		node.Children, node.Recursive = items.Children, items.Recursive
+		node.Items = items.Ref
+	}
+
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		child := build(spec, name, s.Properties[name], stack)
+		child.Required = required[name]
+		node.Children = append(node.Children, child)
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		values := build(spec, "", ap.Schema, stack)
+		if len(s.Properties) == 0 {
+			node.Children = append(node.Children, values.Children...)
+			node.Recursive = values.Recursive
+			node.Items = values.Ref
+		} else {
+			values.Name = "*"
+			node.Children = append(node.Children, values)
+		}
+	}
+	return node
+}
+
+// label describes the type of a schema.
+func label(spec *openapi.OpenAPI, sor *openapi.SchemaOrRef) string {
+	if sor == nil {
+		return "any"
+	}
+	if sor.IsRef() {
+		return openapi.RefName(sor.Ref)
+	}
+	s := &sor.Schema
+	switch {
+	case len(s.OneOf) > 0:
+		return "one of"
+	case len(s.AnyOf) > 0:
+		return "any of"
+	case len(s.AllOf) > 0:
+		return "all of"
+	case isArray(sor):
+		return "array of " + label(spec, s.Items)
+	case isMap(sor):
+		return "map of " + label(spec, s.AdditionalProperties.Schema)
+	}
+	typ := s.Type
+	if typ == "" {
+		typ = "any"
+		if len(s.Properties) > 0 {
+			typ = "object"
+		}
+	}
+	if s.Format != "" {
+		typ += " (" + s.Format + ")"
+	}
+	return typ
+}
+
+func isArray(sor *openapi.SchemaOrRef) bool {
+	return sor.Type == "array"
+}
+
+func isMap(sor *openapi.SchemaOrRef) bool {
+	ap := sor.AdditionalProperties
+	return len(sor.Properties) == 0 && ap != nil && ap.Schema != nil
+}
+
+// constraints describes the validation keywords of a schema.
+func constraints(s *openapi.Schema) []string {
+	var result []string
+	add := func(format string, args ...interface{}) {
+		result = append(result, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, format(v))
+		}
+		add("one of: %s", strings.Join(values, ", "))
+	}
+	if s.Minimum != nil {
+		op := ">="
+		if s.ExclusiveMinimum {
+			op = ">"
+		}
+		add("%s %v", op, *s.Minimum)
+	}
+	if s.Maximum != nil {
+		op := "<="
+		if s.ExclusiveMaximum {
+			op = "<"
+		}
+		add("%s %v", op, *s.Maximum)
+	}
+	if s.MultipleOf != nil {
+		add("multiple of %v", *s.MultipleOf)
+	}
+	if s.MinLength != nil {
+		add("min length: %d", *s.MinLength)
+	}
+	if s.MaxLength != nil {
+		add("max length: %d", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		add("pattern: %s", s.Pattern)
+	}
+	if s.MinItems != nil {
+		add("min items: %d", *s.MinItems)
+	}
+	if s.MaxItems != nil {
+		add("max items: %d", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		add("unique items")
+	}
+	if s.MinProperties != nil {
+		add("min properties: %d", *s.MinProperties)
+	}
+	if s.MaxProperties != nil {
+		add("max properties: %d", *s.MaxProperties)
+	}
+	if s.Default != nil {
+		add("default: %s", format(s.Default))
+	}
+	return result
+}
+
+// refers tells whether the tree refers to a component schema.
+func (n *Node) refers(name string) bool {
+	if n == nil {
+		return false
+	}
+	if n.Ref == name {
+		return true
+	}
+	for _, child := range n.Children {
+		if child.refers(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,492 @@
+// Package docs renders API references from a document.
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Site is the view of a document rendered by the templates.
+type Site struct {
+	Spec        *openapi.OpenAPI
+	Title       string
+	Version     string
+	Description string
+	Servers     []*openapi.Server
+	Tags        []*Tag
+	Operations  []*Operation
+	Schemas     []*Schema
+}
+
+// Tag groups the operations of a tag. Untagged operations are grouped under
+// a tag without name.
+type Tag struct {
+	Name        string
+	Slug        string
+	Description string
+	Operations  []*Operation
+}
+
+// Operation is the view of an operation.
+type Operation struct {
+	Slug        string
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Deprecated  bool
+	Tags        []string
+	Parameters  []*Parameter
+	RequestBody *Body
+	Responses   []*Response
+	// Security lists the alternative requirements, nil if none applies.
+	Security []*Requirement
+}
+
+// Title returns the summary of the operation, or its method and path.
+func (op *Operation) Title() string {
+	if op.Summary != "" {
+		return op.Summary
+	}
+	return op.Method + " " + op.Path
+}
+
+// Parameter is the view of a parameter or a header.
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Deprecated  bool
+	Schema      *Node
+	Example     string
+}
+
+// Body is the view of a request body.
+type Body struct {
+	Description string
+	Required    bool
+	Content     []*Media
+}
+
+// Media is the view of a media type of a body.
+type Media struct {
+	MediaType string
+	Schema    *Node
+	Examples  []*Example
+}
+
+// Example is an example, rendered as JSON when structured.
+type Example struct {
+	Name    string
+	Summary string
+	Value   string
+}
+
+// Response is the view of a response.
+type Response struct {
+	Status      string
+	Description string
+	Headers     []*Parameter
+	Content     []*Media
+}
+
+// Requirement lists the schemes which must all be satisfied, an empty
+// requirement meaning that the operation may be called anonymously.
+type Requirement struct {
+	Schemes []*SchemeUse
+}
+
+// SchemeUse is a security scheme required by an operation.
+type SchemeUse struct {
+	Name        string
+	Type        string
+	Description string
+	Scopes      []string
+}
+
+// Schema is the view of a component schema.
+type Schema struct {
+	Name        string
+	Slug        string
+	Description string
+	Node        *Node
+	Example     string
+	// UsedBy lists the operations referring to the schema, directly or not.
+	UsedBy []*Operation
+}
+
+// NewSite builds the view of a document.
+func NewSite(spec *openapi.OpenAPI) *Site {
+	site := &Site{Spec: spec, Servers: spec.Servers}
+	if spec.Info != nil {
+		site.Title = spec.Info.Title
+		site.Version = spec.Info.Version
+		site.Description = spec.Info.Description
+	}
+	if site.Title == "" {
+		site.Title = "API Reference"
+	}
+
+	slugs := map[string]bool{}
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		for _, method := range openapi.Methods {
+			if op := item.Operation(method); op != nil {
+				site.Operations = append(site.Operations, newOperation(spec, path, method, item, op, slugs))
+			}
+		}
+	}
+
+	site.Tags = groupByTag(spec, site.Operations)
+
+	if spec.Components != nil {
+		names := make([]string, 0, len(spec.Components.Schemas))
+		for name := range spec.Components.Schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sor := spec.Components.Schemas[name]
+			schema := &Schema{
+				Name:        name,
+				Slug:        slug(name),
+				Description: sor.Description,
+				Node:        build(spec, "", sor, map[string]bool{name: true}),
+				Example:     format(spec.Sample(sor)),
+			}
+			for _, op := range site.Operations {
+				if uses(op, name) {
+					schema.UsedBy = append(schema.UsedBy, op)
+				}
+			}
+			site.Schemas = append(site.Schemas, schema)
+		}
+	}
+	return site
+}
+
+func newOperation(spec *openapi.OpenAPI, path, method string, item *openapi.PathItem, op *openapi.Operation, slugs map[string]bool) *Operation {
+	view := &Operation{
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Deprecated:  op.Deprecated,
+		Tags:        op.Tags,
+	}
+
+	name := op.OperationID
+	if name == "" {
+		name = method + "-" + path
+	}
+	view.Slug = slug(name)
+	for i := 2; slugs[view.Slug]; i++ {
+		view.Slug = fmt.Sprintf("%s-%d", slug(name), i)
+	}
+	slugs[view.Slug] = true
+
+	for _, p := range spec.OperationParameters(item, op) {
+		view.Parameters = append(view.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required,
+			Deprecated:  p.Deprecated,
+			Schema:      newNode(spec, p.Schema),
+			Example:     format(p.Example),
+		})
+	}
+
+	if rb := spec.ResolveRequestBody(op.RequestBody); rb != nil {
+		view.RequestBody = &Body{
+			Description: rb.Description,
+			Required:    rb.Required,
+			Content:     newContent(spec, rb.Content),
+		}
+	}
+
+	if op.Responses != nil {
+		for _, status := range sortedStatuses(*op.Responses) {
+			res := spec.ResolveResponse((*op.Responses)[status])
+			if res == nil {
+				continue
+			}
+			response := &Response{
+				Status:      status,
+				Description: res.Description,
+				Content:     newContent(spec, res.Content),
+			}
+			for _, name := range sortedKeys(res.Headers) {
+				header := spec.ResolveHeader(res.Headers[name])
+				if header == nil {
+					continue
+				}
+				response.Headers = append(response.Headers, &Parameter{
+					Name:        name,
+					In:          "header",
+					Description: header.Description,
+					Required:    header.Required,
+					Deprecated:  header.Deprecated,
+					Schema:      newNode(spec, header.Schema),
+					Example:     format(header.Example),
+				})
+			}
+			view.Responses = append(view.Responses, response)
+		}
+	}
+
+	requirements := spec.Security
+	if op.Security != nil {
+		requirements = nil
+		for _, r := range op.Security {
+			if r != nil {
+				requirements = append(requirements, *r)
+			}
+		}
+	}
+	for _, r := range requirements {
+		view.Security = append(view.Security, newRequirement(spec, r))
+	}
+	return view
+}
+
+func newContent(spec *openapi.OpenAPI, content map[string]*openapi.MediaType) []*Media {
+	var result []*Media
+	for _, mediaType := range sortedKeys(content) {
+		media := content[mediaType]
+		if media == nil {
+			continue
+		}
+		view := &Media{MediaType: mediaType, Schema: newNode(spec, media.Schema)}
+		if media.Example != nil {
+			view.Examples = append(view.Examples, &Example{Name: "example", Value: format(media.Example)})
+		}
+		for _, name := range sortedKeys(media.Examples) {
+			if ex := spec.ResolveExample(media.Examples[name]); ex != nil {
+				value := format(ex.Value)
+				if value == "" {
+					value = ex.ExternalValue
+				}
+				view.Examples = append(view.Examples, &Example{Name: name, Summary: ex.Summary, Value: value})
+			}
+		}
+		if len(view.Examples) == 0 && media.Schema != nil {
+			if value := format(spec.Sample(media.Schema)); value != "" {
+				view.Examples = append(view.Examples, &Example{Name: "generated", Value: value})
+			}
+		}
+		result = append(result, view)
+	}
+	return result
+}
+
+func newRequirement(spec *openapi.OpenAPI, r openapi.SecurityRequirement) *Requirement {
+	req := &Requirement{}
+	for _, name := range sortedKeys(r) {
+		use := &SchemeUse{Name: name, Scopes: r[name]}
+		if spec.Components != nil {
+			if ss := spec.Components.SecuritySchemes[name]; ss != nil {
+				use.Type = schemeType(&ss.SecurityScheme)
+				use.Description = ss.Description
+			}
+		}
+		req.Schemes = append(req.Schemes, use)
+	}
+	return req
+}
+
+// schemeType describes how the credentials of a scheme are sent.
+func schemeType(ss *openapi.SecurityScheme) string {
+	switch ss.Type {
+	case "apiKey":
+		return fmt.Sprintf("API key in %s %s", ss.In, ss.Name)
+	case "http":
+		if ss.BearerFormat != "" {
+			return fmt.Sprintf("HTTP %s (%s)", ss.Scheme, ss.BearerFormat)
+		}
+		return "HTTP " + ss.Scheme
+	case "oauth2":
+		return "OAuth 2.0"
+	case "openIdConnect":
+		return "OpenID Connect"
+	}
+	return ss.Type
+}
+
+// groupByTag groups operations by tag, in the order of the tags of the
+// document, then those of undeclared tags by name, then untagged ones.
+func groupByTag(spec *openapi.OpenAPI, ops []*Operation) []*Tag {
+	byName := map[string]*Tag{}
+	var tags []*Tag
+	add := func(name, description string) {
+		if _, ok := byName[name]; ok {
+			return
+		}
+		tag := &Tag{Name: name, Slug: slug(name), Description: description}
+		if name == "" {
+			tag.Slug = "untagged"
+		}
+		byName[name] = tag
+		tags = append(tags, tag)
+	}
+	for _, t := range spec.Tags {
+		if t != nil {
+			add(t.Name, t.Description)
+		}
+	}
+
+	var undeclared []string
+	for _, op := range ops {
+		for _, name := range op.Tags {
+			if _, ok := byName[name]; !ok {
+				undeclared = append(undeclared, name)
+			}
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		add(name, "")
+	}
+
+	for _, op := range ops {
+		names := op.Tags
+		if len(names) == 0 {
+			add("", "")
+			names = []string{""}
+		}
+		for _, name := range names {
+			byName[name].Operations = append(byName[name].Operations, op)
+		}
+	}
+
+	// declared tags without operations are not worth a page
+	result := tags[:0]
+	for _, tag := range tags {
+		if len(tag.Operations) > 0 {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// uses tells whether an operation refers to a component schema.
+func uses(op *Operation, name string) bool {
+	var nodes []*Node
+	for _, p := range op.Parameters {
+		nodes = append(nodes, p.Schema)
+	}
+	var content []*Media
+	if op.RequestBody != nil {
+		content = append(content, op.RequestBody.Content...)
+	}
+	for _, res := range op.Responses {
+		content = append(content, res.Content...)
+		for _, h := range res.Headers {
+			nodes = append(nodes, h.Schema)
+		}
+	}
+	for _, media := range content {
+		nodes = append(nodes, media.Schema)
+	}
+	for _, node := range nodes {
+		if node.refers(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedStatuses sorts status codes, ranges after exact codes and the
+// default response last.
+func sortedStatuses(responses openapi.Responses) []string {
+	statuses := make([]string, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := strings.ToUpper(statuses[i]), strings.ToUpper(statuses[j])
+		if a == "DEFAULT" || b == "DEFAULT" {
+			return b == "DEFAULT" && a != "DEFAULT"
+		}
+		return strings.Replace(a, "X", "~", -1) < strings.Replace(b, "X", "~", -1)
+	})
+	return statuses
+}
+
+// sortedKeys returns the keys of a map with string keys, sorted.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]*openapi.MediaType:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*openapi.ExampleOrRef:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*openapi.HeaderOrRef:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case openapi.SecurityRequirement:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*openapi.SchemaOrRef:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// format renders an example value, structured values as indented JSON.
+func format(v openapi.Any) string {
+	switch v := openapi.NormalizeAny(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// slug turns a name into a file name.
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
@@ -0,0 +1,368 @@
+// Package filter cuts variants out of a document, such as its public part,
+// keeping only the components which remain in use.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// The specification extensions marking operations and schemas.
+const (
+	// ExtensionInternal is true for what is internal.
+	ExtensionInternal = "x-internal"
+	// ExtensionAudience names the audience, or the list of audiences, of
+	// what it marks.
+	ExtensionAudience = "x-audience"
+)
+
+// Filter selects the operations and schemas of a variant. The zero Filter
+// selects everything.
+type Filter struct {
+	// IncludeTags keeps the operations with one of the tags only.
+	IncludeTags []string `yaml:"includeTags,omitempty"`
+	// ExcludeTags drops the operations with one of the tags.
+	ExcludeTags []string `yaml:"excludeTags,omitempty"`
+	// Audiences keeps the operations and schemas whose audience is one of
+	// them, or which have none, only.
+	Audiences []string `yaml:"audiences,omitempty"`
+	// ExcludeInternal drops the operations and schemas marked as internal.
+	ExcludeInternal bool `yaml:"excludeInternal,omitempty"`
+	// IncludePaths keeps the operations of the paths matching one of the
+	// patterns only. Patterns match paths segment by segment, as in
+	// path.Match, and `**` matches any number of segments.
+	IncludePaths []string `yaml:"includePaths,omitempty"`
+	// ExcludePaths drops the operations of the paths matching one of the
+	// patterns.
+	ExcludePaths []string `yaml:"excludePaths,omitempty"`
+	// Operations keeps the operations with one of the operationIds only.
+	Operations []string `yaml:"operations,omitempty"`
+}
+
+// IsZero tells whether the filter selects everything.
+func (f *Filter) IsZero() bool {
+	return len(f.IncludeTags) == 0 && len(f.ExcludeTags) == 0 && len(f.Audiences) == 0 && !f.ExcludeInternal &&
+		len(f.IncludePaths) == 0 && len(f.ExcludePaths) == 0 && len(f.Operations) == 0
+}
+
+// Validate checks the patterns of the paths.
+func (f *Filter) Validate() error {
+	for _, pattern := range append(f.IncludePaths, f.ExcludePaths...) {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid path pattern '%s': %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns a copy of the document holding the operations and schemas
+// selected by f, and the components they use. Dropped schemas are removed
+// from the properties of objects, and must not be used otherwise.
+func Apply(spec *openapi.OpenAPI, f *Filter) (*openapi.OpenAPI, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	spec = spec.Clone()
+
+	// the operations by tag, before and after filtering
+	tagged, kept := map[string]int{}, map[string]int{}
+	selected := 0
+	for path, item := range spec.Paths {
+		if item == nil {
+			continue
+		}
+		keepPath := f.keepPath(path)
+		dropped, left := 0, 0
+		for _, method := range openapi.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			for _, tag := range op.Tags {
+				tagged[tag]++
+			}
+			if keepPath && f.keepOperation(op) {
+				for _, tag := range op.Tags {
+					kept[tag]++
+				}
+				left++
+				selected++
+			} else {
+				item.SetOperation(method, nil)
+				dropped++
+			}
+		}
+		if dropped > 0 && left == 0 {
+			delete(spec.Paths, path)
+		}
+	}
+	if selected == 0 {
+		return nil, fmt.Errorf("no operation is selected")
+	}
+	spec.Tags = keepTags(spec.Tags, tagged, kept)
+
+	dropped := map[string]bool{}
+	if spec.Components != nil {
+		for name, schema := range spec.Components.Schemas {
+			if !f.keep(schema.Extensions) {
+				dropped["#/components/schemas/"+openapi.EscapePointer(name)] = true
+				delete(spec.Components.Schemas, name)
+			}
+		}
+	}
+	eachSchema(reflect.ValueOf(spec), func(schema *openapi.SchemaOrRef) {
+		for name, property := range schema.Properties {
+			if property != nil && (!f.keep(property.Extensions) || dropped[property.Ref]) {
+				delete(schema.Properties, name)
+				schema.Required = remove(schema.Required, name)
+			}
+		}
+	})
+	var err error
+	openapi.WalkRefs(spec, func(pointer, ref string) {
+		if dropped[ref] && err == nil {
+			err = fmt.Errorf("#%s: %s is dropped but still used", pointer, ref)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prune(spec)
+	if spec.Components != nil && isEmpty(reflect.ValueOf(spec.Components).Elem()) {
+		spec.Components = nil
+	}
+	return spec, nil
+}
+
+func (f *Filter) keepPath(path string) bool {
+	if len(f.IncludePaths) > 0 && !matchAny(f.IncludePaths, path) {
+		return false
+	}
+	return !matchAny(f.ExcludePaths, path)
+}
+
+func (f *Filter) keepOperation(op *openapi.Operation) bool {
+	if len(f.Operations) > 0 && !intersects([]string{op.OperationID}, f.Operations) {
+		return false
+	}
+	if len(f.IncludeTags) > 0 && !intersects(op.Tags, f.IncludeTags) {
+		return false
+	}
+	if intersects(op.Tags, f.ExcludeTags) {
+		return false
+	}
+	return f.keep(op.Extensions)
+}
+
+// keep tells whether the markers of an operation or schema select it.
+func (f *Filter) keep(ext openapi.Extensions) bool {
+	if f.ExcludeInternal && ext[ExtensionInternal] == true {
+		return false
+	}
+	if len(f.Audiences) > 0 {
+		if audiences := stringList(ext[ExtensionAudience]); len(audiences) > 0 && !intersects(audiences, f.Audiences) {
+			return false
+		}
+	}
+	return true
+}
+
+// keepTags drops the declarations of the tags whose operations are all
+// dropped, given the number of operations of each tag before and after.
+func keepTags(tags []*openapi.Tag, before, after map[string]int) []*openapi.Tag {
+	var kept []*openapi.Tag
+	for _, tag := range tags {
+		if before[tag.Name] > 0 && after[tag.Name] == 0 {
+			continue
+		}
+		kept = append(kept, tag)
+	}
+	return kept
+}
+
+// prune removes the components which the paths do not use, directly or
+// through other components.
+func prune(spec *openapi.OpenAPI) {
+	if spec.Components == nil {
+		return
+	}
+
+	used := map[string]bool{}
+	var visit func(v interface{})
+	visit = func(v interface{}) {
+		openapi.WalkRefs(v, func(_, ref string) {
+			if used[ref] {
+				return
+			}
+			used[ref] = true
+			target := spec.Lookup(ref)
+			if target == nil {
+				return
+			}
+			visit(target)
+			// subschemas are found through the mapping of their parent
+			if schema, ok := target.(*openapi.SchemaOrRef); ok && schema.Discriminator != nil {
+				for _, mapped := range schema.Discriminator.Mapping {
+					if !strings.HasPrefix(mapped, "#") {
+						mapped = "#/components/schemas/" + openapi.EscapePointer(mapped)
+					}
+					visit(&openapi.Reference{Ref: mapped})
+				}
+			}
+		})
+	}
+	visit(spec.Paths)
+
+	// security schemes are used by name
+	schemes := map[string]bool{}
+	for _, req := range spec.Security {
+		for name := range req {
+			schemes[name] = true
+		}
+	}
+	for _, item := range spec.Paths {
+		for _, method := range openapi.Methods {
+			if op := item.Operation(method); op != nil {
+				for _, req := range op.Security {
+					for name := range *req {
+						schemes[name] = true
+					}
+				}
+			}
+		}
+	}
+
+	components := reflect.ValueOf(spec.Components).Elem()
+	for i := 0; i < components.NumField(); i++ {
+		kind := strings.Split(components.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		m := components.Field(i)
+		for _, key := range m.MapKeys() {
+			name := key.String()
+			if used["#/components/"+kind+"/"+openapi.EscapePointer(name)] || kind == "securitySchemes" && schemes[name] {
+				continue
+			}
+			m.SetMapIndex(key, reflect.Value{})
+		}
+	}
+}
+
+// eachSchema calls fn for every schema found in v, parents first.
+func eachSchema(v reflect.Value, fn func(*openapi.SchemaOrRef)) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if schema, ok := v.Interface().(*openapi.SchemaOrRef); ok {
+			fn(schema)
+		}
+		eachSchema(v.Elem(), fn)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			eachSchema(v.Index(i), fn)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			eachSchema(v.MapIndex(key), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				eachSchema(v.Field(i), fn)
+			}
+		}
+	}
+}
+
+// stringList returns the strings of an extension holding either a string
+// or a list of them.
+func stringList(v openapi.Any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath tells whether a path matches a valid pattern, where `**`
+// matches any number of segments and other segments are matched as in
+// path.Match.
+func matchPath(pattern, p string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(p, "/"), "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(segments); i >= 0; i-- {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// isEmpty tells whether all the maps of a struct are empty.
+func isEmpty(v reflect.Value) bool {
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Map && v.Field(i).Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func remove(list []string, s string) []string {
+	var kept []string
+	for _, v := range list {
+		if v != s {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,308 @@
+package filter
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `
+openapi: 3.0.3
+security:
+- api_key: []
+tags:
+- name: pets
+- name: admin
+paths:
+  /pets:
+    get:
+      tags: [pets]
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /admin/reports:
+    get:
+      tags: [admin]
+      x-internal: true
+      responses:
+        "200":
+          $ref: '#/components/responses/Report'
+  /partners:
+    get:
+      operationId: listPartners
+      x-audience: [partners]
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Partner'
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, owner]
+      properties:
+        id:
+          type: integer
+        secret:
+          type: string
+          x-internal: true
+        owner:
+          $ref: '#/components/schemas/Owner'
+      discriminator:
+        propertyName: kind
+        mapping:
+          cat: Cat
+    Cat:
+      allOf:
+      - $ref: '#/components/schemas/Pet'
+    Owner:
+      type: object
+      x-internal: true
+    Report:
+      type: object
+    Partner:
+      type: object
+    Unused:
+      type: object
+  responses:
+    Report:
+      description: ok
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Report'
+  securitySchemes:
+    api_key:
+      type: apiKey
+    basic:
+      type: http
+`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      Filter
+		wantPaths   []string
+		wantSchemas []string
+		wantTags    []string
+	}{
+		{
+			name:        "everything",
+			wantPaths:   []string{"/admin/reports", "/partners", "/pets"},
+			wantSchemas: []string{"Cat", "Owner", "Partner", "Pet", "Report"},
+			wantTags:    []string{"pets", "admin"},
+		},
+		{
+			name:        "exclude internal",
+			filter:      Filter{ExcludeInternal: true},
+			wantPaths:   []string{"/partners", "/pets"},
+			wantSchemas: []string{"Cat", "Partner", "Pet"},
+			wantTags:    []string{"pets"},
+		},
+		{
+			name:        "audiences",
+			filter:      Filter{Audiences: []string{"public"}},
+			wantPaths:   []string{"/admin/reports", "/pets"},
+			wantSchemas: []string{"Cat", "Owner", "Pet", "Report"},
+			wantTags:    []string{"pets", "admin"},
+		},
+		{
+			name:        "include tags",
+			filter:      Filter{IncludeTags: []string{"pets"}},
+			wantPaths:   []string{"/pets"},
+			wantSchemas: []string{"Cat", "Owner", "Pet"},
+			wantTags:    []string{"pets"},
+		},
+		{
+			name:        "exclude tags",
+			filter:      Filter{ExcludeTags: []string{"admin"}},
+			wantPaths:   []string{"/partners", "/pets"},
+			wantSchemas: []string{"Cat", "Owner", "Partner", "Pet"},
+			wantTags:    []string{"pets"},
+		},
+		{
+			name:        "exclude paths",
+			filter:      Filter{ExcludePaths: []string{"/admin/**", "/part*"}},
+			wantPaths:   []string{"/pets"},
+			wantSchemas: []string{"Cat", "Owner", "Pet"},
+			wantTags:    []string{"pets"},
+		},
+		{
+			name:        "include paths",
+			filter:      Filter{IncludePaths: []string{"/**/reports"}},
+			wantPaths:   []string{"/admin/reports"},
+			wantSchemas: []string{"Report"},
+			wantTags:    []string{"admin"},
+		},
+		{
+			name:        "operations",
+			filter:      Filter{Operations: []string{"listPartners"}},
+			wantPaths:   []string{"/partners"},
+			wantSchemas: []string{"Partner"},
+			wantTags:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spec openapi.OpenAPI
+			if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			before, _ := yaml.Marshal(&spec)
+
+			got, err := Apply(&spec, &tt.filter)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if after, _ := yaml.Marshal(&spec); string(after) != string(before) {
+				t.Errorf("Apply() modifies the document")
+			}
+
+			if paths := keys(got.Paths); !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("paths = %v, want %v", paths, tt.wantPaths)
+			}
+			if schemas := keys(got.Components.Schemas); !reflect.DeepEqual(schemas, tt.wantSchemas) {
+				t.Errorf("schemas = %v, want %v", schemas, tt.wantSchemas)
+			}
+			var tags []string
+			for _, tag := range got.Tags {
+				tags = append(tags, tag.Name)
+			}
+			if !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", tags, tt.wantTags)
+			}
+			if schemes := keys(got.Components.SecuritySchemes); !reflect.DeepEqual(schemes, []string{"api_key"}) {
+				t.Errorf("security schemes = %v, want [api_key]", schemes)
+			}
+
+			pet, ok := got.Components.Schemas["Pet"]
+			if !ok {
+				return
+			}
+			_, secret := pet.Properties["secret"]
+			_, owner := pet.Properties["owner"]
+			if secret == tt.filter.ExcludeInternal || owner == tt.filter.ExcludeInternal {
+				t.Errorf("properties of Pet = %v", keys(pet.Properties))
+			}
+			if tt.filter.ExcludeInternal && !reflect.DeepEqual(pet.Required, []string{"id"}) {
+				t.Errorf("required properties of Pet = %v, want [id]", pet.Required)
+			}
+		})
+	}
+}
+
+func TestApply_Used(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	spec.Components.Schemas["Report"].Extensions = openapi.Extensions{ExtensionInternal: true}
+	spec.Paths["/admin/reports"].Get.Extensions = nil
+
+	_, err := Apply(&spec, &Filter{ExcludeInternal: true})
+	if err == nil || !strings.Contains(err.Error(), "#/components/schemas/Report is dropped but still used") {
+		t.Errorf("Apply() error = %v", err)
+	}
+}
+
+func TestApply_Nothing(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if _, err := Apply(&spec, &Filter{Operations: []string{"nope"}}); err == nil {
+		t.Errorf("Apply() selects no operation without error")
+	}
+
+	// path items without operations are kept but select nothing
+	spec = openapi.OpenAPI{}
+	if err := yaml.Unmarshal([]byte(`
+paths:
+  /pets/{id}:
+    parameters:
+    - name: id
+      in: path
+      required: true
+  /pets:
+    get:
+      operationId: listPets
+`), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if _, err := Apply(&spec, &Filter{Operations: []string{"nope"}}); err == nil {
+		t.Errorf("Apply() selects no operation without error, with a path item without operations")
+	}
+
+	// what remains uses no component
+	spec = openapi.OpenAPI{}
+	if err := yaml.Unmarshal([]byte(`
+paths:
+  /ping:
+    get:
+      operationId: ping
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          $ref: '#/components/responses/Pets'
+components:
+  responses:
+    Pets:
+      description: ok
+`), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	got, err := Apply(&spec, &Filter{Operations: []string{"ping"}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.Components != nil {
+		t.Errorf("Components = %+v, want nil", got.Components)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/admin/**", "/admin", true},
+		{"/admin/**", "/admin/users/{id}", true},
+		{"/admin/**", "/administrators", false},
+		{"/users/*", "/users/{id}", true},
+		{"/users/*", "/users/{id}/posts", false},
+		{"/**/posts", "/users/{id}/posts", true},
+		{"/users/{id}", "/users/{id}", true},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%s, %s) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	if err := (&Filter{ExcludePaths: []string{"/users/["}}).Validate(); err == nil {
+		t.Errorf("Validate() accepts an invalid pattern")
+	}
+}
+
+func keys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// TopLevel returns the root directory of the work tree containing dir.
+func TopLevel(dir string) (string, error) {
+	out, err := run(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Checkout writes the contents of dir as of revision rev into a new temporary
+// directory using `git archive` on the local repository, and returns the path
+// corresponding to dir inside it. The caller must call cleanup once done.
+func Checkout(dir, rev string) (_ string, cleanup func(), err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	top, err := TopLevel(abs)
+	if err != nil {
+		return "", nil, err
+	}
+	// the top level is reported with symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmp, err := ioutil.TempDir("", "gopenapi-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+
+	args := []string{"archive", "--format=tar", rev}
+	if rel != "." {
+		args = append(args, "--", filepath.ToSlash(rel))
+	}
+	var archive bytes.Buffer
+	if _, err := run(top, &archive, args...); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := extract(&archive, tmp); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return filepath.Join(tmp, rel), cleanup, nil
+}
+
+func extract(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(name, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive '%s'", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(name, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func run(dir string, stdout io.Writer, args ...string) ([]byte, error) {
+	var out, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out.Bytes(), nil
+}
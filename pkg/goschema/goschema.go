@@ -0,0 +1,488 @@
+// Package goschema derives component schemas from Go types, following the
+// rules of encoding/json.
+package goschema
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// generator converts the Go types of packages, loaded from source, into
+// schemas. Named types become components referenced by their name.
+type generator struct {
+	dir      string
+	fset     *token.FileSet
+	importer types.ImporterFrom
+
+	schemas map[string]*openapi.SchemaOrRef
+	names   map[*types.TypeName]string
+	docs    map[string]map[string]string
+}
+
+// Schemas loads the Go types matched by patterns and returns their schemas,
+// together with those of the named types they refer to, by component name.
+//
+// A pattern is a package, as understood by `go list` from dir, followed by
+// the name of a type: ./internal/model.User. A package alone, possibly with
+// the ... wildcard, selects all its exported types.
+func Schemas(dir string, patterns ...string) (map[string]*openapi.SchemaOrRef, error) {
+	fset := token.NewFileSet()
+	g := &generator{
+		dir:      dir,
+		fset:     fset,
+		importer: importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+		schemas:  map[string]*openapi.SchemaOrRef{},
+		names:    map[*types.TypeName]string{},
+		docs:     map[string]map[string]string{},
+	}
+
+	for _, pattern := range patterns {
+		pkgPattern, typeName := splitPattern(pattern)
+		paths, err := g.list(pkgPattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			pkg, err := g.importer.ImportFrom(path, dir, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load '%s': %v", path, err)
+			}
+			var objs []*types.TypeName
+			if typeName != "" {
+				obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+				if !ok {
+					return nil, fmt.Errorf("type '%s' is not declared in '%s'", typeName, path)
+				}
+				objs = append(objs, obj)
+			} else {
+				for _, name := range pkg.Scope().Names() {
+					if obj, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok && obj.Exported() {
+						objs = append(objs, obj)
+					}
+				}
+			}
+			for _, obj := range objs {
+				if _, err := g.component(obj); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return g.schemas, nil
+}
+
+// splitPattern splits the type name off a pattern. The type is the exported
+// identifier following the last dot after the last slash, which keeps
+// ./internal/model and gopkg.in/yaml.v2 packages.
+func splitPattern(pattern string) (pkg, typeName string) {
+	slash := strings.LastIndex(pattern, "/")
+	dot := strings.LastIndex(pattern, ".")
+	if dot <= slash {
+		return pattern, ""
+	}
+	if name := pattern[dot+1:]; token.IsIdentifier(name) && token.IsExported(name) {
+		return pattern[:dot], name
+	}
+	return pattern, ""
+}
+
+// list resolves a package pattern into import paths. Local paths have to be
+// resolved as the source importer would not know the module they belong to.
+func (g *generator) list(pattern string) ([]string, error) {
+	cmd := exec.Command("go", "list", "-find", "-f", "{{.ImportPath}}", pattern)
+	cmd.Dir = g.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list '%s': %s", pattern, strings.TrimSpace(stderr.String()))
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// component returns a reference to the component of a named type, declaring
+// it on first use.
+func (g *generator) component(obj *types.TypeName) (*openapi.SchemaOrRef, error) {
+	if name, ok := g.names[obj]; ok {
+		return ref(name), nil
+	}
+
+	name := g.componentName(obj)
+	g.names[obj] = name
+	// reserve the name before converting, as the type may refer to itself
+	g.schemas[name] = nil
+
+	schema, err := g.schema(obj.Type().Underlying(), obj.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %v", obj.Pkg().Path(), obj.Name(), err)
+	}
+	if schema.IsRef() {
+		schema = &openapi.SchemaOrRef{Schema: openapi.Schema{AllOf: []*openapi.SchemaOrRef{schema}}}
+	}
+	schema.Description = g.doc(obj.Pkg(), obj.Name())
+	schema.Enum = enum(obj)
+	g.schemas[name] = schema
+	return ref(name), nil
+}
+
+// componentName returns a free name for the component of a type: its own
+// name, or when taken, the name prefixed with its package name and numbered
+// if that is taken as well.
+func (g *generator) componentName(obj *types.TypeName) string {
+	name := obj.Name()
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+	prefixed := exported(obj.Pkg().Name()) + name
+	name = prefixed
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[name]; !taken {
+			return name
+		}
+		name = prefixed + strconv.Itoa(i)
+	}
+}
+
+// schema converts a type. path locates the type in its declaration for doc
+// comments of fields: "User", "User.Address".
+func (g *generator) schema(typ types.Type, path string) (*openapi.SchemaOrRef, error) {
+	if s := special(typ); s != nil {
+		return s, nil
+	}
+
+	switch t := typ.(type) {
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			// error and any other predeclared types
+			return g.schema(t.Underlying(), path)
+		}
+		return g.component(t.Obj())
+	case *types.Pointer:
+		return g.schema(t.Elem(), path)
+	case *types.Basic:
+		return basic(t)
+	case *types.Slice:
+		if b, ok := t.Elem().(*types.Basic); ok && b.Kind() == types.Byte {
+			return schema("string", "byte"), nil
+		}
+		items, err := g.schema(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		s := schema("array", "")
+		s.Items = items
+		return s, nil
+	case *types.Array:
+		items, err := g.schema(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(t.Len())
+		s := schema("array", "")
+		s.Items, s.MinItems, s.MaxItems = items, &n, &n
+		return s, nil
+	case *types.Map:
+		values, err := g.schema(t.Elem(), path)
+		if err != nil {
+			return nil, err
+		}
+		s := schema("object", "")
+		if values.IsRef() || values.Type != "" {
+			s.AdditionalProperties = &openapi.SchemaOrBool{Schema: values}
+		} else {
+			s.AdditionalProperties = &openapi.SchemaOrBool{Bool: true}
+		}
+		return s, nil
+	case *types.Interface:
+		return &openapi.SchemaOrRef{}, nil
+	case *types.Struct:
+		return g.object(t, path)
+	}
+	return nil, fmt.Errorf("type %s cannot be encoded as JSON", typ)
+}
+
+// special handles the types encoding themselves.
+func special(typ types.Type) *openapi.SchemaOrRef {
+	if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return schema("string", "date-time")
+	}
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return nil
+	}
+	if hasMethod(typ, "MarshalJSON") {
+		// nothing is known about the encoding
+		return &openapi.SchemaOrRef{}
+	}
+	if hasMethod(typ, "MarshalText") {
+		return schema("string", "")
+	}
+	return nil
+}
+
+func hasMethod(typ types.Type, name string) bool {
+	if _, ok := typ.(*types.Pointer); !ok {
+		typ = types.NewPointer(typ)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typ, false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+func basic(t *types.Basic) (*openapi.SchemaOrRef, error) {
+	switch t.Kind() {
+	case types.Bool, types.UntypedBool:
+		return schema("boolean", ""), nil
+	case types.String, types.UntypedString:
+		return schema("string", ""), nil
+	case types.Int8, types.Int16, types.Int32, types.Uint8, types.Uint16:
+		return schema("integer", "int32"), nil
+	case types.Int, types.Int64, types.Uint, types.Uint32, types.Uint64, types.Uintptr, types.UntypedInt:
+		return schema("integer", "int64"), nil
+	case types.Float32:
+		return schema("number", "float"), nil
+	case types.Float64, types.UntypedFloat:
+		return schema("number", "double"), nil
+	}
+	return nil, fmt.Errorf("type %s cannot be encoded as JSON", t)
+}
+
+// object converts a struct the way encoding/json encodes it. Embedded
+// exported structs become allOf references, while the fields of the other
+// embedded structs are promoted.
+func (g *generator) object(t *types.Struct, path string) (*openapi.SchemaOrRef, error) {
+	s := schema("object", "")
+	var allOf []*openapi.SchemaOrRef
+
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		tag := parseTag(t.Tag(i))
+		if tag.name == "-" {
+			continue
+		}
+
+		if field.Embedded() && tag.name == "" {
+			typ := field.Type()
+			if p, ok := typ.(*types.Pointer); ok {
+				typ = p.Elem()
+			}
+			if _, ok := typ.Underlying().(*types.Struct); ok {
+				if named, ok := typ.(*types.Named); ok && named.Obj().Exported() && special(typ) == nil {
+					embedded, err := g.component(named.Obj())
+					if err != nil {
+						return nil, err
+					}
+					allOf = append(allOf, embedded)
+					continue
+				}
+				promotedPath := path
+				if named, ok := typ.(*types.Named); ok {
+					promotedPath = named.Obj().Name()
+				}
+				promoted, err := g.object(typ.Underlying().(*types.Struct), promotedPath)
+				if err != nil {
+					return nil, err
+				}
+				parts := []*openapi.SchemaOrRef{promoted}
+				if len(promoted.AllOf) > 0 {
+					parts = promoted.AllOf
+				}
+				for _, part := range parts {
+					if part.IsRef() {
+						allOf = append(allOf, part)
+						continue
+					}
+					for name, prop := range part.Properties {
+						if _, ok := s.Properties[name]; !ok {
+							setProperty(s, name, prop)
+						}
+					}
+					s.Required = append(s.Required, part.Required...)
+				}
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+
+		name := tag.name
+		if name == "" {
+			name = field.Name()
+		}
+		fieldPath := path + "." + field.Name()
+		prop, err := g.schema(field.Type(), fieldPath)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field.Name(), err)
+		}
+		if tag.asString {
+			prop = schema("string", "")
+		}
+
+		doc := g.doc(field.Pkg(), fieldPath)
+		validation := parseValidate(t.Tag(i))
+		_, pointer := field.Type().(*types.Pointer)
+		nullable := pointer && !tag.omitEmpty
+		if prop.IsRef() && (doc != "" || nullable || !validation.empty()) {
+			prop = &openapi.SchemaOrRef{Schema: openapi.Schema{AllOf: []*openapi.SchemaOrRef{prop}}}
+		}
+		if doc != "" {
+			prop.Description = doc
+		}
+		prop.Nullable = nullable
+		validation.apply(prop)
+
+		setProperty(s, name, prop)
+		if !tag.omitEmpty || validation.required {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	sort.Strings(s.Required)
+	if len(allOf) == 0 {
+		return s, nil
+	}
+	if len(s.Properties) > 0 {
+		allOf = append(allOf, s)
+	}
+	return &openapi.SchemaOrRef{Schema: openapi.Schema{AllOf: allOf}}, nil
+}
+
+func setProperty(s *openapi.SchemaOrRef, name string, prop *openapi.SchemaOrRef) {
+	if s.Properties == nil {
+		s.Properties = map[string]*openapi.SchemaOrRef{}
+	}
+	s.Properties[name] = prop
+}
+
+// enum lists the constants declared with a named type, in source order.
+func enum(obj *types.TypeName) []openapi.Any {
+	if _, ok := obj.Type().Underlying().(*types.Basic); !ok {
+		return nil
+	}
+	var consts []*types.Const
+	scope := obj.Pkg().Scope()
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(c.Type(), obj.Type()) {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+
+	var values []openapi.Any
+	for _, c := range consts {
+		switch v := c.Val(); v.Kind() {
+		case constant.String:
+			values = append(values, constant.StringVal(v))
+		case constant.Int:
+			n, _ := constant.Int64Val(v)
+			values = append(values, n)
+		case constant.Float:
+			f, _ := constant.Float64Val(v)
+			values = append(values, f)
+		case constant.Bool:
+			values = append(values, constant.BoolVal(v))
+		}
+	}
+	return values
+}
+
+// doc returns the doc comment of a type or field declared in pkg, given its
+// path: "User", "User.Address".
+func (g *generator) doc(pkg *types.Package, path string) string {
+	if pkg == nil {
+		return ""
+	}
+	docs, ok := g.docs[pkg.Path()]
+	if !ok {
+		docs = g.parseDocs(pkg.Path())
+		g.docs[pkg.Path()] = docs
+	}
+	return docs[path]
+}
+
+// parseDocs collects the doc comments of the types of a package and of their
+// fields. Packages which cannot be parsed simply have no docs.
+func (g *generator) parseDocs(path string) map[string]string {
+	docs := map[string]string{}
+	bp, err := build.Import(path, g.dir, 0)
+	if err != nil {
+		return docs
+	}
+	for _, filename := range bp.GoFiles {
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(bp.Dir, filename), nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				docs[ts.Name.Name] = text(doc, nil)
+				fieldDocs(docs, ts.Name.Name, ts.Type)
+			}
+		}
+	}
+	return docs
+}
+
+func fieldDocs(docs map[string]string, path string, expr ast.Expr) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		fieldDocs(docs, path, t.X)
+	case *ast.ArrayType:
+		fieldDocs(docs, path, t.Elt)
+	case *ast.MapType:
+		fieldDocs(docs, path, t.Value)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			for _, name := range field.Names {
+				docs[path+"."+name.Name] = text(field.Doc, field.Comment)
+				fieldDocs(docs, path+"."+name.Name, field.Type)
+			}
+		}
+	}
+}
+
+func text(doc, comment *ast.CommentGroup) string {
+	if doc == nil {
+		doc = comment
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+func schema(typ, format string) *openapi.SchemaOrRef {
+	return &openapi.SchemaOrRef{Schema: openapi.Schema{Type: typ, Format: format}}
+}
+
+func ref(name string) *openapi.SchemaOrRef {
+	return &openapi.SchemaOrRef{Reference: openapi.Reference{Ref: "#/components/schemas/" + name}}
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
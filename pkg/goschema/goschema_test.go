@@ -0,0 +1,140 @@
+package goschema
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSplitPattern(t *testing.T) {
+	tests := []struct {
+		pattern, pkg, typeName string
+	}{
+		{"./internal/model.User", "./internal/model", "User"},
+		{"./internal/model", "./internal/model", ""},
+		{"./...", "./...", ""},
+		{"example.com/model.User", "example.com/model", "User"},
+		{"gopkg.in/yaml.v2", "gopkg.in/yaml.v2", ""},
+		{"gopkg.in/yaml.v2.MapSlice", "gopkg.in/yaml.v2", "MapSlice"},
+		{".", ".", ""},
+	}
+	for _, tt := range tests {
+		pkg, typeName := splitPattern(tt.pattern)
+		if pkg != tt.pkg || typeName != tt.typeName {
+			t.Errorf("splitPattern(%q) = %q, %q, want %q, %q", tt.pattern, pkg, typeName, tt.pkg, tt.typeName)
+		}
+	}
+}
+
+func TestComponentName(t *testing.T) {
+	g := &generator{schemas: map[string]*openapi.SchemaOrRef{}}
+	pkg := types.NewPackage("example.com/v2/model", "model")
+
+	for _, want := range []string{"Tag", "ModelTag", "ModelTag2", "ModelTag3"} {
+		got := g.componentName(types.NewTypeName(token.NoPos, pkg, "Tag", nil))
+		if got != want {
+			t.Errorf("componentName() = %s, want %s", got, want)
+		}
+		g.schemas[got] = nil
+	}
+}
+
+func TestSchemas(t *testing.T) {
+	schemas, err := Schemas(".", "./testdata/model.Pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"Status": `
+description: Status of a pet.
+type: string
+enum:
+- available
+- sold
+`,
+		"Base": `
+description: Base holds the fields common to all resources.
+type: object
+required:
+- created_at
+- id
+properties:
+  created_at:
+    type: string
+    format: date-time
+  id:
+    type: integer
+    format: int64
+`,
+		"Pet": `
+description: Pet is a pet of the store.
+allOf:
+- $ref: '#/components/schemas/Base'
+- type: object
+  required:
+  - name
+  - owner
+  properties:
+    labels:
+      type: object
+      additionalProperties:
+        type: string
+    name:
+      description: Name of the pet.
+      type: string
+      maxLength: 64
+      minLength: 1
+    owner:
+      nullable: true
+      allOf:
+      - $ref: '#/components/schemas/Owner'
+    status:
+      $ref: '#/components/schemas/Status'
+    tags:
+      type: array
+      items:
+        type: string
+      maxItems: 10
+`,
+		"Owner": `
+description: Owner owns pets.
+type: object
+required:
+- email
+properties:
+  email:
+    type: string
+    format: email
+  pets:
+    type: array
+    items:
+      $ref: '#/components/schemas/Pet'
+`,
+	}
+	if len(schemas) != len(tests) {
+		t.Errorf("got %d schemas, want %d", len(schemas), len(tests))
+	}
+	for name, want := range tests {
+		schema, ok := schemas[name]
+		if !ok {
+			t.Errorf("missing schema %s", name)
+			continue
+		}
+		got, err := yaml.Marshal(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(got)) != strings.TrimSpace(want) {
+			t.Errorf("schema %s:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+
+	if _, err := Schemas(".", "./testdata/model.Missing"); err == nil {
+		t.Error("expected an error for an undeclared type")
+	}
+}
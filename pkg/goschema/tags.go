@@ -0,0 +1,159 @@
+package goschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// jsonTag is the json key of a struct tag.
+type jsonTag struct {
+	name      string
+	omitEmpty bool
+	asString  bool
+}
+
+func parseTag(tag string) jsonTag {
+	value, ok := reflect.StructTag(tag).Lookup("json")
+	if !ok {
+		return jsonTag{}
+	}
+	if value == "-" {
+		return jsonTag{name: "-"}
+	}
+	options := strings.Split(value, ",")
+	t := jsonTag{name: options[0]}
+	for _, option := range options[1:] {
+		switch option {
+		case "omitempty":
+			t.omitEmpty = true
+		case "string":
+			t.asString = true
+		}
+	}
+	return t
+}
+
+// validation holds the rules of a validate key, as understood by
+// github.com/go-playground/validator, which translate into a schema.
+type validation struct {
+	required bool
+	format   string
+	enum     []string
+	// min and max bound the length of strings, arrays and maps, and the value
+	// of numbers. gt and lt are their exclusive variants.
+	min, max *float64
+	gt, lt   bool
+}
+
+func parseValidate(tag string) validation {
+	var v validation
+	value, ok := reflect.StructTag(tag).Lookup("validate")
+	if !ok {
+		return v
+	}
+	for _, rule := range strings.Split(value, ",") {
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		n, err := strconv.ParseFloat(param, 64)
+		number := err == nil
+		switch name {
+		case "dive":
+			// the following rules apply to elements
+			return v
+		case "required":
+			v.required = true
+		case "email":
+			v.format = "email"
+		case "url", "uri":
+			v.format = "uri"
+		case "uuid", "uuid4":
+			v.format = "uuid"
+		case "ipv4", "ipv6", "hostname":
+			v.format = name
+		case "oneof":
+			v.enum = strings.Fields(param)
+		case "len":
+			if number {
+				v.min, v.max = &n, &n
+			}
+		case "min", "gte":
+			if number {
+				v.min, v.gt = &n, false
+			}
+		case "max", "lte":
+			if number {
+				v.max, v.lt = &n, false
+			}
+		case "gt":
+			if number {
+				v.min, v.gt = &n, true
+			}
+		case "lt":
+			if number {
+				v.max, v.lt = &n, true
+			}
+		}
+	}
+	return v
+}
+
+func (v validation) empty() bool {
+	return v.format == "" && v.enum == nil && v.min == nil && v.max == nil
+}
+
+// apply sets the constraints on the schema of a field. Bounds are ignored on
+// references, whose type is not known here.
+func (v validation) apply(s *openapi.SchemaOrRef) {
+	typ := s.Type
+
+	if v.format != "" {
+		s.Format = v.format
+	}
+	for _, value := range v.enum {
+		switch typ {
+		case "integer":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				s.Enum = append(s.Enum, n)
+			}
+		case "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				s.Enum = append(s.Enum, n)
+			}
+		default:
+			s.Enum = append(s.Enum, value)
+		}
+	}
+
+	switch typ {
+	case "integer", "number":
+		s.Minimum, s.ExclusiveMinimum = v.min, v.gt && v.min != nil
+		s.Maximum, s.ExclusiveMaximum = v.max, v.lt && v.max != nil
+	case "string":
+		s.MinLength, s.MaxLength = length(v.min, v.gt, 1), length(v.max, v.lt, -1)
+	case "array":
+		s.MinItems, s.MaxItems = length(v.min, v.gt, 1), length(v.max, v.lt, -1)
+	case "object":
+		s.MinProperties, s.MaxProperties = length(v.min, v.gt, 1), length(v.max, v.lt, -1)
+	}
+}
+
+// length converts a bound into a length, adjusted by delta if exclusive.
+func length(bound *float64, exclusive bool, delta int64) *uint64 {
+	if bound == nil {
+		return nil
+	}
+	n := int64(*bound)
+	if exclusive {
+		n += delta
+	}
+	if n < 0 {
+		n = 0
+	}
+	l := uint64(n)
+	return &l
+}
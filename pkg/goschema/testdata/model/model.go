@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Status of a pet.
+type Status string
+
+// Statuses
+const (
+	Available Status = "available"
+	Sold      Status = "sold"
+)
+
+// Base holds the fields common to all resources.
+type Base struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Pet is a pet of the store.
+type Pet struct {
+	Base
+	// Name of the pet.
+	Name   string            `json:"name" validate:"required,min=1,max=64"`
+	Status Status            `json:"status,omitempty"`
+	Tags   []string          `json:"tags,omitempty" validate:"max=10,dive,min=1"`
+	Owner  *Owner            `json:"owner"`
+	Labels map[string]string `json:"labels,omitempty"`
+	secret string
+	Skip   string `json:"-"`
+}
+
+// Owner owns pets.
+type Owner struct {
+	Email string `json:"email" validate:"email"`
+	Pets  []*Pet `json:"pets,omitempty"`
+}
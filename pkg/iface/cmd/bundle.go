@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/cry999/gopenapi/pkg/filter"
 	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/swagger2"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 func init() {
@@ -13,24 +20,192 @@ func init() {
 
 	bundleCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "openapi.yml", "bundled output file (default is openapi.yml)")
 	bundleCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "")
+	bundleCmd.PersistentFlags().StringVar(&bundleFormat, "format", "openapi", "format of the bundled document (openapi, swagger2)")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleTargets, "target", nil, "bundle the named targets of the configuration file instead")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.IncludeTags, "include-tag", nil, "keep the operations with one of the tags only")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.ExcludeTags, "exclude-tag", nil, "drop the operations with one of the tags")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.IncludePaths, "include-path", nil, "keep the paths matching one of the patterns only")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.ExcludePaths, "exclude-path", nil, "drop the paths matching one of the patterns")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.Operations, "operation", nil, "keep the operations with one of the operationIds only")
+	bundleCmd.PersistentFlags().StringSliceVar(&bundleFilter.Audiences, "audience", nil, "keep the operations and schemas of the audiences, or without x-audience, only")
+	bundleCmd.PersistentFlags().BoolVar(&bundleFilter.ExcludeInternal, "exclude-internal", false, "drop the operations and schemas marked with x-internal")
+	addWatchFlags(bundleCmd, &bundleWatch)
 
 	rootCmd.AddCommand(bundleCmd)
 }
 
 var (
-	outputFile string
+	outputFile    string
+	bundleFormat  string
+	bundleTargets []string
+	bundleFilter  filter.Filter
+	bundleWatch   bool
 
 	bundleCmd = &cobra.Command{
 		Use:   "bundle",
 		Short: "Bundle files into one file, `openapy.yml`",
-		RunE:  bundleRun,
+		Long: `Bundle files into one file, ` + "`openapy.yml`" + `.
+
+The bundled document can be cut down to a slice of the API, which keeps the
+operations selected by all the filters given:
+
+  bundle --include-tag Billing --exclude-path '/admin/**' --operation getUser
+
+Path patterns match paths segment by segment, where * matches one segment and
+** any number of them. With --audience or --exclude-internal, the bundled
+document only holds the operations and schemas of the audiences, or those not
+marked as internal:
+
+  x-internal: true
+  x-audience: [partners, internal]
+
+Properties of dropped schemas are removed from their objects. Components which
+the remaining operations do not use, directly or through other components, are
+removed too, as are the tags of dropped operations.
+
+With --target, the named variants of the configuration file are bundled
+instead, each into its own output, in one run:
+
+  bundle:
+    targets:
+      public:
+        output: dist/public.yml
+        excludeInternal: true
+        excludePaths: [/admin/**]
+      partners:
+        output: dist/partners.yml
+        audiences: [partners]
+        includeTags: [Billing]
+
+With --watch, the project is bundled again whenever its files change, decoding
+only the files which changed. The output file is only written when the bundled
+document changes.`,
+		RunE: bundleRun,
 	}
 )
 
+// bundleOutput is a document written by bundle.
+type bundleOutput struct {
+	file   string
+	format string
+	filter *filter.Filter
+}
+
+// bundleOutputs returns the documents to bundle, either the output of the
+// flags or the targets.
+func bundleOutputs() ([]*bundleOutput, error) {
+	if len(bundleTargets) == 0 {
+		return []*bundleOutput{{file: outputFile, format: bundleFormat, filter: &bundleFilter}}, nil
+	}
+	if !bundleFilter.IsZero() {
+		return nil, fmt.Errorf("targets cannot be filtered by flags")
+	}
+	var outputs []*bundleOutput
+	for _, name := range bundleTargets {
+		target, ok := cfg.Bundle.Targets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown bundle target '%s'", name)
+		}
+		format := target.Format
+		if format == "" {
+			format = bundleFormat
+		}
+		outputs = append(outputs, &bundleOutput{file: target.Output, format: format, filter: &target.Filter})
+	}
+	return outputs, nil
+}
+
 func bundleRun(cmd *cobra.Command, args []string) error {
-	spec, err := openapi.LoadProject(projectDir)
+	outputs, err := bundleOutputs()
 	if err != nil {
-		return fmt.Errorf("failed to load project '%s': %v", projectDir, err)
+		return err
+	}
+	if !bundleWatch {
+		_, err := bundle(cmd, outputs, nil)
+		return err
+	}
+
+	last := map[string][]byte{}
+	ignore := make([]string, len(outputs))
+	for i, output := range outputs {
+		ignore[i] = output.file
+	}
+	cache := openapi.NewCache()
+	return runWatching(cmd, ignore, func(logger *log.Logger) error {
+		start := time.Now()
+		written, err := bundle(cmd, outputs, last, openapi.WithCache(cache))
+		if err != nil {
+			return err
+		}
+		for _, output := range outputs {
+			if bytes.Equal(written[output.file], last[output.file]) {
+				logger.Printf("%s is up to date", output.file)
+			} else {
+				logger.Printf("bundled %s in %v", output.file, time.Since(start).Round(time.Millisecond))
+			}
+		}
+		last = written
+		return nil
+	})
+}
+
+// bundle bundles the project into the outputs, except those whose bundled
+// document is the same as last. It returns the bundled documents by file.
+func bundle(cmd *cobra.Command, outputs []*bundleOutput, last map[string][]byte, opts ...openapi.LoadOption) (map[string][]byte, error) {
+	spec, err := openapi.LoadProject(projectDir, append(opts, openapi.WithLayout(cfg.Layout))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project '%s': %v", projectDir, err)
+	}
+
+	written := map[string][]byte{}
+	for _, output := range outputs {
+		b, err := bundleTo(cmd, spec, output, last[output.file])
+		if err != nil {
+			return nil, fmt.Errorf("failed to bundle '%s': %v", output.file, err)
+		}
+		written[output.file] = b
+	}
+	return written, nil
+}
+
+// bundleTo writes a document into its output, unless it is the same as last.
+func bundleTo(cmd *cobra.Command, spec *openapi.OpenAPI, output *bundleOutput, last []byte) ([]byte, error) {
+	if !output.filter.IsZero() {
+		var err error
+		if spec, err = filter.Apply(spec, output.filter); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc interface{}
+	switch output.format {
+	case "openapi":
+		doc = spec
+	case "swagger2":
+		var warnings []swagger2.Warning
+		doc, warnings = swagger2.FromOpenAPI(spec)
+		for _, w := range warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", w)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported bundle format '%s'", output.format)
+	}
+
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && bytes.Equal(b, last) {
+		return b, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(output.file), 0o755); err != nil {
+		return nil, err
+	}
+	switch doc := doc.(type) {
+	case *swagger2.Swagger:
+		err = swagger2.DumpInOneFile(output.file, doc)
+	default:
+		err = openapi.DumpInOneFile(output.file, spec)
 	}
-	return openapi.DumpInOneFile(outputFile, spec)
+	return b, err
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/diff"
+	"github.com/cry999/gopenapi/pkg/git"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	changelogCmd.PersistentFlags().StringVar(&changelogFrom, "from", "", "git revision of the previous release")
+	changelogCmd.PersistentFlags().StringVar(&changelogTo, "to", "HEAD", "git revision of the new release")
+	changelogCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory (default is $(pwd))")
+	changelogCmd.MarkPersistentFlagRequired("from")
+
+	rootCmd.AddCommand(changelogCmd)
+}
+
+var (
+	// flags
+	changelogFrom string
+	changelogTo   string
+
+	// command
+	changelogCmd = &cobra.Command{
+		Use:   "changelog",
+		Short: "Generate a changelog of the project between two git revisions",
+		RunE:  changelogRun,
+	}
+)
+
+func changelogRun(cmd *cobra.Command, args []string) error {
+	base, err := loadProjectAt(projectDir, changelogFrom)
+	if err != nil {
+		return err
+	}
+	revision, err := loadProjectAt(projectDir, changelogTo)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("Changes from %s to %s", changelogFrom, changelogTo)
+	if revision.Info != nil && revision.Info.Version != "" {
+		title = fmt.Sprintf("%s (%s...%s)", revision.Info.Version, changelogFrom, changelogTo)
+	}
+	return diff.WriteChangelog(cmd.OutOrStdout(), title, diff.Compare(base, revision))
+}
+
+func loadProjectAt(dir, rev string) (*openapi.OpenAPI, error) {
+	root, cleanup, err := git.Checkout(dir, rev)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	spec, err := openapi.LoadProject(root, openapi.WithLayout(cfg.Layout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project at '%s': %v", rev, err)
+	}
+	return spec, nil
+}
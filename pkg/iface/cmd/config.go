@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// cfg holds the settings read from the configuration file, if any.
+var cfg = &config.Config{}
+
+// applyConfig reads the configuration file, and sets the flags of the
+// command which are not given to its settings.
+func applyConfig(cmd *cobra.Command, args []string) error {
+	filename := cfgFile
+	if filename == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		if filename, err = config.Find(wd); err != nil || filename == "" {
+			return err
+		}
+	}
+	c, err := config.Load(filename)
+	if err != nil {
+		return err
+	}
+	cfg = c
+
+	settings := map[string]string{"project-dir": cfg.Project}
+	switch {
+	case cmd == bundleCmd:
+		settings["output"] = cfg.Bundle.Output
+		settings["format"] = cfg.Bundle.Format
+	case cmd.Parent() == generateCmd:
+		settings["package"] = cfg.Generate.Package
+		settings["output"] = cfg.Generate.Outputs[cmd.Name()]
+	}
+	for name, value := range settings {
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Changed || value == "" {
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cry999/gopenapi/pkg/diff"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	diffCmd.PersistentFlags().StringVar(&diffFormat, "format", "text", "output format (text, markdown, json)")
+	diffCmd.PersistentFlags().StringVar(&diffFailOn, "fail-on", "", "exit with an error when changes of this kind are found (breaking, any)")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+var (
+	// flags
+	diffFormat string
+	diffFailOn string
+
+	// command
+	diffCmd = &cobra.Command{
+		Use:   "diff <old> <new>",
+		Short: "Compare two versions of a project or bundled file",
+		Long: `Compare two versions of an API semantically and classify the changes
+as breaking or non-breaking. Each argument is either a project directory or
+a bundled file.`,
+		Args: cobra.ExactArgs(2),
+		RunE: diffRun,
+	}
+)
+
+func diffRun(cmd *cobra.Command, args []string) error {
+	base, err := loadSpec(args[0])
+	if err != nil {
+		return err
+	}
+	revision, err := loadSpec(args[1])
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Compare(base, revision)
+	if err := writeChanges(cmd, diffFormat, changes); err != nil {
+		return err
+	}
+
+	switch diffFailOn {
+	case "":
+	case "breaking":
+		if n := len(diff.Breaking(changes)); n > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d breaking changes found", n)
+		}
+	case "any":
+		if n := len(changes); n > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d changes found", n)
+		}
+	default:
+		return fmt.Errorf("unsupported --fail-on value '%s'", diffFailOn)
+	}
+	return nil
+}
+
+func writeChanges(cmd *cobra.Command, format string, changes []*diff.Change) error {
+	w := cmd.OutOrStdout()
+	switch format {
+	case "text":
+		return diff.WriteText(w, changes)
+	case "markdown":
+		return diff.WriteMarkdown(w, changes)
+	case "json":
+		return diff.WriteJSON(w, changes)
+	default:
+		return fmt.Errorf("unsupported format '%s'", format)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/docs"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	docsCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory or bundled file (default is $(pwd))")
+	docsCmd.PersistentFlags().StringVar(&docsOut, "out", "site", "directory to write the documentation to")
+	docsCmd.PersistentFlags().StringVar(&docsFormat, "format", "html", "format of the documentation: html or markdown")
+	docsCmd.PersistentFlags().BoolVar(&docsSingle, "single", false, "write the markdown documentation in one file")
+	docsCmd.PersistentFlags().StringVar(&docsTemplates, "templates", "", "directory of templates replacing the default ones")
+
+	rootCmd.AddCommand(docsCmd)
+}
+
+var (
+	// flags
+	docsOut       string
+	docsFormat    string
+	docsSingle    bool
+	docsTemplates string
+
+	// command
+	docsCmd = &cobra.Command{
+		Use:   "docs",
+		Short: "Render a reference documentation of the project",
+		Long: `Render a reference documentation of the project.
+
+The html format writes a static site: an index of the operations grouped by
+tag, a page per tag, per operation and per component schema. Operation pages
+show the parameters, request bodies and responses with their schemas as
+expandable trees, their examples and the security requirements. The site
+embeds its stylesheet and script, and can be browsed from the file system.
+
+The markdown format writes a README.md giving an overview of the API, a file
+per tag under tags/ describing its operations, and a schemas.md describing the
+component schemas, with tables of their parameters and fields linking to each
+other. With --single, everything goes to the README.md.
+
+Both formats are rendered by Go templates, which --templates replaces: a file
+of the directory named after a default template, such as tag.md, replaces it,
+and the templates it defines, such as "operation", replace those of the same
+name. Run with --templates pointing to an empty directory and read the
+templates of pkg/docs/templates to start with.`,
+		RunE: docsRun,
+	}
+)
+
+func docsRun(cmd *cobra.Command, args []string) error {
+	spec, err := loadSpec(projectDir)
+	if err != nil {
+		return err
+	}
+	site := docs.NewSite(spec)
+
+	var files docs.Files
+	switch docsFormat {
+	case "html":
+		files, err = docs.HTML(site, docsTemplates)
+	case "markdown":
+		files, err = docs.Markdown(site, docsSingle, docsTemplates)
+	default:
+		return fmt.Errorf("unknown format '%s'", docsFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render the documentation: %v", err)
+	}
+	return files.Write(docsOut)
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cry999/gopenapi/pkg/codegen"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	generateCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory or bundled file (default is $(pwd))")
+	generateCmd.PersistentFlags().StringVar(&generatePackage, "package", "api", "package name of the generated code")
+	generateCmd.PersistentFlags().StringVarP(&generateOutput, "output", "o", "", "generated file (default is stdout)")
+	generateCmd.PersistentFlags().StringVar(&generateConfig, "config", "", "YAML file mapping schemas and formats to existing Go types")
+
+	generateCmd.AddCommand(generateServerCmd)
+	generateCmd.AddCommand(generateClientCmd)
+	generateCmd.AddCommand(generateModelsCmd)
+	generateCmd.AddCommand(generateTypeScriptCmd)
+	generateCmd.AddCommand(generateEmbedCmd)
+	rootCmd.AddCommand(generateCmd)
+}
+
+var (
+	// flags
+	generatePackage string
+	generateOutput  string
+	generateConfig  string
+
+	// command
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate code from the project",
+	}
+	generateServerCmd = &cobra.Command{
+		Use:   "server",
+		Short: "Generate a Go server interface, its types and its routing",
+		Long: `Generate a Go server from the project.
+
+The generated file declares a type for every component schema, a
+ServerInterface with one method per operation, named after its operationId,
+and a Handler decoding the parameters and JSON body of requests before
+calling the methods of a ServerInterface.`,
+		RunE: generateRun(codegen.GenerateServer),
+	}
+	generateClientCmd = &cobra.Command{
+		Use:   "client",
+		Short: "Generate a typed Go client",
+		Long: `Generate a typed Go client from the project.
+
+The generated file declares a type for every component schema and a Client
+with one method per operation, named after its operationId. Responses are
+decoded into a field per documented status code. Requests are sent to the
+first server unless WithBaseURL is given, and each security scheme gets an
+option setting its credentials: an API key, a bearer token, a user name and
+password, or an OAuth2 token source.
+
+Generate the client and the server in different packages, as both declare
+the types of the component schemas.`,
+		RunE: generateRun(codegen.GenerateClient),
+	}
+	generateModelsCmd = &cobra.Command{
+		Use:   "models",
+		Short: "Generate Go types for the component schemas",
+		Long: `Generate Go types for the component schemas of the project.
+
+Objects become structs with json tags, whose optional and nullable fields are
+pointers. Enums become types with a constant per value, and oneOf schemas with
+a discriminator become a struct holding one of their variants, which all
+implement a sealed interface. Strings of format date-time are time.Time and
+those of format uuid a generated UUID type.
+
+The --config file maps schemas and formats to existing Go types, written with
+their import path:
+
+  types:
+    Money: github.com/shopspring/decimal.Decimal
+  formats:
+    uuid: github.com/google/uuid.UUID`,
+		RunE: generateRun(codegen.GenerateModels),
+	}
+	generateTypeScriptCmd = &cobra.Command{
+		Use:   "typescript",
+		Short: "Generate TypeScript types and a fetch based client",
+		Long: `Generate TypeScript types and a fetch based client from the project.
+
+Objects become interfaces whose optional properties are those which are not
+required, enums become unions of literal types, and oneOf schemas unions of
+their variants. Operations become methods of a client class per tag, named
+after their first tag, which send requests with fetch and throw an ApiError
+for unsuccessful responses. The --package and --config flags are ignored.`,
+		RunE: generateRun(codegen.GenerateTypeScript),
+	}
+	generateEmbedCmd = &cobra.Command{
+		Use:   "embed",
+		Short: "Generate a Go package embedding the bundled document",
+		Long: `Generate a Go file embedding the bundled document of the project.
+
+The document is bundled into ` + codegen.EmbedYAML + ` and ` + codegen.EmbedJSON + `, written next to the
+--output file, which is required. The generated file embeds both and declares
+Spec, returning the document parsed on its first call, and YAML and JSON,
+returning it as written, so that a service serves and validates requests
+against the document it was built with:
+
+  handler = validate.Middleware(spec.Spec())(handler)
+
+The --config flag is ignored.`,
+		RunE: generateEmbedRun,
+	}
+)
+
+// generateRun runs a generator on the project.
+func generateRun(generate func(*openapi.OpenAPI, string, *codegen.Config) ([]byte, error)) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		spec, err := loadSpec(projectDir)
+		if err != nil {
+			return err
+		}
+		// the --config file replaces the types and formats of gopenapi.yml
+		types := &cfg.Generate.Config
+		if generateConfig != "" {
+			if types, err = codegen.LoadConfig(generateConfig); err != nil {
+				return err
+			}
+		}
+		src, err := generate(spec, generatePackage, types)
+		if err != nil {
+			return err
+		}
+		return writeGenerated(cmd, generateOutput, src)
+	}
+}
+
+func generateEmbedRun(cmd *cobra.Command, args []string) error {
+	if generateOutput == "" {
+		return fmt.Errorf("embed needs an --output file, next to which the document is written")
+	}
+	spec, err := loadSpec(projectDir)
+	if err != nil {
+		return err
+	}
+	yml, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	js, err := openapi.EncodeJSON(spec)
+	if err != nil {
+		return err
+	}
+	src, err := codegen.GenerateEmbed(generatePackage)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(generateOutput)
+	if err := writeGenerated(cmd, filepath.Join(dir, codegen.EmbedYAML), yml); err != nil {
+		return err
+	}
+	if err := writeGenerated(cmd, filepath.Join(dir, codegen.EmbedJSON), js); err != nil {
+		return err
+	}
+	return writeGenerated(cmd, generateOutput, src)
+}
+
+// writeGenerated writes src to filename, or stdout if empty. An unchanged
+// file is left untouched so that regenerating does not trigger rebuilds.
+func writeGenerated(cmd *cobra.Command, filename string, src []byte) error {
+	if filename == "" {
+		_, err := cmd.OutOrStdout().Write(src)
+		return err
+	}
+	if current, err := ioutil.ReadFile(filename); err == nil && bytes.Equal(current, src) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, src, 0o644)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/swagger2"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	importCmd.PersistentFlags().StringVar(&importFrom, "from", "swagger2", "format of the imported document (swagger2)")
+	importCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory to write (default is $(pwd))")
+
+	rootCmd.AddCommand(importCmd)
+}
+
+var (
+	// flags
+	importFrom string
+
+	// command
+	importCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a document of another format as a gopenapi project",
+		Args:  cobra.ExactArgs(1),
+		RunE:  importRun,
+	}
+)
+
+func importRun(cmd *cobra.Command, args []string) error {
+	var (
+		spec *openapi.OpenAPI
+		err  error
+	)
+
+	switch importFrom {
+	case "swagger2":
+		spec, err = importSwagger2(args[0])
+	default:
+		return fmt.Errorf("unsupported import format '%s'", importFrom)
+	}
+	if err != nil {
+		return err
+	}
+
+	return openapi.DumpProject(projectDir, spec, openapi.WithDumpLayout(cfg.Layout))
+}
+
+func importSwagger2(filename string) (*openapi.OpenAPI, error) {
+	doc, err := swagger2.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load '%s': %v", filename, err)
+	}
+	if doc.Swagger != "2.0" {
+		return nil, fmt.Errorf("'%s' is not a Swagger 2.0 document", filename)
+	}
+	spec, err := swagger2.ToOpenAPI(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert '%s': %v", filename, err)
+	}
+	return spec, nil
+}
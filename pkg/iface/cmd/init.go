@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/cry999/gopenapi/pkg/config"
 	"github.com/cry999/gopenapi/pkg/openapi"
 	"github.com/spf13/cobra"
 )
@@ -38,22 +39,20 @@ example
 ├── info.yml
 ├── security.yml
 └── servers.yml
+
+or those of the layout of ` + config.Filename + `.
 `,
 		RunE: runInit,
 	}
-
-	initDirectories = []string{
-		"components/headers",
-		"components/parameters",
-		"components/requestBodies",
-		"components/responses",
-		"components/schemas",
-		"paths",
-	}
 )
 
 func runInit(cmd *cobra.Command, args []string) error {
-	for _, dir := range initDirectories {
+	layout := cfg.Layout.Complete()
+	dirs := []string{layout.Paths}
+	for _, kind := range []string{layout.Headers, layout.Parameters, layout.RequestBodies, layout.Responses, layout.Schemas} {
+		dirs = append(dirs, filepath.Join(layout.Components, kind))
+	}
+	for _, dir := range dirs {
 		dir = filepath.Join(projectDir, dir)
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return err
@@ -61,41 +60,27 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	project := filepath.Base(projectDir)
-	if err := openapi.DumpOpenAPIVersion(projectDir, "3.0.0"); err != nil {
-		return err
-	}
-
-	if err := openapi.DumpInfo(projectDir, &openapi.Info{
-		Title:       project,
-		Description: "Auto generated by gopenapi",
-		Version:     "0.0.1",
-	}); err != nil {
-		return err
-	}
-
-	if err := openapi.DumpServers(projectDir, []*openapi.Server{
-		{
-			URL:         openapi.MustParseURL("http://localhost/api/v1"),
-			Description: "Local development",
+	return openapi.DumpProject(projectDir, &openapi.OpenAPI{
+		Version: "3.0.0",
+		Info: &openapi.Info{
+			Title:       project,
+			Description: "Auto generated by gopenapi",
+			Version:     "0.0.1",
 		},
-	}); err != nil {
-		return err
-	}
-
-	if err := openapi.DumpSecurity(projectDir, []openapi.SecurityRequirement{
-		{"apiKey": []string{}},
-	}); err != nil {
-		return err
-	}
-
-	if err := openapi.DumpTags(projectDir, []*openapi.Tag{
-		{
-			Name:        "Sample",
-			Description: "Sample API groups",
+		Servers: []*openapi.Server{
+			{
+				URL:         openapi.MustParseURL("http://localhost/api/v1"),
+				Description: "Local development",
+			},
 		},
-	}); err != nil {
-		return err
-	}
-
-	return nil
+		Security: []openapi.SecurityRequirement{
+			{"apiKey": []string{}},
+		},
+		Tags: []*openapi.Tag{
+			{
+				Name:        "Sample",
+				Description: "Sample API groups",
+			},
+		},
+	}, openapi.WithDumpLayout(layout))
 }
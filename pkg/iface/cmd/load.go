@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// loadSpec loads either a project directory, laid out as configured, or a
+// bundled file.
+func loadSpec(path string, opts ...openapi.LoadOption) (*openapi.OpenAPI, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec *openapi.OpenAPI
+	if fi.IsDir() {
+		spec, err = openapi.LoadProject(path, append(opts, openapi.WithLayout(cfg.Layout))...)
+	} else {
+		spec, err = openapi.LoadInOneFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load '%s': %v", path, err)
+	}
+	return spec, nil
+}
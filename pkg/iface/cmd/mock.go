@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/mock"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	mockCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory (default is $(pwd))")
+	mockCmd.PersistentFlags().StringVar(&mockHost, "host", "127.0.0.1", "address to listen on")
+	mockCmd.PersistentFlags().IntVar(&mockPort, "port", 4010, "port to listen on")
+	mockCmd.PersistentFlags().BoolVar(&mockValidate, "validate", true, "reject requests which do not conform to the API")
+	mockCmd.PersistentFlags().BoolVar(&mockStateful, "stateful", false, "keep created resources in memory")
+
+	rootCmd.AddCommand(mockCmd)
+}
+
+var (
+	// flags
+	mockHost     string
+	mockPort     int
+	mockValidate bool
+	mockStateful bool
+
+	// command
+	mockCmd = &cobra.Command{
+		Use:   "mock",
+		Short: "Serve mock responses generated from the project",
+		Long: `Serve mock responses generated from the project.
+
+Each request is routed to the operation of its path and method, and answered
+with an example of the first successful response. Clients can pick another
+response or a named example with the Prefer header:
+
+  Prefer: code=404
+  Prefer: example=empty
+
+Paths are served below the base paths of the servers, such as /v1/pets for a
+server at https://api.example.com/v1, and from the root as well, as /pets.
+
+Requests are validated against the parameters and request body of the
+operation. Invalid requests are answered with a problem+json body listing the
+violations, with status 400 for invalid parameters or undecodable bodies and
+422 for bodies which do not match their schema.
+
+With --stateful, paths such as /users and /users/{id} are served as a
+collection and its items kept in memory: POST creates an item, GET lists or
+fetches them, PUT replaces, PATCH merges and DELETE removes one. Stored items
+are validated against the schema of the resource. Requests with a Prefer
+header are still answered with the documented examples.`,
+		RunE: mockRun,
+	}
+)
+
+func mockRun(cmd *cobra.Command, args []string) error {
+	spec, err := openapi.LoadProject(projectDir, openapi.WithLayout(cfg.Layout))
+	if err != nil {
+		return fmt.Errorf("failed to load project '%s': %v", projectDir, err)
+	}
+
+	logger := log.New(cmd.ErrOrStderr(), "", log.LstdFlags)
+	addr := fmt.Sprintf("%s:%d", mockHost, mockPort)
+	logger.Printf("mock server listening on http://%s", addr)
+
+	return http.ListenAndServe(addr, mock.New(spec,
+		mock.WithLogger(logger),
+		mock.WithValidation(mockValidate),
+		mock.WithState(mockStateful),
+	))
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"github.com/cry999/gopenapi/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config-file", "", "configuration file (default is "+config.Filename+" in the working directory or its parents)")
+}
+
 var (
 	cfgFile     string
 	userLicense string
@@ -11,6 +16,31 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   "gopenapi",
 		Short: "Utitlity tools for OpenAPI implementing by Go",
+		Long: `Utitlity tools for OpenAPI implementing by Go.
+
+Settings are read from ` + config.Filename + `, looked for in the working directory and
+its parents unless --config-file is given. Flags override them:
+
+  project: api
+  layout:
+    components: shared
+    extension: .yaml
+  bundle:
+    output: dist/openapi.yml
+    format: openapi
+  lint:
+    rules:
+      operation-ids: false
+  generate:
+    package: api
+    outputs:
+      server: internal/api/server.go
+    formats:
+      uuid: github.com/google/uuid.UUID
+
+The layout renames the directories and files of the project, whose defaults
+are those created by init.`,
+		PersistentPreRunE: applyConfig,
 	}
 )
 
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/scan"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	scanCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory to write (default is $(pwd))")
+	scanCmd.PersistentFlags().BoolVar(&scanForce, "force", false, "overwrite files edited by hand")
+
+	rootCmd.AddCommand(scanCmd)
+}
+
+var (
+	// flags
+	scanForce bool
+
+	// command
+	scanCmd = &cobra.Command{
+		Use:   "scan [packages]",
+		Short: "Write operations declared in the comments of Go functions",
+		Long: `Write the operations declared in the doc comments of Go functions into the
+project, one paths/<path>/<method>.yml file per operation.
+
+A function declares an operation with a @route annotation, described by the
+following ones:
+
+  // GetUser returns a user.
+  //
+  // @route GET /users/{id}
+  // @summary Get a user
+  // @id getUser
+  // @tags users
+  // @param id path int64 "id of the user"
+  // @param fields query []string required "fields to include"
+  // @body UserPatch application/json "changes to apply"
+  // @response 200 User
+  // @response 404 Problem "no such user"
+  // @security bearerAuth
+  // @deprecated
+  func GetUser(w http.ResponseWriter, r *http.Request)
+
+The rest of the comment becomes the description, and the name of the function
+the default operationId. Types are primitives
+(string, int32, int64, number, boolean, date, time...), names of component
+schemas, or arrays of them written []T.
+
+Files are only updated while they keep the content last written by scan.
+Files edited by hand, or which were not written by scan, are reported as
+conflicts instead, unless --force is given.`,
+		RunE: scanRun,
+	}
+)
+
+func scanRun(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	ops, err := scan.Packages(wd, args...)
+	if err != nil {
+		return err
+	}
+	result, err := scan.Write(projectDir, cfg.Layout, ops, scanForce)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range result.Written {
+		fmt.Fprintf(cmd.OutOrStdout(), "wrote %s\n", file)
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(cmd.ErrOrStderr(), "conflict: %s\n", conflict)
+	}
+	if len(result.Conflicts) > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d files conflict, edit them by hand or use --force", len(result.Conflicts))
+	}
+	return nil
+}
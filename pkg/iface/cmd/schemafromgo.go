@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/goschema"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	schemaFromGoCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory to write (default is $(pwd))")
+
+	rootCmd.AddCommand(schemaFromGoCmd)
+}
+
+var (
+	// command
+	schemaFromGoCmd = &cobra.Command{
+		Use:   "schema-from-go <package>.<type>...",
+		Short: "Derive component schemas from Go types",
+		Long: `Derive component schemas from Go types, parsed from source.
+
+Each argument is a package, relative to the working directory or an import
+path, followed by the name of a type: ./internal/model.User. A package alone,
+possibly with the ... wildcard, selects all its exported types.
+
+Schemas follow the encoding of encoding/json: json tags name the properties,
+fields without omitempty are required, and embedded exported structs become
+allOf references. Named types referred to get schemas of their own, time.Time
+becomes a date-time string, and constants of a named type become its enum.
+Doc comments become descriptions, and validate tags add constraints:
+
+  Name  string   ` + "`json:\"name\" validate:\"required,min=1,max=64\"`" + `
+  Email string   ` + "`json:\"email,omitempty\" validate:\"email\"`" + `
+  Tags  []string ` + "`json:\"tags,omitempty\" validate:\"max=10\"`" + `
+
+Existing files of the same schemas are overwritten.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: schemaFromGoRun,
+	}
+)
+
+func schemaFromGoRun(cmd *cobra.Command, args []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	schemas, err := goschema.Schemas(wd, args...)
+	if err != nil {
+		return err
+	}
+	return openapi.DumpComponents(projectDir, &openapi.Components{Schemas: schemas})
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/preview"
+	"github.com/cry999/gopenapi/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	serveCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory or bundled file (default is $(pwd))")
+	serveCmd.PersistentFlags().StringVar(&serveHost, "host", "127.0.0.1", "address to listen on")
+	serveCmd.PersistentFlags().IntVar(&servePort, "port", 8080, "port to listen on")
+	serveCmd.PersistentFlags().DurationVar(&serveInterval, "interval", 500*time.Millisecond, "time the project files stay unchanged before loading them again, and between checks where changes are not notified")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+var (
+	// flags
+	serveHost     string
+	servePort     int
+	serveInterval time.Duration
+
+	// command
+	serveCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Preview the documentation of the project while editing it",
+		Long: `Preview the documentation of the project while editing it.
+
+The bundled document is served at /openapi.yml and /openapi.json, and its HTML
+reference, as written by the docs command, at /. Once the project files have
+changed and then stayed unchanged for --interval, the project is loaded again
+and the open pages reload themselves. When the project fails to load, the
+pages show the error until it is fixed.
+
+Changes are notified by the system on Linux. Elsewhere, or when notifications
+fail, such as when the limit of inotify watches is reached, the files are
+checked every --interval instead.`,
+		RunE: serveRun,
+	}
+)
+
+func serveRun(cmd *cobra.Command, args []string) error {
+	logger := log.New(cmd.ErrOrStderr(), "", log.LstdFlags)
+	cache := openapi.NewCache()
+	server := preview.New(func() (*openapi.OpenAPI, error) {
+		return loadSpec(projectDir, openapi.WithCache(cache))
+	}, preview.WithLogger(logger))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		err := watch.Watch(ctx, projectDir, serveInterval, func(files []string) {
+			logger.Printf("%s changed", describeChanges(projectDir, files))
+			server.Reload()
+		})
+		if err != nil && err != context.Canceled {
+			logger.Printf("failed to watch '%s': %v", projectDir, err)
+		}
+	}()
+
+	addr := fmt.Sprintf("%s:%d", serveHost, servePort)
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: server,
+		// the reload streams of the pages end with the context
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- httpServer.ListenAndServe()
+	}()
+	logger.Printf("serving the documentation on http://%s", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cry999/gopenapi/pkg/lint"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	wd, _ := os.Getwd()
+
+	validateCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", wd, "project directory or bundled file (default is $(pwd))")
+	addWatchFlags(validateCmd, &validateWatch)
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+var (
+	// flags
+	validateWatch bool
+
+	// command
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check the project for mistakes",
+		Long: `Check the project for mistakes.
+
+The project is loaded and checked for mistakes which loading it does not
+catch: a missing title or version, references to components which do not
+exist, operations without responses, undeclared or unused path parameters,
+duplicate operationIds and undefined security schemes. Each problem is
+printed with its location in the bundled document and the rule it breaks.
+
+Rules disabled in the lint section of the configuration file are skipped.
+With --watch, the project is checked again whenever its files change.`,
+		RunE:         validateRun,
+		SilenceUsage: true,
+	}
+)
+
+func validateRun(cmd *cobra.Command, args []string) error {
+	if !validateWatch {
+		return validateProject(cmd)
+	}
+	cache := openapi.NewCache()
+	return runWatching(cmd, nil, func(logger *log.Logger) error {
+		if err := validateProject(cmd, openapi.WithCache(cache)); err != nil {
+			return err
+		}
+		logger.Printf("no problems found")
+		return nil
+	})
+}
+
+func validateProject(cmd *cobra.Command, opts ...openapi.LoadOption) error {
+	spec, err := loadSpec(projectDir, opts...)
+	if err != nil {
+		return err
+	}
+	problems := lint.CheckRules(spec, cfg.LintRules())
+	for _, p := range problems {
+		fmt.Fprintln(cmd.OutOrStdout(), p)
+	}
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("1 problem found")
+	default:
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+}
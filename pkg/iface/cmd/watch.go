@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cry999/gopenapi/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+// addWatchFlags adds the --watch and --interval flags to a command.
+func addWatchFlags(cmd *cobra.Command, enabled *bool) {
+	cmd.PersistentFlags().BoolVarP(enabled, "watch", "w", false, "run again whenever the project files change")
+	cmd.PersistentFlags().DurationVar(&watchInterval, "interval", 500*time.Millisecond, "time the project files stay unchanged before running again, and between checks where changes are not notified")
+}
+
+var watchInterval time.Duration
+
+// runWatching runs run, then runs it again whenever the files of the project
+// change, except for the ignored ones, until interrupted. Each run is
+// logged with its changes and its outcome.
+func runWatching(cmd *cobra.Command, ignore []string, run func(logger *log.Logger) error) error {
+	logger := log.New(cmd.ErrOrStderr(), "", log.LstdFlags)
+	ignored := map[string]bool{}
+	for _, name := range ignore {
+		if abs, err := filepath.Abs(name); err == nil {
+			ignored[abs] = true
+		}
+	}
+
+	report := func() {
+		if err := run(logger); err != nil {
+			logger.Printf("failed: %v", err)
+		}
+	}
+	report()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	logger.Printf("watching '%s' for changes", projectDir)
+	err := watch.Watch(ctx, projectDir, watchInterval, func(files []string) {
+		var changes []string
+		for _, name := range files {
+			if abs, err := filepath.Abs(name); err != nil || !ignored[abs] {
+				changes = append(changes, name)
+			}
+		}
+		if len(changes) == 0 {
+			return
+		}
+		logger.Printf("%s changed", describeChanges(projectDir, changes))
+		report()
+	})
+	if err == context.Canceled {
+		return nil
+	}
+	return err
+}
+
+// describeChanges names the changed files relative to root, up to three of
+// them.
+func describeChanges(root string, files []string) string {
+	const max = 3
+	var names []string
+	for i, name := range files {
+		if i == max {
+			break
+		}
+		if rel, err := filepath.Rel(root, name); err == nil && rel != "." {
+			name = rel
+		}
+		names = append(names, name)
+	}
+	description := strings.Join(names, ", ")
+	if len(files) > max {
+		description += fmt.Sprintf(" and %d more files", len(files)-max)
+	}
+	return description
+}
@@ -0,0 +1,185 @@
+// Package lint checks documents for mistakes which loading them does not
+// catch.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Problem is a mistake found in a document.
+type Problem struct {
+	// Rule names the check which found the problem.
+	Rule string
+	// Pointer locates the offending part of the document, as in
+	// `#/paths/~1pets/get`.
+	Pointer string
+	Message string
+}
+
+// String ...
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s (%s)", p.Pointer, p.Message, p.Rule)
+}
+
+// Rules lists the names of the checks.
+var Rules = []string{"info", "refs", "responses", "path-params", "operation-ids", "security"}
+
+// checks runs the check of each rule.
+var checks = map[string]func(spec *openapi.OpenAPI, report reporter){
+	"info":          checkInfo,
+	"refs":          checkRefs,
+	"responses":     checkResponses,
+	"path-params":   checkPathParams,
+	"operation-ids": checkOperationIDs,
+	"security":      checkSecurity,
+}
+
+type reporter func(pointer, format string, args ...interface{})
+
+// Check checks a document against all the rules, and returns the problems
+// found sorted by pointer.
+func Check(spec *openapi.OpenAPI) []*Problem {
+	return CheckRules(spec, Rules)
+}
+
+// CheckRules checks a document against the given rules only, which must be
+// among Rules.
+func CheckRules(spec *openapi.OpenAPI, rules []string) []*Problem {
+	var problems []*Problem
+	for _, rule := range rules {
+		rule := rule
+		checks[rule](spec, func(pointer, format string, args ...interface{}) {
+			problems = append(problems, &Problem{
+				Rule:    rule,
+				Pointer: pointer,
+				Message: fmt.Sprintf(format, args...),
+			})
+		})
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Pointer < problems[j].Pointer })
+	return problems
+}
+
+func checkInfo(spec *openapi.OpenAPI, report reporter) {
+	if spec.Version == "" {
+		report("#/openapi", "the OpenAPI version is missing")
+	}
+	if spec.Info == nil {
+		report("#/info", "info is missing")
+		return
+	}
+	if spec.Info.Title == "" {
+		report("#/info/title", "the title is missing")
+	}
+	if spec.Info.Version == "" {
+		report("#/info/version", "the version is missing")
+	}
+}
+
+func checkRefs(spec *openapi.OpenAPI, report reporter) {
+	openapi.WalkRefs(spec, func(pointer, ref string) {
+		if strings.HasPrefix(ref, "#") && spec.Lookup(ref) == nil {
+			report("#"+pointer, "%s does not exist", ref)
+		}
+	})
+}
+
+func checkResponses(spec *openapi.OpenAPI, report reporter) {
+	eachOperation(spec, func(pointer, path string, item *openapi.PathItem, op *openapi.Operation) {
+		if op.Responses == nil || len(*op.Responses) == 0 {
+			report(pointer+"/responses", "the operation has no responses")
+		}
+	})
+}
+
+var templateParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+func checkPathParams(spec *openapi.OpenAPI, report reporter) {
+	eachOperation(spec, func(pointer, path string, item *openapi.PathItem, op *openapi.Operation) {
+		declared := map[string]bool{}
+		for _, param := range spec.OperationParameters(item, op) {
+			if param.In != "path" {
+				continue
+			}
+			declared[param.Name] = true
+			if !strings.Contains(path, "{"+param.Name+"}") {
+				report(pointer+"/parameters", "path parameter %s is not in the path", param.Name)
+			} else if !param.Required {
+				report(pointer+"/parameters", "path parameter %s must be required", param.Name)
+			}
+		}
+		for _, m := range templateParam.FindAllStringSubmatch(path, -1) {
+			if !declared[m[1]] {
+				report(pointer+"/parameters", "path parameter %s is not declared", m[1])
+			}
+		}
+	})
+}
+
+func checkOperationIDs(spec *openapi.OpenAPI, report reporter) {
+	seen := map[string]string{}
+	eachOperation(spec, func(pointer, path string, item *openapi.PathItem, op *openapi.Operation) {
+		if op.OperationID == "" {
+			return
+		}
+		if first, ok := seen[op.OperationID]; ok {
+			report(pointer+"/operationId", "operationId %s is already used by %s", op.OperationID, first)
+			return
+		}
+		seen[op.OperationID] = pointer
+	})
+}
+
+func checkSecurity(spec *openapi.OpenAPI, report reporter) {
+	check := func(pointer string, r openapi.SecurityRequirement) {
+		for _, name := range sortedNames(r) {
+			if spec.Components == nil || spec.Components.SecuritySchemes[name] == nil {
+				report(pointer, "security scheme %s is not defined", name)
+			}
+		}
+	}
+	for i, r := range spec.Security {
+		check(fmt.Sprintf("#/security/%d", i), r)
+	}
+	eachOperation(spec, func(pointer, path string, item *openapi.PathItem, op *openapi.Operation) {
+		for i, r := range op.Security {
+			if r != nil {
+				check(fmt.Sprintf("%s/security/%d", pointer, i), *r)
+			}
+		}
+	})
+}
+
+// eachOperation calls fn for every operation, sorted by path and method.
+func eachOperation(spec *openapi.OpenAPI, fn func(pointer, path string, item *openapi.PathItem, op *openapi.Operation)) {
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, method := range openapi.Methods {
+			if op := item.Operation(method); op != nil {
+				fn("#/paths/"+openapi.EscapePointer(path)+"/"+method, path, item, op)
+			}
+		}
+	}
+}
+
+func sortedNames(r openapi.SecurityRequirement) []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+paths:
+  /pets/{petId}:
+    get:
+      operationId: showPet
+      security:
+      - api_key: []
+      parameters:
+      - name: id
+        in: path
+        required: true
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+    delete:
+      operationId: showPet
+  /pets:
+    get:
+      parameters:
+      - $ref: '#/components/parameters/Limit'
+      responses:
+        "200":
+          description: ok
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+`
+
+func TestCheck(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var got []string
+	for _, p := range Check(&spec) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"#/info/version: the version is missing (info)",
+		"#/paths/~1pets~1{petId}/delete/operationId: operationId showPet is already used by #/paths/~1pets~1{petId}/get (operation-ids)",
+		"#/paths/~1pets~1{petId}/delete/parameters: path parameter petId is not declared (path-params)",
+		"#/paths/~1pets~1{petId}/delete/responses: the operation has no responses (responses)",
+		"#/paths/~1pets~1{petId}/get/parameters: path parameter id is not in the path (path-params)",
+		"#/paths/~1pets~1{petId}/get/parameters: path parameter petId is not declared (path-params)",
+		"#/paths/~1pets~1{petId}/get/responses/200/content/application~1json/schema: #/components/schemas/Pet does not exist (refs)",
+		"#/paths/~1pets~1{petId}/get/security/0: security scheme api_key is not defined (security)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Check() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,179 @@
+package mock
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// resource pairs a collection path such as `/users` with the item path
+// `/users/{id}` right below it.
+type resource struct {
+	collection string
+	item       string
+	// idParam is the name of the templated segment of the item path.
+	idParam string
+	// idProperty is the property of the stored objects holding their id.
+	idProperty string
+	idSchema   *openapi.SchemaOrRef
+	schema     *openapi.SchemaOrRef
+}
+
+// newResources infers the resources from the path tree, indexed by the
+// templates of their collection and item paths.
+func newResources(spec *openapi.OpenAPI) (collections, items map[string]*resource) {
+	byPath := map[string]string{}
+	for template := range spec.Paths {
+		byPath[strings.Join(splitPath(template), "/")] = template
+	}
+
+	collections, items = map[string]*resource{}, map[string]*resource{}
+	for template, item := range spec.Paths {
+		segments := splitPath(template)
+		n := len(segments)
+		if n == 0 || !isParam(segments[n-1]) {
+			continue
+		}
+		collection, ok := byPath[strings.Join(segments[:n-1], "/")]
+		if !ok {
+			continue
+		}
+		last := segments[n-1]
+		res := &resource{
+			collection: collection,
+			item:       template,
+			idParam:    last[1 : len(last)-1],
+			schema:     resourceSchema(spec, spec.Paths[collection], item),
+		}
+		res.idProperty, res.idSchema = res.idParam, idParamSchema(spec, item, res.idParam)
+		if schema := spec.ResolveSchema(res.schema); schema != nil {
+			for _, name := range []string{res.idParam, "id"} {
+				if prop, ok := schema.Properties[name]; ok {
+					res.idProperty, res.idSchema = name, spec.ResolveSchema(prop)
+					break
+				}
+			}
+		}
+		collections[collection], items[template] = res, res
+	}
+	return collections, items
+}
+
+// resourceSchema finds the schema of the items from the successful response
+// of the item, the bodies sent to create or replace one, or the items of the
+// listed collection.
+func resourceSchema(spec *openapi.OpenAPI, collection, item *openapi.PathItem) *openapi.SchemaOrRef {
+	if op := item.Get; op != nil {
+		if schema := successSchema(spec, op); schema != nil {
+			return schema
+		}
+	}
+	for _, op := range []*openapi.Operation{item.Put, collection.Post} {
+		if op == nil {
+			continue
+		}
+		if body := spec.ResolveRequestBody(op.RequestBody); body != nil {
+			if schema := jsonSchema(body.Content); schema != nil {
+				return schema
+			}
+		}
+	}
+	if op := collection.Get; op != nil {
+		if schema := spec.ResolveSchema(successSchema(spec, op)); schema != nil && schema.Type == "array" {
+			return schema.Items
+		}
+	}
+	return nil
+}
+
+func successSchema(spec *openapi.OpenAPI, op *openapi.Operation) *openapi.SchemaOrRef {
+	if op.Responses == nil {
+		return nil
+	}
+	codes := make([]string, 0, len(*op.Responses))
+	for code := range *op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if res := spec.ResolveResponse((*op.Responses)[code]); res != nil {
+			if schema := jsonSchema(res.Content); schema != nil {
+				return schema
+			}
+		}
+	}
+	return nil
+}
+
+func jsonSchema(content map[string]*openapi.MediaType) *openapi.SchemaOrRef {
+	for mediaType, media := range content {
+		if isJSON(mediaType) && media != nil && media.Schema != nil {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// idParamSchema returns the schema of the path parameter name declared by
+// any operation of the item.
+func idParamSchema(spec *openapi.OpenAPI, item *openapi.PathItem, name string) *openapi.SchemaOrRef {
+	for _, method := range openapi.Methods {
+		op := item.Operation(method)
+		if op == nil {
+			continue
+		}
+		for _, param := range spec.OperationParameters(item, op) {
+			if param.In == "path" && param.Name == name {
+				return spec.ResolveSchema(param.Schema)
+			}
+		}
+	}
+	return nil
+}
+
+// expand fills the templated segments of a path with the path parameters.
+func expand(template string, params map[string]string) string {
+	segments := splitPath(template)
+	for i, segment := range segments {
+		if isParam(segment) {
+			segments[i] = url.PathEscape(params[segment[1:len(segment)-1]])
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// typedID converts an id taken from a path into the type of the id property.
+func (res *resource) typedID(id string) interface{} {
+	if res.idSchema == nil {
+		return id
+	}
+	switch res.idSchema.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(id, 64); err == nil {
+			return n
+		}
+	}
+	return id
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
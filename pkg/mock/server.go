@@ -0,0 +1,347 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/router"
+	"github.com/cry999/gopenapi/pkg/validate"
+)
+
+// Server answers requests with the responses described in a document.
+type Server struct {
+	spec      *openapi.OpenAPI
+	router    *router.Router
+	logger    *log.Logger
+	validator *validate.Validator
+	// validation tells whether invalid requests are rejected.
+	validation bool
+
+	stateful    bool
+	collections map[string]*resource
+	items       map[string]*resource
+	store       *store
+}
+
+// Option ...
+type Option func(*Server)
+
+// WithLogger logs every request to l.
+func WithLogger(l *log.Logger) Option {
+	return func(s *Server) { s.logger = l }
+}
+
+// WithValidation enables or disables the validation of requests, which is
+// enabled by default.
+func WithValidation(enabled bool) Option {
+	return func(s *Server) { s.validation = enabled }
+}
+
+// WithState enables or disables the stateful mode, in which the collections
+// and items inferred from paths such as `/users` and `/users/{id}` are kept
+// in memory, so that created items can be fetched, replaced, patched and
+// deleted afterwards.
+func WithState(enabled bool) Option {
+	return func(s *Server) { s.stateful = enabled }
+}
+
+// New ...
+func New(spec *openapi.OpenAPI, opts ...Option) *Server {
+	s := &Server{
+		spec:       spec,
+		router:     router.New(spec.Paths, spec.Servers),
+		validator:  validate.New(spec),
+		validation: true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.stateful {
+		s.collections, s.items = newResources(spec)
+		s.store = newStore()
+	}
+	return s
+}
+
+// ServeHTTP ...
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(rw, r)
+	if s.logger != nil {
+		s.logger.Printf("%s %s %d", r.Method, r.URL.RequestURI(), rw.status)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	m, err := s.router.Match(r)
+	switch err {
+	case router.ErrNotFound:
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("no path matches '%s'", r.URL.Path), nil)
+		return
+	case router.ErrMethodNotAllowed:
+		w.Header().Set("Allow", strings.Join(allowedMethods(m.PathItem), ", "))
+		writeProblem(w, http.StatusMethodNotAllowed, "Method Not Allowed",
+			fmt.Sprintf("%s is not defined for '%s'", r.Method, m.Template), nil)
+		return
+	}
+
+	input, err := s.validator.Request(r, m.PathItem, m.Operation, m.Params)
+	if err != nil && s.validation {
+		if verr, ok := err.(*validate.Error); ok {
+			writeProblem(w, verr.Status(), http.StatusText(verr.Status()),
+				"the request does not conform to the API", verr.Violations)
+			return
+		}
+		writeProblem(w, http.StatusBadRequest, "Bad Request", err.Error(), nil)
+		return
+	}
+
+	// a Prefer header asks for a documented response rather than the state
+	if s.stateful && len(r.Header.Values("Prefer")) == 0 && s.serveStateful(w, r, m, input) {
+		return
+	}
+	s.respond(w, r, m.Operation)
+}
+
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, op *openapi.Operation) {
+	prefer := parsePrefer(r.Header.Values("Prefer"))
+
+	code, res := s.selectResponse(op, prefer["code"])
+	if res == nil {
+		if code := prefer["code"]; code != "" {
+			writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("no response '%s' is defined", code), nil)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	status := http.StatusOK
+	if n, err := strconv.Atoi(code); err == nil {
+		status = n
+	} else if len(code) == 3 && strings.HasSuffix(code, "XX") {
+		status, _ = strconv.Atoi(code[:1] + "00")
+	}
+
+	for name, hor := range res.Headers {
+		header := s.spec.ResolveHeader(hor)
+		if header == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		value := header.Example
+		if value == nil {
+			value = s.spec.Sample(header.Schema)
+		}
+		if value != nil {
+			w.Header().Set(name, fmt.Sprint(value))
+		}
+	}
+
+	mediaType := negotiate(res.Content, r.Header.Get("Accept"))
+	if mediaType == "" {
+		w.WriteHeader(status)
+		return
+	}
+
+	body, ok := s.example(res.Content[mediaType], prefer["example"])
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("no example '%s' is defined", prefer["example"]), nil)
+		return
+	}
+	writeBody(w, status, mediaType, body)
+}
+
+// selectResponse picks the response for the preferred status code, or the
+// first successful one.
+func (s *Server) selectResponse(op *openapi.Operation, preferred string) (string, *openapi.ResponseOrRef) {
+	if op.Responses == nil {
+		return "", nil
+	}
+	responses := *op.Responses
+
+	if preferred != "" {
+		if res, ok := responses[preferred]; ok {
+			return preferred, s.spec.ResolveResponse(res)
+		}
+		if res, ok := responses[preferred[:1]+"XX"]; ok && len(preferred) == 3 {
+			return preferred, s.spec.ResolveResponse(res)
+		}
+		return preferred, nil
+	}
+
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return code, s.spec.ResolveResponse(responses[code])
+		}
+	}
+	if res, ok := responses["default"]; ok {
+		return "200", s.spec.ResolveResponse(res)
+	}
+	if len(codes) > 0 {
+		return codes[0], s.spec.ResolveResponse(responses[codes[0]])
+	}
+	return "", nil
+}
+
+// example returns the named example, the declared example or one
+// synthesized from the schema, in that order.
+func (s *Server) example(media *openapi.MediaType, name string) (openapi.Any, bool) {
+	if name != "" {
+		example := s.spec.ResolveExample(media.Examples[name])
+		if example == nil {
+			return nil, false
+		}
+		return openapi.NormalizeAny(example.Value), true
+	}
+
+	if media.Example != nil {
+		return openapi.NormalizeAny(media.Example), true
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example := s.spec.ResolveExample(media.Examples[name]); example != nil && example.Value != nil {
+			return openapi.NormalizeAny(example.Value), true
+		}
+	}
+	return s.spec.Sample(media.Schema), true
+}
+
+// negotiate picks the media type of the response content matching the
+// Accept header, preferring JSON.
+func negotiate(content map[string]*openapi.MediaType, accept string) string {
+	if len(content) == 0 {
+		return ""
+	}
+	available := make([]string, 0, len(content))
+	for mediaType := range content {
+		available = append(available, mediaType)
+	}
+	sort.Slice(available, func(i, j int) bool {
+		if isJSON(available[i]) != isJSON(available[j]) {
+			return isJSON(available[i])
+		}
+		return available[i] < available[j]
+	})
+
+	for _, accepted := range strings.Split(accept, ",") {
+		accepted, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		for _, mediaType := range available {
+			if mediaTypeMatches(accepted, mediaType) {
+				return mediaType
+			}
+		}
+	}
+	return available[0]
+}
+
+func mediaTypeMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))
+	}
+	if strings.HasSuffix(mediaType, "/*") {
+		return strings.HasPrefix(pattern, strings.TrimSuffix(mediaType, "*"))
+	}
+	return false
+}
+
+func isJSON(mediaType string) bool {
+	mediaType = strings.SplitN(mediaType, ";", 2)[0]
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func writeBody(w http.ResponseWriter, status int, mediaType string, body openapi.Any) {
+	if strings.Contains(mediaType, "*") {
+		mediaType = "application/json"
+	}
+	w.Header().Set("Content-Type", mediaType)
+
+	if s, ok := body.(string); ok && !isJSON(mediaType) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, s)
+		return
+	}
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(body)
+}
+
+// Problem is an RFC 7807 problem detail.
+type Problem struct {
+	Type   string      `json:"type,omitempty"`
+	Title  string      `json:"title"`
+	Status int         `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, title, detail string, errors interface{}) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(&Problem{
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Errors: errors,
+	})
+}
+
+// parsePrefer parses `Prefer` headers such as `code=404, example=empty`.
+func parsePrefer(values []string) map[string]string {
+	prefer := map[string]string{}
+	for _, value := range values {
+		for _, pref := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+			kv := strings.SplitN(strings.TrimSpace(pref), "=", 2)
+			if len(kv) != 2 {
+				prefer[strings.ToLower(kv[0])] = ""
+				continue
+			}
+			prefer[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prefer
+}
+
+func allowedMethods(item *openapi.PathItem) []string {
+	var methods []string
+	for _, method := range openapi.Methods {
+		if item.Operation(method) != nil {
+			methods = append(methods, strings.ToUpper(method))
+		}
+	}
+	return methods
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,237 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `
+servers:
+- url: http://localhost/api/v1
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+              examples:
+                alice:
+                  value:
+                    id: 1
+                    name: alice
+        "404":
+          description: not found
+          content:
+            application/json:
+              example:
+                message: not found
+  /users/me:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          example: bob
+`
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(testSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	return New(&spec)
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		prefer     string
+		wantStatus int
+		wantBody   map[string]interface{}
+	}{
+		{
+			name:       "literal path wins over template",
+			path:       "/api/v1/users/me",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": float64(0), "name": "bob"},
+		},
+		{
+			name:       "first named example",
+			path:       "/api/v1/users/42",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": float64(1), "name": "alice"},
+		},
+		{
+			name:       "preferred code",
+			path:       "/api/v1/users/42",
+			prefer:     "code=404",
+			wantStatus: http.StatusNotFound,
+			wantBody:   map[string]interface{}{"message": "not found"},
+		},
+		{
+			name:       "bare path without base path",
+			path:       "/users/42",
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]interface{}{"id": float64(1), "name": "alice"},
+		},
+		{
+			name:       "unknown path",
+			path:       "/api/v1/groups",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantBody) {
+				t.Errorf("body = %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+const statefulSpec = `
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: created
+  /pets/{petId}:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+    patch:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        "200":
+          description: ok
+    delete:
+      responses:
+        "204":
+          description: deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+        secret:
+          type: string
+          writeOnly: true
+`
+
+func TestServer_Stateful(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(statefulSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	s := New(&spec, WithState(true))
+
+	steps := []struct {
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{http.MethodGet, "/pets/1", "", http.StatusNotFound, ""},
+		{http.MethodPost, "/pets", `{"name": "tom", "secret": "x"}`, http.StatusCreated, `{"id": 1, "name": "tom"}`},
+		{http.MethodPost, "/pets", `{"name": "jerry"}`, http.StatusCreated, `{"id": 2, "name": "jerry"}`},
+		{http.MethodPatch, "/pets/1", `{"name": "garfield"}`, http.StatusOK, `{"id": 1, "name": "garfield"}`},
+		{http.MethodPatch, "/pets/1", `{"name": null}`, http.StatusUnprocessableEntity, ""},
+		{http.MethodDelete, "/pets/2", "", http.StatusNoContent, ""},
+		{http.MethodGet, "/pets", "", http.StatusOK, `[{"id": 1, "name": "garfield"}]`},
+		{http.MethodGet, "/pets/2", "", http.StatusNotFound, ""},
+	}
+	for _, step := range steps {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		if step.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s %s status = %d, want %d: %s", step.method, step.path, rec.Code, step.wantStatus, rec.Body)
+		}
+		if step.wantBody == "" {
+			continue
+		}
+		var got, want interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		json.Unmarshal([]byte(step.wantBody), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s %s body = %v, want %v", step.method, step.path, got, want)
+		}
+	}
+}
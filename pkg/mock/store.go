@@ -0,0 +1,276 @@
+package mock
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/router"
+	"github.com/cry999/gopenapi/pkg/validate"
+)
+
+// store keeps the items of every collection in memory, keyed by the
+// concrete path of the collection and the id of the item.
+type store struct {
+	mu          sync.Mutex
+	collections map[string]*collection
+}
+
+type collection struct {
+	// ids keeps the items in the order they were created.
+	ids   []string
+	items map[string]map[string]interface{}
+	next  int64
+}
+
+func newStore() *store {
+	return &store{collections: map[string]*collection{}}
+}
+
+func (s *store) collection(path string) *collection {
+	c, ok := s.collections[path]
+	if !ok {
+		c = &collection{items: map[string]map[string]interface{}{}}
+		s.collections[path] = c
+	}
+	return c
+}
+
+func (c *collection) put(id string, obj map[string]interface{}) (created bool) {
+	if _, ok := c.items[id]; !ok {
+		c.ids = append(c.ids, id)
+		created = true
+	}
+	c.items[id] = obj
+	return created
+}
+
+func (c *collection) delete(id string) {
+	delete(c.items, id)
+	for i := range c.ids {
+		if c.ids[i] == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// newID generates a sequential id for numeric ids, and a random UUID
+// otherwise.
+func (c *collection) newID(schema *openapi.SchemaOrRef) interface{} {
+	if schema != nil && (schema.Type == "integer" || schema.Type == "number") {
+		for {
+			c.next++
+			if _, ok := c.items[strconv.FormatInt(c.next, 10)]; !ok {
+				return c.next
+			}
+		}
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// serveStateful answers the request from the store when its route belongs
+// to a resource, and reports whether it did.
+func (s *Server) serveStateful(w http.ResponseWriter, r *http.Request, m *router.Match, input *validate.Input) bool {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	op, params := m.Operation, m.Params
+	if res, ok := s.collections[m.Template]; ok {
+		c := s.store.collection(expand(m.Template, params))
+		switch r.Method {
+		case http.MethodGet:
+			items := make([]interface{}, 0, len(c.ids))
+			for _, id := range c.ids {
+				items = append(items, s.visible(res, c.items[id]))
+			}
+			writeBody(w, http.StatusOK, "application/json", items)
+		case http.MethodPost:
+			s.create(w, r, op, res, c, input)
+		default:
+			return false
+		}
+		return true
+	}
+
+	res, ok := s.items[m.Template]
+	if !ok {
+		return false
+	}
+	c := s.store.collection(expand(res.collection, params))
+	id := params[res.idParam]
+	stored, exists := c.items[id]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			writeNotStored(w, r)
+			return true
+		}
+		writeBody(w, http.StatusOK, "application/json", s.visible(res, stored))
+
+	case http.MethodPut:
+		obj, ok := s.object(w, input)
+		if !ok {
+			return true
+		}
+		obj[res.idProperty] = res.typedID(id)
+		if !s.conforms(w, res, obj) {
+			return true
+		}
+		code := successStatus(op, http.StatusOK, http.StatusNoContent)
+		if c.put(id, obj) {
+			code = successStatus(op, http.StatusCreated, http.StatusOK)
+		}
+		s.writeStored(w, code, res, obj)
+
+	case http.MethodPatch:
+		if !exists {
+			writeNotStored(w, r)
+			return true
+		}
+		patch, ok := s.object(w, input)
+		if !ok {
+			return true
+		}
+		obj := mergePatch(stored, patch)
+		obj[res.idProperty] = stored[res.idProperty]
+		if !s.conforms(w, res, obj) {
+			return true
+		}
+		c.put(id, obj)
+		s.writeStored(w, successStatus(op, http.StatusOK, http.StatusNoContent), res, obj)
+
+	case http.MethodDelete:
+		if !exists {
+			writeNotStored(w, r)
+			return true
+		}
+		c.delete(id)
+		s.writeStored(w, successStatus(op, http.StatusNoContent, http.StatusOK), res, stored)
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, op *openapi.Operation, res *resource, c *collection, input *validate.Input) {
+	obj, ok := s.object(w, input)
+	if !ok {
+		return
+	}
+	idValue, ok := obj[res.idProperty]
+	if !ok {
+		idValue = c.newID(res.idSchema)
+		obj[res.idProperty] = idValue
+	}
+	id := fmt.Sprint(idValue)
+	if _, exists := c.items[id]; exists {
+		writeProblem(w, http.StatusConflict, "Conflict", fmt.Sprintf("'%s' already exists", id), nil)
+		return
+	}
+	if !s.conforms(w, res, obj) {
+		return
+	}
+	c.put(id, obj)
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+id)
+	s.writeStored(w, successStatus(op, http.StatusCreated, http.StatusOK), res, obj)
+}
+
+// object returns the decoded request body, which must be a JSON object.
+func (s *Server) object(w http.ResponseWriter, input *validate.Input) (map[string]interface{}, bool) {
+	obj, ok := input.Body.(map[string]interface{})
+	if !ok {
+		writeProblem(w, http.StatusBadRequest, "Bad Request", "the request body must be a JSON object", nil)
+		return nil, false
+	}
+	return obj, true
+}
+
+// conforms validates an item before storing it.
+func (s *Server) conforms(w http.ResponseWriter, res *resource, obj map[string]interface{}) bool {
+	if res.schema == nil {
+		return true
+	}
+	violations := s.validator.Value(res.schema, obj, validate.Stored)
+	if len(violations) == 0 {
+		return true
+	}
+	for _, v := range violations {
+		v.In = validate.InBody
+	}
+	writeProblem(w, http.StatusUnprocessableEntity, "Unprocessable Entity",
+		"the resource does not conform to its schema", violations)
+	return false
+}
+
+func (s *Server) writeStored(w http.ResponseWriter, code int, res *resource, obj map[string]interface{}) {
+	if code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+	writeBody(w, code, "application/json", s.visible(res, obj))
+}
+
+// visible hides the write only properties of a stored item.
+func (s *Server) visible(res *resource, obj map[string]interface{}) map[string]interface{} {
+	schema := s.spec.ResolveSchema(res.schema)
+	if schema == nil {
+		return obj
+	}
+	visible := make(map[string]interface{}, len(obj))
+	for name, value := range obj {
+		if prop := s.spec.ResolveSchema(schema.Properties[name]); prop != nil && prop.WriteOnly {
+			continue
+		}
+		visible[name] = value
+	}
+	return visible
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to a copy of obj.
+func mergePatch(obj, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(obj))
+	for name, value := range obj {
+		merged[name] = value
+	}
+	for name, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(merged, name)
+		case map[string]interface{}:
+			target, _ := merged[name].(map[string]interface{})
+			merged[name] = mergePatch(target, value)
+		default:
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// successStatus returns the first of the codes declared by the operation, or
+// the first code when none is.
+func successStatus(op *openapi.Operation, codes ...int) int {
+	if op.Responses != nil {
+		for _, code := range codes {
+			if _, ok := (*op.Responses)[strconv.Itoa(code)]; ok {
+				return code
+			}
+		}
+	}
+	return codes[0]
+}
+
+func writeNotStored(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, http.StatusNotFound, "Not Found", fmt.Sprintf("'%s' does not exist", r.URL.Path), nil)
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Cache keeps the files decoded while loading projects. A file is decoded
+// again only when its modification time or size changed, and its content
+// too: touching a file costs reading it, not decoding it. Loaded projects
+// get copies of the cached values, which they may modify.
+//
+// A cache may be shared by loads running at the same time, of projects of
+// the same file system.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// decoded counts the files decoded, for tests.
+	decoded int
+}
+
+type cacheEntry struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+	// value points to the decoded value.
+	value reflect.Value
+}
+
+// NewCache ...
+func NewCache() *Cache {
+	return &Cache{entries: map[string]*cacheEntry{}}
+}
+
+// decode decodes a file into v, a pointer, unless it is cached.
+func (c *Cache) decode(files fileSystem, filename string, v interface{}) error {
+	fi, err := files.Stat(filename)
+	if err != nil {
+		return err
+	}
+	target := reflect.ValueOf(v)
+
+	c.mu.Lock()
+	entry := c.entries[filename]
+	c.mu.Unlock()
+	if entry != nil && entry.value.Type() != target.Type() {
+		entry = nil
+	}
+	if entry != nil && entry.modTime.Equal(fi.ModTime()) && entry.size == fi.Size() {
+		target.Elem().Set(deepCopy(entry.value.Elem()))
+		return nil
+	}
+
+	b, err := files.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	if entry == nil || entry.sum != sum {
+		if err := unmarshalYAML(filename, b, v); err != nil {
+			return err
+		}
+		value := reflect.New(target.Type().Elem())
+		value.Elem().Set(deepCopy(target.Elem()))
+		entry = &cacheEntry{sum: sum, value: value}
+		c.mu.Lock()
+		c.decoded++
+		c.mu.Unlock()
+	} else {
+		target.Elem().Set(deepCopy(entry.value.Elem()))
+		entry = &cacheEntry{sum: sum, value: entry.value}
+	}
+	entry.modTime, entry.size = fi.ModTime(), fi.Size()
+
+	c.mu.Lock()
+	c.entries[filename] = entry
+	c.mu.Unlock()
+	return nil
+}
+
+// Clone returns a deep copy of the document, which may be modified without
+// changing o.
+func (o *OpenAPI) Clone() *OpenAPI {
+	return deepCopy(reflect.ValueOf(o)).Interface().(*OpenAPI)
+}
+
+// deepCopy copies the pointers, maps, slices and interfaces of a value
+// recursively. Unexported fields are copied as is.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
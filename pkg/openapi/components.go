@@ -1,27 +1,9 @@
 package openapi
 
 import (
-	"fmt"
-	"io"
-	"io/ioutil"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v2"
-)
-
-const (
-	dirComponents     = "components"
-	dirSchema         = "schemas"
-	dirResponse       = "responses"
-	dirParameter      = "parameters"
-	dirExample        = "examples"
-	dirRequestBody    = "requestBodies"
-	dirHeader         = "headers"
-	dirSecuritySchema = "securitySchemes"
-	dirLink           = "links"
-	dirCallback       = "callbacks"
 )
 
 // Components ...
@@ -75,183 +57,119 @@ type SecuritySchemeOrRef struct {
 func (sor *SecuritySchemeOrRef) IsRef() bool { return sor.Reference.Ref != "" }
 
 // LoadComponents ...
-func LoadComponents(root string) (_ *Components, err error) {
-	newRoot := filepath.Join(root, dirComponents)
-
-	schemas, err := loadSchemas(newRoot)
-	if err != nil {
-		return
-	}
+func LoadComponents(root string) (*Components, error) {
+	return newLoader(osFS{}).loadComponents(root)
+}
 
-	responses, err := loadResponses(newRoot)
-	if err != nil {
-		return
-	}
+func (l *loader) loadComponents(root string) (*Components, error) {
+	newRoot := l.files.Join(root, l.layout.Components)
 
-	requestBodies, err := loadRequestBodies(newRoot)
-	if err != nil {
-		return
+	components := &Components{
+		Schemas:         map[string]*SchemaOrRef{},
+		Responses:       map[string]*ResponseOrRef{},
+		Parameters:      map[string]*ParameterOrRef{},
+		Examples:        map[string]*ExampleOrRef{},
+		RequestBodies:   map[string]*RequestBodyOrRef{},
+		Headers:         map[string]*HeaderOrRef{},
+		SecuritySchemes: map[string]*SecuritySchemeOrRef{},
 	}
 
-	securitySchemas, err := loadSecuritySchemas(newRoot)
-	if err != nil {
-		return
+	var g group
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.Schemas), func() interface{} { return new(SchemaOrRef) }, func(name string, v interface{}) {
+			components.Schemas[name] = v.(*SchemaOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.Responses), func() interface{} { return new(ResponseOrRef) }, func(name string, v interface{}) {
+			components.Responses[name] = v.(*ResponseOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.Parameters), func() interface{} { return new(ParameterOrRef) }, func(name string, v interface{}) {
+			components.Parameters[name] = v.(*ParameterOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.Examples), func() interface{} { return new(ExampleOrRef) }, func(name string, v interface{}) {
+			components.Examples[name] = v.(*ExampleOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.RequestBodies), func() interface{} { return new(RequestBodyOrRef) }, func(name string, v interface{}) {
+			components.RequestBodies[name] = v.(*RequestBodyOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.Headers), func() interface{} { return new(HeaderOrRef) }, func(name string, v interface{}) {
+			components.Headers[name] = v.(*HeaderOrRef)
+		})
+	})
+	g.Go(func() error {
+		return l.walk(l.files.Join(newRoot, l.layout.SecuritySchemes), func() interface{} { return new(SecuritySchemeOrRef) }, func(name string, v interface{}) {
+			components.SecuritySchemes[name] = v.(*SecuritySchemeOrRef)
+		})
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
-
-	return &Components{
-		Schemas:         schemas,
-		Responses:       responses,
-		RequestBodies:   requestBodies,
-		SecuritySchemes: securitySchemas,
-	}, nil
+	return components, nil
 }
 
-func filenameWithoutExt(filename string) string {
-	ext := filepath.Ext(filename)
-	base := filepath.Base(filename)
-	return strings.TrimRight(base, ext)
+// DumpComponents ...
+func DumpComponents(root string, components *Components) error {
+	return newDumper(DirSink(root)).dumpComponents(components)
 }
 
-func loadSchemas(root string) (_ map[string]*SchemaOrRef, err error) {
-	schemas := map[string]*SchemaOrRef{}
-
-	dirname := filepath.Join(root, dirSchema)
-	if err := walk(dirname, func(f *os.File) (err error) {
-		schema, err := loadSchema(f)
-		if err != nil {
-			return fmt.Errorf("%s: %v", f.Name(), err)
-		}
-
-		schemaName := filenameWithoutExt(f.Name())
-		schemas[schemaName] = schema
-
+func (d *dumper) dumpComponents(components *Components) (err error) {
+	if components == nil {
 		return
-	}); err != nil {
-		return nil, err
 	}
-	return schemas, nil
-}
 
-func loadSchema(r io.Reader) (_ *SchemaOrRef, err error) {
-	var sor SchemaOrRef
-	if err = yaml.NewDecoder(r).Decode(&sor); err != nil {
-		return
+	for name, schema := range components.Schemas {
+		if err = d.dumpComponent(d.layout.Schemas, name, schema); err != nil {
+			return
+		}
 	}
-	return &sor, nil
-}
-
-func loadResponses(root string) (_ map[string]*ResponseOrRef, err error) {
-	responses := map[string]*ResponseOrRef{}
-
-	dirname := filepath.Join(root, dirResponse)
-	if err := walk(dirname, func(f *os.File) (err error) {
-		res, err := loadResponse(f)
-		if err != nil {
-			return fmt.Errorf("%s: %v", f.Name(), err)
+	for name, res := range components.Responses {
+		if err = d.dumpComponent(d.layout.Responses, name, res); err != nil {
+			return
 		}
-
-		responseName := filenameWithoutExt(f.Name())
-		responses[responseName] = res
-
-		return
-	}); err != nil {
-		return nil, err
 	}
-	return responses, nil
-}
-
-func loadResponse(r io.Reader) (_ *ResponseOrRef, err error) {
-	var ror ResponseOrRef
-	if err = yaml.NewDecoder(r).Decode(&ror); err != nil {
-		return
+	for name, param := range components.Parameters {
+		if err = d.dumpComponent(d.layout.Parameters, name, param); err != nil {
+			return
+		}
 	}
-	return &ror, nil
-}
-
-func loadRequestBodies(root string) (_ map[string]*RequestBodyOrRef, err error) {
-	bodies := map[string]*RequestBodyOrRef{}
-
-	dirname := filepath.Join(root, dirRequestBody)
-	if err := walk(dirname, func(f *os.File) error {
-		body, err := loadRequestBody(f)
-		if err != nil {
-			return fmt.Errorf("%s: %v", f.Name(), err)
+	for name, example := range components.Examples {
+		if err = d.dumpComponent(d.layout.Examples, name, example); err != nil {
+			return
 		}
-
-		bodyName := filenameWithoutExt(f.Name())
-		bodies[bodyName] = body
-
-		return nil
-	}); err != nil {
-		return nil, err
 	}
-	return bodies, nil
-}
-
-func loadRequestBody(r io.Reader) (*RequestBodyOrRef, error) {
-	var rbor RequestBodyOrRef
-	if err := yaml.NewDecoder(r).Decode(&rbor); err != nil {
-		return nil, err
+	for name, body := range components.RequestBodies {
+		if err = d.dumpComponent(d.layout.RequestBodies, name, body); err != nil {
+			return
+		}
 	}
-	return &rbor, nil
-}
-
-func loadSecuritySchemas(root string) (_ map[string]*SecuritySchemeOrRef, err error) {
-	ss := map[string]*SecuritySchemeOrRef{}
-
-	dirname := filepath.Join(root, dirSecuritySchema)
-	if err := walk(dirname, func(f *os.File) error {
-		ssor, err := loadSecuritySchema(f)
-		if err != nil {
-			return err
+	for name, header := range components.Headers {
+		if err = d.dumpComponent(d.layout.Headers, name, header); err != nil {
+			return
 		}
-
-		name := filenameWithoutExt(f.Name())
-		ss[name] = ssor
-
-		return nil
-	}); err != nil {
-		return nil, err
 	}
-
-	return ss, nil
-}
-
-func loadSecuritySchema(r io.Reader) (*SecuritySchemeOrRef, error) {
-	var ssor SecuritySchemeOrRef
-	if err := yaml.NewDecoder(r).Decode(&ssor); err != nil {
-		return nil, err
+	for name, ss := range components.SecuritySchemes {
+		if err = d.dumpComponent(d.layout.SecuritySchemes, name, ss); err != nil {
+			return
+		}
 	}
-	return &ssor, nil
+	return
 }
 
-func walk(dirname string, callback func(f *os.File) error) (err error) {
-	fileInfos, err := ioutil.ReadDir(dirname)
-	if err != nil {
-		return
-	}
-
-	for _, fileinfo := range fileInfos {
-		if fileinfo.IsDir() {
-			// TODO recursive directory is not supported
-			continue
-		}
-
-		filename := filepath.Join(dirname, fileinfo.Name())
-		ext := filepath.Ext(filename)
-		if ext != ".yml" && ext != ".yaml" && ext != ".json" {
-			continue
-		}
-
-		f, err := os.Open(filename)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
+func (d *dumper) dumpComponent(kind, name string, v interface{}) error {
+	return d.writeYAML(path.Join(d.layout.Components, kind, d.layout.file(name)), v)
+}
 
-		if err := callback(f); err != nil {
-			return err
-		}
-	}
-	return
+func filenameWithoutExt(filename string) string {
+	ext := filepath.Ext(filename)
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, ext)
 }
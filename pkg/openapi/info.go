@@ -1,11 +1,7 @@
 package openapi
 
 import (
-	"path/filepath"
-)
-
-const (
-	fileInfo = "info.yml"
+	"path"
 )
 
 // Info ...
@@ -32,19 +28,25 @@ type License struct {
 }
 
 // LoadInfo ...
-func LoadInfo(root string) (_ *Info, err error) {
-	filename := filepath.Join(root, fileInfo)
+func LoadInfo(root string) (*Info, error) {
+	return newLoader(osFS{}).loadInfo(root)
+}
+
+func (l *loader) loadInfo(root string) (_ *Info, err error) {
+	filename := l.files.Join(root, l.layout.file(l.layout.Info))
 
 	var info Info
-	if err = loadYAML(filename, &info); err != nil {
+	if err = l.decode(filename, &info); err != nil {
 		return
 	}
 	return &info, nil
 }
 
 // DumpInfo ...
-func DumpInfo(root string, info *Info) (err error) {
-	filename := filepath.Join(root, fileInfo)
+func DumpInfo(root string, info *Info) error {
+	return newDumper(DirSink(root)).dumpInfo(".", info)
+}
 
-	return dumpYAML(filename, info)
+func (d *dumper) dumpInfo(dir string, info *Info) error {
+	return d.writeYAML(path.Join(dir, d.layout.file(d.layout.Info)), info)
 }
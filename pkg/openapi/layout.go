@@ -0,0 +1,127 @@
+package openapi
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Layout names the directories and files of a project. Files other than
+// the OpenAPI version are named without their extension, which is the same
+// for all of them. Empty fields are those of DefaultLayout.
+type Layout struct {
+	Paths      string `yaml:"paths,omitempty"`
+	Components string `yaml:"components,omitempty"`
+	// The directories of the components, in Components.
+	Schemas         string `yaml:"schemas,omitempty"`
+	Responses       string `yaml:"responses,omitempty"`
+	Parameters      string `yaml:"parameters,omitempty"`
+	Examples        string `yaml:"examples,omitempty"`
+	RequestBodies   string `yaml:"requestBodies,omitempty"`
+	Headers         string `yaml:"headers,omitempty"`
+	SecuritySchemes string `yaml:"securitySchemes,omitempty"`
+
+	OpenAPIVersion string `yaml:"openapiVersion,omitempty"`
+	Info           string `yaml:"info,omitempty"`
+	Servers        string `yaml:"servers,omitempty"`
+	Security       string `yaml:"security,omitempty"`
+	Tags           string `yaml:"tags,omitempty"`
+	// PathIndex is the file of a path item in its directory, next to the
+	// files of its operations, named after their method.
+	PathIndex string `yaml:"pathIndex,omitempty"`
+	// Extension is the extension of the files, `.yml` or `.yaml`.
+	// Components are read whatever their extension.
+	Extension string `yaml:"extension,omitempty"`
+}
+
+// DefaultLayout returns the layout of projects created by `gopenapi init`.
+func DefaultLayout() Layout {
+	return Layout{
+		Paths:           "paths",
+		Components:      "components",
+		Schemas:         "schemas",
+		Responses:       "responses",
+		Parameters:      "parameters",
+		Examples:        "examples",
+		RequestBodies:   "requestBodies",
+		Headers:         "headers",
+		SecuritySchemes: "securitySchemes",
+		OpenAPIVersion:  "openapi_version",
+		Info:            "info",
+		Servers:         "servers",
+		Security:        "security",
+		Tags:            "tags",
+		PathIndex:       "index",
+		Extension:       ".yml",
+	}
+}
+
+// Complete returns the layout with its empty fields set from DefaultLayout.
+func (l Layout) Complete() Layout {
+	def := DefaultLayout()
+	for _, f := range []struct{ field, value *string }{
+		{&l.Paths, &def.Paths},
+		{&l.Components, &def.Components},
+		{&l.Schemas, &def.Schemas},
+		{&l.Responses, &def.Responses},
+		{&l.Parameters, &def.Parameters},
+		{&l.Examples, &def.Examples},
+		{&l.RequestBodies, &def.RequestBodies},
+		{&l.Headers, &def.Headers},
+		{&l.SecuritySchemes, &def.SecuritySchemes},
+		{&l.OpenAPIVersion, &def.OpenAPIVersion},
+		{&l.Info, &def.Info},
+		{&l.Servers, &def.Servers},
+		{&l.Security, &def.Security},
+		{&l.Tags, &def.Tags},
+		{&l.PathIndex, &def.PathIndex},
+		{&l.Extension, &def.Extension},
+	} {
+		if *f.field == "" {
+			*f.field = *f.value
+		}
+	}
+	return l
+}
+
+// Validate checks that the layout can be read back once written.
+func (l Layout) Validate() error {
+	l = l.Complete()
+	if l.Extension != ".yml" && l.Extension != ".yaml" {
+		return fmt.Errorf("unsupported extension '%s', want .yml or .yaml", l.Extension)
+	}
+	names := map[string]bool{}
+	for _, name := range []string{l.Paths, l.Components, l.OpenAPIVersion, l.Info + l.Extension, l.Servers + l.Extension, l.Security + l.Extension, l.Tags + l.Extension} {
+		if strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("'%s' is not a name", name)
+		}
+		if names[name] {
+			return fmt.Errorf("'%s' is used twice", name)
+		}
+		names[name] = true
+	}
+	for _, method := range Methods {
+		if l.PathIndex == method {
+			return fmt.Errorf("the path index '%s' is the file of an operation", l.PathIndex)
+		}
+	}
+	return nil
+}
+
+// file returns the name of a file of the layout with its extension.
+func (l Layout) file(name string) string {
+	return name + l.Extension
+}
+
+// PathItemFilename returns the index file of a path in the project at root.
+func (l Layout) PathItemFilename(root, path string) string {
+	l = l.Complete()
+	return filepath.Join(root, l.Paths, filepath.FromSlash(path), l.file(l.PathIndex))
+}
+
+// OperationFilename returns the file of the operation of a path and method
+// in the project at root.
+func (l Layout) OperationFilename(root, path, method string) string {
+	l = l.Complete()
+	return filepath.Join(root, l.Paths, filepath.FromSlash(path), l.file(strings.ToLower(method)))
+}
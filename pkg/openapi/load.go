@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadOption configures how a project is loaded.
+type LoadOption func(*loader)
+
+// WithConcurrency bounds the number of files read and decoded at once,
+// which defaults to the number of CPUs.
+func WithConcurrency(n int) LoadOption {
+	return func(l *loader) {
+		if n > 0 {
+			l.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithCache keeps the decoded files in c, so that loading the project again
+// only decodes the files which changed since.
+func WithCache(c *Cache) LoadOption {
+	return func(l *loader) { l.cache = c }
+}
+
+// WithLayout reads projects laid out as l instead of DefaultLayout.
+func WithLayout(l Layout) LoadOption {
+	return func(ld *loader) { ld.layout = l.Complete() }
+}
+
+// loader reads and decodes the files of a project.
+type loader struct {
+	files  fileSystem
+	layout Layout
+	// sem bounds the number of files read at once.
+	sem   chan struct{}
+	cache *Cache
+}
+
+func newLoader(files fileSystem, opts ...LoadOption) *loader {
+	l := &loader{files: files, layout: DefaultLayout(), sem: make(chan struct{}, runtime.NumCPU())}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// fileSystem is where the files of a project are read from.
+type fileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	// Join joins the elements of a name.
+	Join(elem ...string) string
+	// ToSlash converts a name to a slash separated path.
+	ToSlash(name string) string
+}
+
+// osFS reads files from the operating system, with names in its format.
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error)       { return ioutil.ReadFile(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) Join(elem ...string) string                 { return filepath.Join(elem...) }
+func (osFS) ToSlash(name string) string                 { return filepath.ToSlash(name) }
+
+// ioFS reads files from an fs.FS, with slash separated names.
+type ioFS struct{ fsys fs.FS }
+
+func (f ioFS) ReadFile(name string) ([]byte, error)       { return fs.ReadFile(f.fsys, name) }
+func (f ioFS) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(f.fsys, name) }
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(f.fsys, name) }
+func (ioFS) Join(elem ...string) string                   { return path.Join(elem...) }
+func (ioFS) ToSlash(name string) string                   { return name }
+
+// decode decodes a YAML file, or a JSON one, into v.
+func (l *loader) decode(filename string, v interface{}) error {
+	l.sem <- struct{}{}
+	defer func() { <-l.sem }()
+
+	if l.cache != nil {
+		return l.cache.decode(l.files, filename, v)
+	}
+	b, err := l.files.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return unmarshalYAML(filename, b, v)
+}
+
+// read reads a file.
+func (l *loader) read(filename string) ([]byte, error) {
+	l.sem <- struct{}{}
+	defer func() { <-l.sem }()
+
+	return l.files.ReadFile(filename)
+}
+
+func unmarshalYAML(filename string, b []byte, v interface{}) error {
+	// a decoder, unlike yaml.Unmarshal, fails on empty files
+	if err := yaml.NewDecoder(bytes.NewReader(b)).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return nil
+}
+
+// walk decodes the files of a directory concurrently, each into a value
+// returned by newValue, then calls add with the name of each file, without
+// its extension, and its value in the order of their names. Directories,
+// missing or not, and files other than YAML and JSON ones are skipped.
+func (l *loader) walk(dirname string, newValue func() interface{}, add func(name string, v interface{})) error {
+	entries, err := l.files.ReadDir(dirname)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			// optional component directories may be omitted
+			return nil
+		}
+		return err
+	}
+
+	var names, filenames []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			// TODO recursive directory is not supported
+			continue
+		}
+		filename := l.files.Join(dirname, entry.Name())
+		ext := path.Ext(filename)
+		if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+			continue
+		}
+		names = append(names, filenameWithoutExt(entry.Name()))
+		filenames = append(filenames, filename)
+	}
+
+	values := make([]interface{}, len(filenames))
+	var g group
+	for i, filename := range filenames {
+		i, filename := i, filename
+		g.Go(func() error {
+			v := newValue()
+			if err := l.decode(filename, v); err != nil {
+				return err
+			}
+			values[i] = v
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	for i, name := range names {
+		add(name, values[i])
+	}
+	return nil
+}
+
+// group runs functions concurrently. Its error is the one of the first
+// function started which failed, so that it does not depend on scheduling.
+type group struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	n    int
+	errs map[int]error
+}
+
+// Go runs fn in a goroutine.
+func (g *group) Go(fn func() error) {
+	g.mu.Lock()
+	i := g.n
+	g.n++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.mu.Lock()
+			if g.errs == nil {
+				g.errs = map[int]error{}
+			}
+			g.errs[i] = err
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for the functions to return.
+func (g *group) Wait() error {
+	g.wg.Wait()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	indexes := make([]int, 0, len(g.errs))
+	for i := range g.errs {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return g.errs[indexes[0]]
+}
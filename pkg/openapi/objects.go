@@ -1,6 +1,9 @@
 package openapi
 
-import "net/url"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // OpenAPI ...
 type OpenAPI struct {
@@ -16,8 +19,8 @@ type OpenAPI struct {
 
 // ExternalDocumentation ...
 type ExternalDocumentation struct {
-	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
-	URL         *url.URL `json:"url,omitempty" yaml:"url,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	URL         *URL   `json:"url,omitempty" yaml:"url,omitempty"`
 }
 
 // Parameter ...
@@ -30,7 +33,7 @@ type Parameter struct {
 	AllowEmptyValue bool   `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
 
 	Style         string                   `json:"style,omitempty" yaml:"style,omitempty"`
-	Explode       bool                     `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Explode       *bool                    `json:"explode,omitempty" yaml:"explode,omitempty"`
 	AllowReserved bool                     `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
 	Schema        *SchemaOrRef             `json:"schema,omitempty" yaml:"schema,omitempty"`
 	Example       Any                      `json:"example,omitempty" yaml:"example,omitempty"`
@@ -77,7 +80,7 @@ type Encoding struct {
 	ContentType   string                  `json:"contentType,omitempty" yaml:"contentType,omitempty"`
 	Headers       map[string]*HeaderOrRef `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Style         string                  `json:"style,omitempty" yaml:"style,omitempty"`
-	Explode       bool                    `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Explode       *bool                   `json:"explode,omitempty" yaml:"explode,omitempty"`
 	AllowReserved bool                    `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
 }
 
@@ -157,7 +160,7 @@ type Header struct {
 	AllowEmptyValue bool   `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
 
 	Style         string                   `json:"style,omitempty" yaml:"style,omitempty"`
-	Explode       bool                     `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Explode       *bool                    `json:"explode,omitempty" yaml:"explode,omitempty"`
 	AllowReserved bool                     `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
 	Schema        *SchemaOrRef             `json:"schema,omitempty" yaml:"schema,omitempty"`
 	Example       Any                      `json:"example,omitempty" yaml:"example,omitempty"`
@@ -182,24 +185,129 @@ type Reference struct {
 
 // Schema ...
 type Schema struct {
-	Type       string                  `json:"type,omitempty" yaml:"type,omitempty"`
-	Format     string                  `json:"format,omitempty" yaml:"format,omitempty"`
-	Example    string                  `json:"example,omitempty" yaml:"example,omitempty"`
-	Enum       []string                `json:"enum,omitempty" yaml:"enum,omitempty"`
-	Properties map[string]*SchemaOrRef `json:"properties,omitempty" yaml:"properties,omitempty"`
-	Items      *SchemaOrRef            `json:"items,omitempty" yaml:"items,omitempty"`
+	Title                string                  `json:"title,omitempty" yaml:"title,omitempty"`
+	Description          string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Type                 string                  `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                  `json:"format,omitempty" yaml:"format,omitempty"`
+	Nullable             bool                    `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Default              Any                     `json:"default,omitempty" yaml:"default,omitempty"`
+	Example              Any                     `json:"example,omitempty" yaml:"example,omitempty"`
+	Enum                 []Any                   `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Required             []string                `json:"required,omitempty" yaml:"required,omitempty"`
+	Properties           map[string]*SchemaOrRef `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *SchemaOrBool           `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Items                *SchemaOrRef            `json:"items,omitempty" yaml:"items,omitempty"`
+	AllOf                []*SchemaOrRef          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf                []*SchemaOrRef          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf                []*SchemaOrRef          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Not                  *SchemaOrRef            `json:"not,omitempty" yaml:"not,omitempty"`
+	Discriminator        *Discriminator          `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+	ReadOnly             bool                    `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly            bool                    `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Deprecated           bool                    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	ExternalDocs         *ExternalDocumentation  `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+
+	MultipleOf       *float64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MaxLength        *uint64  `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinLength        *uint64  `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	Pattern          string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MaxItems         *uint64  `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems         *uint64  `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	UniqueItems      bool     `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	MaxProperties    *uint64  `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	MinProperties    *uint64  `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+}
+
+// Discriminator ...
+type Discriminator struct {
+	PropertyName string            `json:"propertyName,omitempty" yaml:"propertyName,omitempty"`
+	Mapping      map[string]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
+}
+
+// SchemaOrBool is used for `additionalProperties`, which is either a boolean
+// or a schema.
+type SchemaOrBool struct {
+	Bool   bool
+	Schema *SchemaOrRef
+}
+
+// MarshalYAML ...
+func (sob *SchemaOrBool) MarshalYAML() (interface{}, error) {
+	if sob.Schema != nil {
+		return sob.Schema, nil
+	}
+	return sob.Bool, nil
+}
+
+// UnmarshalYAML ...
+func (sob *SchemaOrBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&sob.Bool); err == nil {
+		return nil
+	}
+	sob.Bool = true
+	return unmarshal(&sob.Schema)
+}
+
+// MarshalJSON ...
+func (sob *SchemaOrBool) MarshalJSON() ([]byte, error) {
+	if sob.Schema != nil {
+		return json.Marshal(sob.Schema)
+	}
+	return json.Marshal(sob.Bool)
+}
+
+// UnmarshalJSON ...
+func (sob *SchemaOrBool) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &sob.Bool); err == nil {
+		return nil
+	}
+	sob.Bool = true
+	return json.Unmarshal(b, &sob.Schema)
 }
 
 // SchemaOrRef ...
 type SchemaOrRef struct {
-	Schema    `yaml:",inline"`
-	Reference `yaml:",inline"`
+	Schema     `yaml:",inline"`
+	Reference  `yaml:",inline"`
+	Extensions `json:"-" yaml:",inline"`
 }
 
 // IsRef ...
 func (sor *SchemaOrRef) IsRef() bool { return sor.Reference.Ref != "" }
 
-// TODO extensions
+// UnmarshalYAML ...
+func (sor *SchemaOrRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type schemaOrRef SchemaOrRef
+	if err := unmarshal((*schemaOrRef)(sor)); err != nil {
+		return err
+	}
+	sor.Extensions = sor.Extensions.specification()
+	return nil
+}
+
+// Extensions holds the specification extensions of an object, the fields
+// starting with `x-`. YAML only inlines the extensions of the outermost
+// struct, which is where they are.
+type Extensions map[string]Any
+
+// specification drops the fields which are not specification extensions,
+// as inlining gathers all the fields out of the model.
+func (e Extensions) specification() Extensions {
+	var ext Extensions
+	for key, value := range e {
+		if strings.HasPrefix(key, "x-") {
+			if ext == nil {
+				ext = Extensions{}
+			}
+			ext[key] = value
+		}
+	}
+	return ext
+}
 
 // Any ...
 type Any interface{}
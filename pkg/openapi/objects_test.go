@@ -1,9 +1,15 @@
 package openapi
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -49,3 +55,262 @@ format: email
 		}
 	}
 }
+
+func TestEncoding_Explode(t *testing.T) {
+	for _, in := range []string{"style: form\n", "style: form\nexplode: false\n", "style: form\nexplode: true\n"} {
+		var encoding Encoding
+		if err := yaml.Unmarshal([]byte(in), &encoding); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		got, err := yaml.Marshal(&encoding)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(got) != in {
+			t.Errorf("Marshal() = %q, want %q", got, in)
+		}
+	}
+}
+
+func TestLoadInOneFile_Extensions(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "openapi.json")
+	if err := ioutil.WriteFile(filename, []byte(`{
+	"openapi": "3.0.3",
+	"paths": {
+		"/pets": {
+			"get": {
+				"x-internal": true,
+				"unknown": 1,
+				"responses": {}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Pet": {"type": "object", "x-audience": ["partners"], "const": 1}
+		}
+	}
+}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := LoadInOneFile(filename)
+	if err != nil {
+		t.Fatalf("LoadInOneFile() error = %v", err)
+	}
+	if got, want := spec.Paths["/pets"].Get.Extensions, (Extensions{"x-internal": true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions of the operation = %v, want %v", got, want)
+	}
+	if got, want := spec.Components.Schemas["Pet"].Extensions, (Extensions{"x-audience": []interface{}{"partners"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions of the schema = %v, want %v", got, want)
+	}
+}
+
+const refsSpec = `
+paths:
+  /pets/{id}:
+    parameters:
+    - $ref: '#/components/parameters/Id'
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Pet'
+              example:
+                $ref: not a reference
+components:
+  parameters:
+    Id:
+      name: id
+      in: path
+  schemas:
+    Pet:
+      additionalProperties:
+        $ref: '#/components/schemas/Tag'
+`
+
+func TestWalkRefs(t *testing.T) {
+	var spec OpenAPI
+	if err := yaml.Unmarshal([]byte(refsSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var got []string
+	WalkRefs(&spec, func(pointer, ref string) {
+		got = append(got, pointer+" "+ref)
+	})
+	want := []string{
+		"/paths/~1pets~1{id}/get/responses/200/content/application~1json/schema/items #/components/schemas/Pet",
+		"/paths/~1pets~1{id}/parameters/0 #/components/parameters/Id",
+		"/components/schemas/Pet/additionalProperties #/components/schemas/Tag",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkRefs() = %v, want %v", got, want)
+	}
+
+	for ref, want := range map[string]bool{
+		"#/components/schemas/Pet":                      true,
+		"#/components/parameters/Id":                    true,
+		"#/components/schemas/Tag":                      false,
+		"#/paths/~1pets~1{id}/get/responses/200":        true,
+		"#/paths/~1pets~1{id}/parameters/1":             false,
+		"#/components/schemas/Pet/additionalProperties": true,
+	} {
+		if got := spec.Lookup(ref) != nil; got != want {
+			t.Errorf("Lookup(%s) found = %v, want %v", ref, got, want)
+		}
+	}
+}
+
+func writeProject(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadProject_Cache(t *testing.T) {
+	dir := t.TempDir()
+	writeProject(t, dir, map[string]string{
+		"openapi_version":           "3.0.3\n",
+		"info.yml":                  "title: Pets\nversion: 1.0.0\n",
+		"servers.yml":               "[]\n",
+		"security.yml":              "[]\n",
+		"tags.yml":                  "[]\n",
+		"paths/pets/index.yml":      "{}\n",
+		"paths/pets/get.yml":        "operationId: listPets\n",
+		"components/schemas/A.yml":  "type: string\n",
+		"components/schemas/B.yml":  "type: integer\n",
+		"components/headers/X.yaml": "description: x\n",
+	})
+
+	cache := NewCache()
+	spec, err := LoadProject(dir, WithCache(cache), WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if cache.decoded != 9 {
+		t.Errorf("decoded = %d, want 9", cache.decoded)
+	}
+	// loaded projects do not share the cached values
+	spec.Paths["/pets"].Get.OperationID = "modified"
+	spec.Components.Schemas["A"].Type = "modified"
+
+	// touched files are not decoded again
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "info.yml"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeProject(t, dir, map[string]string{"components/schemas/B.yml": "type: number\n"})
+
+	spec, err = LoadProject(dir, WithCache(cache))
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if cache.decoded != 10 {
+		t.Errorf("decoded = %d, want 10", cache.decoded)
+	}
+	if got := spec.Paths["/pets"].Get.OperationID; got != "listPets" {
+		t.Errorf("operationId = %s, want listPets", got)
+	}
+	if got := spec.Components.Schemas["A"].Type + "," + spec.Components.Schemas["B"].Type; got != "string,number" {
+		t.Errorf("types = %s, want string,number", got)
+	}
+	if spec.Info.Title != "Pets" || spec.Components.Headers["X"] == nil {
+		t.Errorf("LoadProject() = %+v, want the info and the header", spec)
+	}
+
+	// the error is the one of the first broken file
+	writeProject(t, dir, map[string]string{
+		"components/schemas/A.yml": "type: [\n",
+		"components/schemas/B.yml": "type: [\n",
+	})
+	for i := 0; i < 5; i++ {
+		_, err := LoadProject(dir, WithCache(cache))
+		if err == nil || !strings.HasPrefix(err.Error(), filepath.Join(dir, "components/schemas/A.yml")+":") {
+			t.Fatalf("LoadProject() error = %v, want an error about A.yml", err)
+		}
+	}
+}
+
+func TestLoadProjectFS(t *testing.T) {
+	var spec OpenAPI
+	if err := yaml.Unmarshal([]byte(refsSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	spec.Version = "3.0.3"
+	spec.Info = &Info{Title: "Pets", Version: "1.0.0"}
+	spec.Paths["/pets/{id}"].Servers = []*Server{{Description: "pets"}}
+
+	sink := MapSink{}
+	if err := DumpProjectTo(sink, &spec); err != nil {
+		t.Fatalf("DumpProjectTo() error = %v", err)
+	}
+	for _, name := range []string{"openapi_version", "info.yml", "paths/pets/{id}/index.yml", "paths/pets/{id}/get.yml", "paths/pets/{id}/servers.yml", "components/schemas/Pet.yml"} {
+		if _, ok := sink[name]; !ok {
+			t.Errorf("DumpProjectTo() does not write %s", name)
+		}
+	}
+
+	fsys := fstest.MapFS{}
+	for name, data := range sink {
+		fsys["spec/"+name] = &fstest.MapFile{Data: data}
+	}
+	got, err := LoadProjectFS(fsys, "spec")
+	if err != nil {
+		t.Fatalf("LoadProjectFS() error = %v", err)
+	}
+	want, _ := yaml.Marshal(&spec)
+	if b, _ := yaml.Marshal(got); string(b) != string(want) {
+		t.Errorf("LoadProjectFS() =\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	var spec OpenAPI
+	if err := yaml.Unmarshal([]byte(refsSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	spec.Version = "3.0.3"
+	spec.Info = &Info{Title: "Pets", Version: "1.0.0"}
+
+	layout := Layout{Paths: "routes", Components: "shared", Info: "about", PathIndex: "_item", Extension: ".yaml"}
+	sink := MapSink{}
+	if err := DumpProjectTo(sink, &spec, WithDumpLayout(layout)); err != nil {
+		t.Fatalf("DumpProjectTo() error = %v", err)
+	}
+	for _, name := range []string{"openapi_version", "about.yaml", "routes/pets/{id}/_item.yaml", "routes/pets/{id}/get.yaml", "shared/schemas/Pet.yaml"} {
+		if _, ok := sink[name]; !ok {
+			t.Errorf("DumpProjectTo() does not write %s", name)
+		}
+	}
+	if got, want := layout.OperationFilename("api", "/pets/{id}", "GET"), filepath.Join("api", "routes", "pets", "{id}", "get.yaml"); got != want {
+		t.Errorf("OperationFilename() = %s, want %s", got, want)
+	}
+
+	fsys := fstest.MapFS{}
+	for name, data := range sink {
+		fsys[name] = &fstest.MapFile{Data: data}
+	}
+	got, err := LoadProjectFS(fsys, ".", WithLayout(layout))
+	if err != nil {
+		t.Fatalf("LoadProjectFS() error = %v", err)
+	}
+	want, _ := yaml.Marshal(&spec)
+	if b, _ := yaml.Marshal(got); string(b) != string(want) {
+		t.Errorf("LoadProjectFS() =\n%s\nwant\n%s", b, want)
+	}
+}
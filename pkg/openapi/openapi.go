@@ -1,50 +1,131 @@
 package openapi
 
-// LoadProject ...
-func LoadProject(projectDir string) (*OpenAPI, error) {
-	version, err := LoadOpenAPIVersion(projectDir)
-	if err != nil {
-		return nil, err
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadProject loads the project at projectDir. Its files are read and
+// decoded concurrently.
+func LoadProject(projectDir string, opts ...LoadOption) (*OpenAPI, error) {
+	return newLoader(osFS{}, opts...).loadProject(projectDir)
+}
+
+// LoadProjectFS loads the project at root in fsys, such as an embed.FS.
+func LoadProjectFS(fsys fs.FS, root string, opts ...LoadOption) (*OpenAPI, error) {
+	return newLoader(ioFS{fsys}, opts...).loadProject(root)
+}
+
+func (l *loader) loadProject(projectDir string) (*OpenAPI, error) {
+	openapi := &OpenAPI{
+		// ExternalDocs: []*ExternalDocumentation{},
 	}
-	info, err := LoadInfo(projectDir)
-	if err != nil {
+
+	var g group
+	g.Go(func() (err error) {
+		openapi.Version, err = l.loadOpenAPIVersion(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Info, err = l.loadInfo(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Servers, err = l.loadServers(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Paths, err = l.loadPaths(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Components, err = l.loadComponents(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Security, err = l.loadSecurity(projectDir)
+		return
+	})
+	g.Go(func() (err error) {
+		openapi.Tags, err = l.loadTags(projectDir)
+		return
+	})
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	servers, err := LoadServers(projectDir)
-	if err != nil {
-		return nil, err
+	return openapi, nil
+}
+
+// DumpProject ...
+func DumpProject(projectDir string, openapi *OpenAPI, opts ...DumpOption) error {
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		return err
 	}
-	paths, err := LoadPaths(projectDir)
-	if err != nil {
-		return nil, err
+	return DumpProjectTo(DirSink(projectDir), openapi, opts...)
+}
+
+// DumpProjectTo writes the files of a project to sink.
+func DumpProjectTo(sink Sink, openapi *OpenAPI, opts ...DumpOption) error {
+	d := newDumper(sink, opts...)
+	if err := d.dumpOpenAPIVersion(openapi.Version); err != nil {
+		return err
 	}
-	components, err := LoadComponents(projectDir)
-	if err != nil {
-		return nil, err
+	if err := d.dumpInfo(".", openapi.Info); err != nil {
+		return err
 	}
-	security, err := LoadSecurity(projectDir)
-	if err != nil {
-		return nil, err
+	if err := d.dumpServers(".", openapi.Servers); err != nil {
+		return err
 	}
-	tags, err := LoadTags(projectDir)
-	if err != nil {
-		return nil, err
+	if err := d.dumpPaths(openapi.Paths); err != nil {
+		return err
 	}
-
-	openapi := &OpenAPI{
-		Version:    version,
-		Info:       info,
-		Servers:    servers,
-		Paths:      paths,
-		Components: components,
-		Security:   security,
-		Tags:       tags,
-		// ExternalDocs: []*ExternalDocumentation{},
+	if err := d.dumpComponents(openapi.Components); err != nil {
+		return err
 	}
-	return openapi, nil
+	if err := d.dumpSecurity(".", openapi.Security); err != nil {
+		return err
+	}
+	return d.dumpTags(".", openapi.Tags)
 }
 
 // DumpInOneFile ...
 func DumpInOneFile(output string, openapi *OpenAPI) error {
 	return dumpYAML(output, openapi)
 }
+
+// Parse parses a bundled document written in YAML, or in JSON which YAML
+// includes.
+func Parse(data []byte) (*OpenAPI, error) {
+	var openapi OpenAPI
+	if err := yaml.Unmarshal(data, &openapi); err != nil {
+		return nil, err
+	}
+	return &openapi, nil
+}
+
+// EncodeJSON encodes the document as indented JSON. It goes through YAML, for
+// the JSON to be the same document as the bundled one.
+func EncodeJSON(openapi *OpenAPI) ([]byte, error) {
+	b, err := yaml.Marshal(openapi)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(NormalizeAny(v), "", "  ")
+}
+
+// LoadInOneFile loads a bundled document. JSON documents are decoded as
+// YAML, which they are, so that they are read as bundled YAML is.
+func LoadInOneFile(filename string) (*OpenAPI, error) {
+	var openapi OpenAPI
+	if err := loadYAML(filename, &openapi); err != nil {
+		return nil, err
+	}
+	return &openapi, nil
+}
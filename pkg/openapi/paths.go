@@ -1,18 +1,12 @@
 package openapi
 
 import (
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"errors"
+	"io/fs"
+	"path"
 	"strings"
 )
 
-const (
-	dirPaths = "paths"
-
-	filePathIndex = "index.yml"
-)
-
 // Paths ...
 type Paths map[string]*PathItem
 
@@ -45,89 +39,232 @@ type Operation struct {
 	Responses    *Responses                `json:"responses,omitempty" yaml:"responses,omitempty"`
 	Callbacks    map[string]*CallbackOrRef `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
 	Deprecated   bool                      `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
-	Security     []*SecurityRequirement    `json:"security,omitempty" yaml:"security,omitempty"`
+	Security     SecurityRequirements      `json:"security,omitempty" yaml:"security,omitempty"`
 	Servers      []*Server                 `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Extensions   `json:"-" yaml:",inline"`
+}
+
+// UnmarshalYAML ...
+func (o *Operation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type operation Operation
+	if err := unmarshal((*operation)(o)); err != nil {
+		return err
+	}
+	o.Extensions = o.Extensions.specification()
+	return nil
 }
 
 // LoadPaths ...
 func LoadPaths(root string) (Paths, error) {
-	paths := Paths{}
-	pathsRoot := filepath.Join(root, dirPaths)
+	return newLoader(osFS{}).loadPaths(root)
+}
 
-	if err := loadPathItem(pathsRoot, pathsRoot, paths); err != nil {
+func (l *loader) loadPaths(root string) (Paths, error) {
+	pathsRoot := l.files.Join(root, l.layout.Paths)
+	if _, err := l.files.Stat(pathsRoot); errors.Is(err, fs.ErrNotExist) {
+		// a project without paths does not need the directory
+		return Paths{}, nil
+	}
+
+	// directories holding an index file are path items
+	var dirs []string
+	if err := l.findPathItems(pathsRoot, &dirs); err != nil {
 		return nil, err
 	}
 
+	items := make([]*PathItem, len(dirs))
+	var g group
+	for i, dir := range dirs {
+		i, dir := i, dir
+		g.Go(func() (err error) {
+			items[i], err = l.loadPathItem(dir)
+			return
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	paths := Paths{}
+	for i, dir := range dirs {
+		path := l.files.ToSlash(strings.TrimPrefix(dir, pathsRoot))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		paths[path] = items[i]
+	}
 	return paths, nil
 }
 
-func loadPathItem(cwd, root string, paths Paths) error {
-	index := filepath.Join(cwd, filePathIndex)
-	if _, err := os.Stat(index); err == nil || os.IsExist(err) {
-		var pathitem PathItem
-		if err := loadYAML(index, &pathitem); err != nil {
-			return err
+func (l *loader) findPathItems(cwd string, dirs *[]string) error {
+	index := l.files.Join(cwd, l.layout.file(l.layout.PathIndex))
+	if _, err := l.files.Stat(index); err == nil {
+		*dirs = append(*dirs, cwd)
+	}
+
+	entries, err := l.files.ReadDir(cwd)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
 		}
 
-		if err := loadPathItemOperations(cwd, &pathitem); err != nil {
+		nwd := l.files.Join(cwd, entry.Name())
+		if err := l.findPathItems(nwd, dirs); err != nil {
 			return err
 		}
+	}
 
-		servers, err := LoadServers(cwd)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				return err
-			}
-		} else {
-			pathitem.Servers = servers
-		}
+	return nil
+}
 
-		path := strings.TrimPrefix(cwd, root)
-		if !strings.HasPrefix(path, "/") {
-			path = "/" + path
-		}
+func (l *loader) loadPathItem(cwd string) (*PathItem, error) {
+	var pathitem PathItem
+	if err := l.decode(l.files.Join(cwd, l.layout.file(l.layout.PathIndex)), &pathitem); err != nil {
+		return nil, err
+	}
 
-		paths[path] = &pathitem
+	if err := l.loadPathItemOperations(cwd, &pathitem); err != nil {
+		return nil, err
 	}
 
-	files, err := ioutil.ReadDir(cwd)
+	servers, err := l.loadServers(cwd)
 	if err != nil {
-		return err
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	} else {
+		pathitem.Servers = servers
 	}
+	return &pathitem, nil
+}
+
+// pathItemOperations maps the methods of a path item to its operations.
+func pathItemOperations(item *PathItem) map[string]**Operation {
+	return map[string]**Operation{
+		"get":     &item.Get,
+		"put":     &item.Put,
+		"post":    &item.Post,
+		"delete":  &item.Delete,
+		"options": &item.Options,
+		"head":    &item.Head,
+		"patch":   &item.Patch,
+		"trace":   &item.Trace,
+	}
+}
 
-	for _, fileinfo := range files {
-		if !fileinfo.IsDir() {
+func (l *loader) loadPathItemOperations(cwd string, item *PathItem) error {
+	for method, op := range pathItemOperations(item) {
+		filename := l.files.Join(cwd, l.layout.file(method))
+		if _, err := l.files.Stat(filename); err != nil {
 			continue
 		}
 
-		nwd := filepath.Join(cwd, fileinfo.Name())
-		if err := loadPathItem(nwd, root, paths); err != nil {
+		if err := l.decode(filename, op); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
 
-func loadPathItemOperations(cwd string, item *PathItem) error {
-	for filename, op := range map[string]**Operation{
-		"get.yml":     &item.Get,
-		"put.yml":     &item.Put,
-		"post.yml":    &item.Post,
-		"delete.yml":  &item.Delete,
-		"options.yml": &item.Options,
-		"head.yml":    &item.Head,
-		"patch.yml":   &item.Patch,
-		"trace.yml":   &item.Trace,
-	} {
-		filename = filepath.Join(cwd, filename)
-		if _, err := os.Stat(filename); err != nil && !os.IsExist(err) {
-			continue
-		}
+// DumpPaths ...
+func DumpPaths(root string, paths Paths) error {
+	return newDumper(DirSink(root)).dumpPaths(paths)
+}
+
+func (d *dumper) dumpPaths(paths Paths) error {
+	for p, item := range paths {
+		dir := path.Join(d.layout.Paths, p)
 
-		if err := loadYAML(filename, op); err != nil {
+		// operations and servers live in their own files
+		index := PathItem{
+			Ref:         item.Ref,
+			Summary:     item.Summary,
+			Description: item.Description,
+			Parameters:  item.Parameters,
+		}
+		if err := d.writeYAML(path.Join(dir, d.layout.file(d.layout.PathIndex)), &index); err != nil {
 			return err
 		}
+
+		for method, op := range pathItemOperations(item) {
+			if *op == nil {
+				continue
+			}
+			if err := d.writeYAML(path.Join(dir, d.layout.file(method)), *op); err != nil {
+				return err
+			}
+		}
+
+		if len(item.Servers) > 0 {
+			if err := d.dumpServers(dir, item.Servers); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PathItemFilename returns the index file of a path in the project at root,
+// laid out as DefaultLayout.
+func PathItemFilename(root, path string) string {
+	return DefaultLayout().PathItemFilename(root, path)
+}
+
+// OperationFilename returns the file of the operation of a path and method
+// in the project at root, laid out as DefaultLayout.
+func OperationFilename(root, path, method string) string {
+	return DefaultLayout().OperationFilename(root, path, method)
+}
+
+// Methods lists the HTTP methods a path item can hold operations for, in
+// the order they appear in the specification.
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Operation returns the operation for the given HTTP method, or nil.
+func (item *PathItem) Operation(method string) *Operation {
+	switch strings.ToLower(method) {
+	case "get":
+		return item.Get
+	case "put":
+		return item.Put
+	case "post":
+		return item.Post
+	case "delete":
+		return item.Delete
+	case "options":
+		return item.Options
+	case "head":
+		return item.Head
+	case "patch":
+		return item.Patch
+	case "trace":
+		return item.Trace
 	}
 	return nil
 }
+
+// SetOperation ...
+func (item *PathItem) SetOperation(method string, op *Operation) {
+	switch strings.ToLower(method) {
+	case "get":
+		item.Get = op
+	case "put":
+		item.Put = op
+	case "post":
+		item.Post = op
+	case "delete":
+		item.Delete = op
+	case "options":
+		item.Options = op
+	case "head":
+		item.Head = op
+	case "patch":
+		item.Patch = op
+	case "trace":
+		item.Trace = op
+	}
+}
@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WalkRefs calls fn for every `$ref` found in v, a document or a part of
+// it, with the JSON pointer of the reference relative to v. Maps are walked
+// in the order of their keys, and examples are not looked into.
+func WalkRefs(v interface{}, fn func(pointer, ref string)) {
+	walkRefs(reflect.ValueOf(v), "", fn)
+}
+
+func walkRefs(v reflect.Value, pointer string, fn func(pointer, ref string)) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkRefs(v.Elem(), pointer, fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkRefs(v.Index(i), pointer+"/"+strconv.Itoa(i), fn)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			walkRefs(v.MapIndex(key), pointer+"/"+EscapePointer(key.String()), fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, inline := fieldName(t.Field(i))
+			field := v.Field(i)
+			switch {
+			case name == "-":
+			case name == "$ref":
+				if ref := field.String(); ref != "" {
+					fn(pointer, ref)
+				}
+			case inline:
+				walkRefs(field, pointer, fn)
+			default:
+				walkRefs(field, pointer+"/"+EscapePointer(name), fn)
+			}
+		}
+	}
+}
+
+// Lookup returns the part of the document a local reference such as
+// `#/components/schemas/Pet` points to, or nil if there is none.
+func (o *OpenAPI) Lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	v := reflect.ValueOf(o)
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		if v = lookup(v, UnescapePointer(token)); !v.IsValid() {
+			return nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+func lookup(v reflect.Value, token string) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= v.Len() {
+			return reflect.Value{}
+		}
+		return v.Index(i)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}
+		}
+		return v.MapIndex(reflect.ValueOf(token).Convert(v.Type().Key()))
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, inline := fieldName(t.Field(i))
+			switch {
+			case name == token && !inline:
+				return v.Field(i)
+			case inline:
+				if found := lookup(v.Field(i), token); found.IsValid() {
+					return found
+				}
+			}
+		}
+	}
+	return reflect.Value{}
+}
+
+// fieldName returns the name of a field in YAML documents, and whether its
+// fields are inlined. Fields without tags, such as those of SchemaOrBool,
+// are inlined too.
+func fieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "-", false
+	}
+	tag, ok := f.Tag.Lookup("yaml")
+	if !ok {
+		return "", true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return "", true
+		}
+	}
+	if parts[0] == "" {
+		return strings.ToLower(f.Name), false
+	}
+	return parts[0], false
+}
+
+// EscapePointer escapes a token of a JSON pointer.
+func EscapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// UnescapePointer unescapes a token of a JSON pointer.
+func UnescapePointer(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
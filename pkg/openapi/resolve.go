@@ -0,0 +1,113 @@
+package openapi
+
+import "strings"
+
+const (
+	refSchemas       = "#/components/schemas/"
+	refResponses     = "#/components/responses/"
+	refParameters    = "#/components/parameters/"
+	refExamples      = "#/components/examples/"
+	refRequestBodies = "#/components/requestBodies/"
+	refHeaders       = "#/components/headers/"
+)
+
+// maxRefDepth guards against reference cycles such as `A -> B -> A`.
+const maxRefDepth = 32
+
+// RefName returns the component name of a local reference such as
+// `#/components/schemas/Pet`.
+func RefName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// ResolveSchema follows local references until it reaches a schema. It
+// returns nil when a reference cannot be resolved.
+func (o *OpenAPI) ResolveSchema(sor *SchemaOrRef) *SchemaOrRef {
+	for depth := 0; sor != nil && sor.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(sor.Ref, refSchemas) {
+			return nil
+		}
+		sor = o.Components.Schemas[strings.TrimPrefix(sor.Ref, refSchemas)]
+	}
+	return sor
+}
+
+// ResolveParameter ...
+func (o *OpenAPI) ResolveParameter(por *ParameterOrRef) *ParameterOrRef {
+	for depth := 0; por != nil && por.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(por.Ref, refParameters) {
+			return nil
+		}
+		por = o.Components.Parameters[strings.TrimPrefix(por.Ref, refParameters)]
+	}
+	return por
+}
+
+// ResolveRequestBody ...
+func (o *OpenAPI) ResolveRequestBody(rbor *RequestBodyOrRef) *RequestBodyOrRef {
+	for depth := 0; rbor != nil && rbor.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(rbor.Ref, refRequestBodies) {
+			return nil
+		}
+		rbor = o.Components.RequestBodies[strings.TrimPrefix(rbor.Ref, refRequestBodies)]
+	}
+	return rbor
+}
+
+// ResolveResponse ...
+func (o *OpenAPI) ResolveResponse(ror *ResponseOrRef) *ResponseOrRef {
+	for depth := 0; ror != nil && ror.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(ror.Ref, refResponses) {
+			return nil
+		}
+		ror = o.Components.Responses[strings.TrimPrefix(ror.Ref, refResponses)]
+	}
+	return ror
+}
+
+// ResolveHeader ...
+func (o *OpenAPI) ResolveHeader(hor *HeaderOrRef) *HeaderOrRef {
+	for depth := 0; hor != nil && hor.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(hor.Ref, refHeaders) {
+			return nil
+		}
+		hor = o.Components.Headers[strings.TrimPrefix(hor.Ref, refHeaders)]
+	}
+	return hor
+}
+
+// ResolveExample ...
+func (o *OpenAPI) ResolveExample(eor *ExampleOrRef) *ExampleOrRef {
+	for depth := 0; eor != nil && eor.IsRef(); depth++ {
+		if depth > maxRefDepth || o.Components == nil || !strings.HasPrefix(eor.Ref, refExamples) {
+			return nil
+		}
+		eor = o.Components.Examples[strings.TrimPrefix(eor.Ref, refExamples)]
+	}
+	return eor
+}
+
+// OperationParameters returns the resolved parameters of an operation
+// including those inherited from its path item. Operation level parameters
+// override path level ones with the same name and location.
+func (o *OpenAPI) OperationParameters(item *PathItem, op *Operation) []*Parameter {
+	var params []*Parameter
+	index := map[string]int{}
+	for _, list := range [][]*ParameterOrRef{item.Parameters, op.Parameters} {
+		for _, por := range list {
+			resolved := o.ResolveParameter(por)
+			if resolved == nil {
+				continue
+			}
+			param := &resolved.Parameter
+			key := param.In + ":" + param.Name
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
@@ -0,0 +1,119 @@
+package openapi
+
+// maxSampleDepth stops the synthesis of recursive schemas.
+const maxSampleDepth = 8
+
+// Sample synthesizes a value matching the schema. Examples, defaults and enum
+// values declared in the schema are preferred over generated values.
+func (o *OpenAPI) Sample(sor *SchemaOrRef) Any {
+	return o.sample(sor, 0)
+}
+
+func (o *OpenAPI) sample(sor *SchemaOrRef, depth int) Any {
+	sor = o.ResolveSchema(sor)
+	if sor == nil || depth > maxSampleDepth {
+		return nil
+	}
+	schema := &sor.Schema
+
+	switch {
+	case schema.Example != nil:
+		return NormalizeAny(schema.Example)
+	case schema.Default != nil:
+		return NormalizeAny(schema.Default)
+	case len(schema.Enum) > 0:
+		return NormalizeAny(schema.Enum[0])
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, sub := range schema.AllOf {
+			if m, ok := o.sample(sub, depth+1).(map[string]interface{}); ok {
+				for key, value := range m {
+					merged[key] = value
+				}
+			}
+		}
+		for key, value := range o.sampleProperties(schema, depth) {
+			merged[key] = value
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return o.sample(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return o.sample(schema.AnyOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "string":
+		return sampleString(schema)
+	case "integer":
+		if schema.Minimum != nil {
+			return int64(*schema.Minimum)
+		}
+		return 0
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "array":
+		item := o.sample(schema.Items, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	default:
+		// objects, and schemas with only properties
+		return o.sampleProperties(schema, depth)
+	}
+}
+
+func (o *OpenAPI) sampleProperties(schema *Schema, depth int) map[string]interface{} {
+	m := map[string]interface{}{}
+	for name, prop := range schema.Properties {
+		if o.ResolveSchema(prop) != nil && o.ResolveSchema(prop).WriteOnly {
+			continue
+		}
+		if value := o.sample(prop, depth+1); value != nil {
+			m[name] = value
+		}
+	}
+	return m
+}
+
+func sampleString(schema *Schema) string {
+	switch schema.Format {
+	case "date":
+		return "2020-01-01"
+	case "date-time":
+		return "2020-01-01T00:00:00Z"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+
+	s := "string"
+	if schema.MinLength != nil {
+		for uint64(len(s)) < *schema.MinLength {
+			s += "s"
+		}
+	}
+	if schema.MaxLength != nil && uint64(len(s)) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	return s
+}
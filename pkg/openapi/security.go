@@ -1,29 +1,40 @@
 package openapi
 
 import (
-	"path/filepath"
-)
-
-const (
-	fileSecurity = "security.yml"
+	"path"
 )
 
 // SecurityRequirement ...
 type SecurityRequirement map[string][]string
 
+// SecurityRequirements are the requirements of an operation. An empty list,
+// which removes the requirements of the document, is kept apart from none.
+type SecurityRequirements []*SecurityRequirement
+
+// IsZero keeps an empty list when omitting empty fields.
+func (s SecurityRequirements) IsZero() bool {
+	return s == nil
+}
+
 // LoadSecurity ...
-func LoadSecurity(root string) (security []SecurityRequirement, err error) {
-	filename := filepath.Join(root, fileSecurity)
+func LoadSecurity(root string) ([]SecurityRequirement, error) {
+	return newLoader(osFS{}).loadSecurity(root)
+}
 
-	if err = loadYAML(filename, &security); err != nil {
+func (l *loader) loadSecurity(root string) (security []SecurityRequirement, err error) {
+	filename := l.files.Join(root, l.layout.file(l.layout.Security))
+
+	if err = l.decode(filename, &security); err != nil {
 		return
 	}
 	return
 }
 
 // DumpSecurity ...
-func DumpSecurity(root string, security []SecurityRequirement) (err error) {
-	filename := filepath.Join(root, fileSecurity)
+func DumpSecurity(root string, security []SecurityRequirement) error {
+	return newDumper(DirSink(root)).dumpSecurity(".", security)
+}
 
-	return dumpYAML(filename, security)
+func (d *dumper) dumpSecurity(dir string, security []SecurityRequirement) error {
+	return d.writeYAML(path.Join(dir, d.layout.file(d.layout.Security)), security)
 }
@@ -1,11 +1,7 @@
 package openapi
 
 import (
-	"path/filepath"
-)
-
-const (
-	fileServers = "servers.yml"
+	"path"
 )
 
 // Server ...
@@ -23,18 +19,24 @@ type ServerVariable struct {
 }
 
 // LoadServers ...
-func LoadServers(root string) (servers []*Server, err error) {
-	filename := filepath.Join(root, fileServers)
+func LoadServers(root string) ([]*Server, error) {
+	return newLoader(osFS{}).loadServers(root)
+}
 
-	if err = loadYAML(filename, &servers); err != nil {
+func (l *loader) loadServers(root string) (servers []*Server, err error) {
+	filename := l.files.Join(root, l.layout.file(l.layout.Servers))
+
+	if err = l.decode(filename, &servers); err != nil {
 		return
 	}
 	return
 }
 
 // DumpServers ...
-func DumpServers(root string, servers []*Server) (err error) {
-	filename := filepath.Join(root, fileServers)
+func DumpServers(root string, servers []*Server) error {
+	return newDumper(DirSink(root)).dumpServers(".", servers)
+}
 
-	return dumpYAML(filename, servers)
+func (d *dumper) dumpServers(dir string, servers []*Server) error {
+	return d.writeYAML(path.Join(dir, d.layout.file(d.layout.Servers)), servers)
 }
@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sink receives the files of a project being dumped.
+type Sink interface {
+	// WriteFile writes a file, named by its slash separated path relative
+	// to the root of the project.
+	WriteFile(name string, data []byte) error
+}
+
+// DirSink writes files under a directory, creating the directories they
+// are in.
+type DirSink string
+
+// WriteFile ...
+func (dir DirSink) WriteFile(name string, data []byte) error {
+	filename := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// MapSink keeps files in memory, by name.
+type MapSink map[string][]byte
+
+// WriteFile ...
+func (m MapSink) WriteFile(name string, data []byte) error {
+	m[name] = data
+	return nil
+}
+
+// DumpOption configures how a project is dumped.
+type DumpOption func(*dumper)
+
+// WithDumpLayout lays out dumped projects as l instead of DefaultLayout.
+func WithDumpLayout(l Layout) DumpOption {
+	return func(d *dumper) { d.layout = l.Complete() }
+}
+
+// dumper writes the files of a project to a sink.
+type dumper struct {
+	sink   Sink
+	layout Layout
+}
+
+func newDumper(sink Sink, opts ...DumpOption) *dumper {
+	d := &dumper{sink: sink, layout: DefaultLayout()}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *dumper) writeYAML(name string, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return d.sink.WriteFile(name, b)
+}
@@ -1,10 +1,6 @@
 package openapi
 
-import "path/filepath"
-
-const (
-	fileTags = "tags.yml"
-)
+import "path"
 
 // Tag ...
 type Tag struct {
@@ -14,18 +10,24 @@ type Tag struct {
 }
 
 // LoadTags ...
-func LoadTags(root string) (tags []*Tag, err error) {
-	filename := filepath.Join(root, fileTags)
+func LoadTags(root string) ([]*Tag, error) {
+	return newLoader(osFS{}).loadTags(root)
+}
 
-	if err = loadYAML(filename, &tags); err != nil {
+func (l *loader) loadTags(root string) (tags []*Tag, err error) {
+	filename := l.files.Join(root, l.layout.file(l.layout.Tags))
+
+	if err = l.decode(filename, &tags); err != nil {
 		return
 	}
 	return
 }
 
 // DumpTags ...
-func DumpTags(root string, tags []*Tag) (err error) {
-	filename := filepath.Join(root, fileTags)
+func DumpTags(root string, tags []*Tag) error {
+	return newDumper(DirSink(root)).dumpTags(".", tags)
+}
 
-	return dumpYAML(filename, tags)
+func (d *dumper) dumpTags(dir string, tags []*Tag) error {
+	return d.writeYAML(path.Join(dir, d.layout.file(d.layout.Tags)), tags)
 }
@@ -1,8 +1,12 @@
 package openapi
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/url"
 	"os"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -14,11 +18,14 @@ func loadYAML(filename string, v interface{}) (err error) {
 	}
 	defer f.Close()
 
-	return yaml.NewDecoder(f).Decode(v)
+	if err = yaml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return nil
 }
 
 func dumpYAML(filename string, v interface{}) (err error) {
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0o644)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
 	if err != nil {
 		return
 	}
@@ -30,6 +37,48 @@ func dumpYAML(filename string, v interface{}) (err error) {
 // URL ...
 type URL struct {
 	*url.URL
+
+	// raw keeps URLs holding server variables as written, see parseURL.
+	raw string
+}
+
+// String ...
+func (u *URL) String() string {
+	if u.raw != "" {
+		return u.raw
+	}
+	return u.URL.String()
+}
+
+var urlVariable = regexp.MustCompile(`\{[^{}]*\}`)
+
+// parseURL parses s, allowing server variables such as
+// `https://{env}.example.com:{port}/v1` in the host and port, which net/url
+// rejects.
+func parseURL(s string) (*URL, error) {
+	u, err := url.Parse(s)
+	if err == nil || !urlVariable.MatchString(s) {
+		return &URL{URL: u}, err
+	}
+	// check the URL is valid once its variables are filled
+	if _, err := url.Parse(urlVariable.ReplaceAllString(s, "0")); err != nil {
+		return nil, err
+	}
+	i := strings.Index(s, "://")
+	if i < 0 {
+		return nil, err
+	}
+	authority := s[i+3:]
+	rest := ""
+	if j := strings.IndexAny(authority, "/?#"); j >= 0 {
+		authority, rest = authority[:j], authority[j:]
+	}
+	ref, err := url.Parse(rest)
+	if err != nil {
+		return nil, err
+	}
+	ref.Scheme, ref.Host = s[:i], authority
+	return &URL{URL: ref, raw: s}, nil
 }
 
 // MarshalYAML ...
@@ -43,15 +92,66 @@ func (u *URL) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
 	if err = unmarshal(&s); err != nil {
 		return
 	}
-	u.URL, err = url.Parse(s)
+	parsed, err := parseURL(s)
+	if err != nil {
+		return
+	}
+	*u = *parsed
+	return
+}
+
+// MarshalJSON ...
+func (u *URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON ...
+func (u *URL) UnmarshalJSON(b []byte) (err error) {
+	var s string
+	if err = json.Unmarshal(b, &s); err != nil {
+		return
+	}
+	parsed, err := parseURL(s)
+	if err != nil {
+		return
+	}
+	*u = *parsed
 	return
 }
 
 // MustParseURL ...
 func MustParseURL(s string) *URL {
-	u, err := url.Parse(s)
+	u, err := parseURL(s)
 	if err != nil {
 		panic(err)
 	}
-	return &URL{u}
+	return u
+}
+
+// NormalizeAny converts the `map[interface{}]interface{}` values produced by
+// the YAML decoder into `map[string]interface{}`, so that they can be encoded
+// as JSON.
+func NormalizeAny(v Any) Any {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = NormalizeAny(value)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = NormalizeAny(value)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, value := range v {
+			s[i] = NormalizeAny(value)
+		}
+		return s
+	default:
+		return v
+	}
 }
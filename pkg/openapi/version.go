@@ -1,20 +1,18 @@
 package openapi
 
 import (
-	"io/ioutil"
-	"path/filepath"
 	"strings"
 )
 
-const (
-	fileOpenAPIVersion = "openapi_version"
-)
-
 // LoadOpenAPIVersion ...
-func LoadOpenAPIVersion(root string) (ver string, err error) {
-	path := filepath.Join(root, fileOpenAPIVersion)
+func LoadOpenAPIVersion(root string) (string, error) {
+	return newLoader(osFS{}).loadOpenAPIVersion(root)
+}
 
-	bver, err := ioutil.ReadFile(path)
+func (l *loader) loadOpenAPIVersion(root string) (ver string, err error) {
+	filename := l.files.Join(root, l.layout.OpenAPIVersion)
+
+	bver, err := l.read(filename)
 	if err != nil {
 		return
 	}
@@ -24,8 +22,10 @@ func LoadOpenAPIVersion(root string) (ver string, err error) {
 }
 
 // DumpOpenAPIVersion ...
-func DumpOpenAPIVersion(root string, ver string) (err error) {
-	path := filepath.Join(root, fileOpenAPIVersion)
+func DumpOpenAPIVersion(root string, ver string) error {
+	return newDumper(DirSink(root)).dumpOpenAPIVersion(ver)
+}
 
-	return ioutil.WriteFile(path, []byte(ver), 0o644)
+func (d *dumper) dumpOpenAPIVersion(ver string) error {
+	return d.sink.WriteFile(d.layout.OpenAPIVersion, []byte(ver))
 }
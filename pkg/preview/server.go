@@ -0,0 +1,195 @@
+// Package preview serves the documentation of a document while it is being
+// edited.
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/cry999/gopenapi/pkg/docs"
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+// reloadScript reloads pages when the server sends an event.
+const reloadScript = `<script>new EventSource("/_reload").onmessage = function () { location.reload(); };</script>`
+
+// Server serves a document at /openapi.yml and /openapi.json and its HTML
+// reference at /. Pages reload themselves whenever the document is reloaded,
+// and show the error instead if it fails to load.
+type Server struct {
+	load   func() (*openapi.OpenAPI, error)
+	logger *log.Logger
+
+	mu    sync.RWMutex
+	err   error
+	files docs.Files
+	yaml  []byte
+	json  []byte
+	// reloaded is closed when the document is reloaded.
+	reloaded chan struct{}
+}
+
+// Option ...
+type Option func(*Server)
+
+// WithLogger logs every request and reload to l.
+func WithLogger(l *log.Logger) Option {
+	return func(s *Server) { s.logger = l }
+}
+
+// New creates a server of the document returned by load, and loads it.
+func New(load func() (*openapi.OpenAPI, error), opts ...Option) *Server {
+	s := &Server{load: load, reloaded: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Reload()
+	return s
+}
+
+// Reload loads the document again and tells the pages to reload.
+func (s *Server) Reload() {
+	files, yml, js, err := s.render()
+	if s.logger != nil {
+		if err != nil {
+			s.logger.Printf("failed to load the document: %v", err)
+		} else {
+			s.logger.Printf("loaded the document")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files, s.yaml, s.json, s.err = files, yml, js, err
+	close(s.reloaded)
+	s.reloaded = make(chan struct{})
+}
+
+func (s *Server) render() (docs.Files, []byte, []byte, error) {
+	spec, err := s.load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	files, err := docs.HTML(docs.NewSite(spec), "")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to render the documentation: %v", err)
+	}
+	yml, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	js, err := openapi.EncodeJSON(spec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return files, yml, js, nil
+}
+
+// ServeHTTP ...
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/_reload" {
+		s.serveReload(w, r)
+		return
+	}
+	rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.serve(rw, r)
+	if s.logger != nil {
+		s.logger.Printf("%s %s %d", r.Method, r.URL.RequestURI(), rw.status)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	files, yml, js, err := s.files, s.yaml, s.json, s.err
+	s.mu.RUnlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+	switch {
+	case err != nil && (name == "openapi.yml" || name == "openapi.json"):
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case name == "openapi.yml":
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(yml)
+	case name == "openapi.json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(js)
+	case err != nil && path.Ext(name) == ".html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		errorPage.Execute(w, err.Error())
+	default:
+		content, ok := files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if path.Ext(name) == ".html" {
+			content = bytes.Replace(content, []byte("</body>"), []byte(reloadScript+"\n</body>"), 1)
+		}
+		if typ := mime.TypeByExtension(path.Ext(name)); typ != "" {
+			w.Header().Set("Content-Type", typ)
+		}
+		w.Write(content)
+	}
+}
+
+// serveReload sends an event when the document is reloaded.
+func (s *Server) serveReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	s.mu.RLock()
+	reloaded := s.reloaded
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	select {
+	case <-reloaded:
+		fmt.Fprint(w, "data: reload\n\n")
+		flusher.Flush()
+	case <-r.Context().Done():
+	}
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Failed to load the document</title>
+<style>body { font-family: sans-serif; margin: 2rem; } pre { background: #fdecea; color: #611a15; padding: 1rem; white-space: pre-wrap; }</style>
+</head>
+<body>
+<h1>Failed to load the document</h1>
+<pre>{{.}}</pre>
+<p>The page reloads once the document is fixed.</p>
+` + reloadScript + `
+</body>
+</html>
+`))
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
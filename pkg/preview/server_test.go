@@ -0,0 +1,73 @@
+package preview
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+func TestServer(t *testing.T) {
+	var loadErr error
+	spec := &openapi.OpenAPI{
+		Version: "3.0.3",
+		Info:    &openapi.Info{Title: "Pets", Version: "1.0.0"},
+	}
+	s := New(func() (*openapi.OpenAPI, error) { return spec, loadErr })
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/openapi.yml", http.StatusOK, "openapi: 3.0.3"},
+		{"/openapi.json", http.StatusOK, `"title": "Pets"`},
+		{"/", http.StatusOK, `new EventSource("/_reload")`},
+		{"/assets/style.css", http.StatusOK, ""},
+		{"/missing.html", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		status, body := get(tt.path)
+		if status != tt.status || !strings.Contains(body, tt.want) {
+			t.Errorf("GET %s = %d %q, want %d containing %q", tt.path, status, body, tt.status, tt.want)
+		}
+	}
+
+	// reloading sends an event
+	resp, err := http.Get(ts.URL + "/_reload")
+	if err != nil {
+		t.Fatalf("GET /_reload error = %v", err)
+	}
+	defer resp.Body.Close()
+	loadErr = errors.New("info.yml: broken")
+	s.Reload()
+	if line, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil || line != "data: reload\n" {
+		t.Errorf("event = %q, %v, want data: reload", line, err)
+	}
+
+	// errors are shown instead of the pages
+	if status, body := get("/"); status != http.StatusInternalServerError || !strings.Contains(body, "info.yml: broken") {
+		t.Errorf("GET / = %d %q, want the error", status, body)
+	}
+	if status, _ := get("/openapi.json"); status != http.StatusInternalServerError {
+		t.Errorf("GET /openapi.json = %d, want %d", status, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,251 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// errors returned when no operation matches a request
+var (
+	ErrNotFound         = errors.New("no path matches the request")
+	ErrMethodNotAllowed = errors.New("the method is not defined for the path")
+)
+
+// Router finds the operation a request hits among the paths of a document.
+type Router struct {
+	routes  []*route
+	servers []*server
+}
+
+// Match is the operation hit by a request.
+type Match struct {
+	// Template is the matched path, such as `/users/{id}`.
+	Template  string
+	PathItem  *openapi.PathItem
+	Operation *openapi.Operation
+	// Params holds the decoded values of the templated path segments.
+	Params map[string]string
+
+	// Server is the server whose base path prefixes the request, and
+	// ServerVariables the values of the variables of its path. Server is nil
+	// when the request is not below any base path.
+	Server          *openapi.Server
+	ServerVariables map[string]string
+}
+
+type route struct {
+	template string
+	segments []*segment
+	item     *openapi.PathItem
+}
+
+type server struct {
+	server   *openapi.Server
+	segments []*segment
+}
+
+// segment is a literal segment of a path, or a templated one such as `{id}`
+// or `{name}.{ext}`.
+type segment struct {
+	literal string
+	pattern *regexp.Regexp
+	names   []string
+}
+
+var variable = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func newSegment(s string) *segment {
+	locs := variable.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 {
+		return &segment{literal: s}
+	}
+	seg := &segment{}
+	expr, last := "^", 0
+	for _, loc := range locs {
+		expr += regexp.QuoteMeta(s[last:loc[0]]) + "(.+?)"
+		seg.names = append(seg.names, s[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	seg.pattern = regexp.MustCompile(expr + regexp.QuoteMeta(s[last:]) + "$")
+	return seg
+}
+
+// rank orders literal segments before partly templated ones, and those
+// before wholly templated ones.
+func (s *segment) rank() int {
+	switch {
+	case s.pattern == nil:
+		return 0
+	case s.pattern.String() == "^(.+?)$":
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (s *segment) match(value string, vars map[string]string) bool {
+	if s.pattern == nil {
+		return s.literal == value
+	}
+	m := s.pattern.FindStringSubmatch(value)
+	if m == nil {
+		return false
+	}
+	for i, name := range s.names {
+		vars[name] = m[i+1]
+	}
+	return true
+}
+
+func newSegments(path string) []*segment {
+	var segments []*segment
+	for _, s := range splitPath(path) {
+		segments = append(segments, newSegment(s))
+	}
+	return segments
+}
+
+// New builds a router from the paths of a document, served below the base
+// paths of servers. Paths are served from the root as well, when no base path
+// prefixes the request.
+func New(paths openapi.Paths, servers []*openapi.Server) *Router {
+	r := &Router{}
+	for template, item := range paths {
+		r.routes = append(r.routes, &route{template: template, segments: newSegments(template), item: item})
+	}
+	// literal segments take precedence over templated ones
+	sort.Slice(r.routes, func(i, j int) bool {
+		a, b := r.routes[i].segments, r.routes[j].segments
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k].rank() != b[k].rank() {
+				return a[k].rank() < b[k].rank()
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return r.routes[i].template < r.routes[j].template
+	})
+
+	for _, s := range servers {
+		var path string
+		if s.URL != nil && s.URL.URL != nil {
+			path = s.URL.Path
+		}
+		r.servers = append(r.servers, &server{server: s, segments: newSegments(path)})
+	}
+	if len(r.servers) == 0 {
+		r.servers = []*server{{server: &openapi.Server{URL: openapi.MustParseURL("/")}}}
+	}
+	// the longest base path wins when several servers share a prefix
+	sort.SliceStable(r.servers, func(i, j int) bool {
+		return len(r.servers[i].segments) > len(r.servers[j].segments)
+	})
+	if len(r.servers[len(r.servers)-1].segments) > 0 {
+		// bare paths match as well, without server
+		r.servers = append(r.servers, &server{})
+	}
+	return r
+}
+
+// Match finds the operation of the request. When the path matches but the
+// method does not, the match is returned without operation along with
+// ErrMethodNotAllowed.
+func (r *Router) Match(req *http.Request) (*Match, error) {
+	return r.Lookup(req.Method, req.URL.EscapedPath())
+}
+
+// Lookup finds the operation of the method at the escaped path.
+func (r *Router) Lookup(method, path string) (*Match, error) {
+	segments := splitPath(path)
+	for i, s := range segments {
+		unescaped, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, ErrNotFound
+		}
+		segments[i] = unescaped
+	}
+
+	for _, s := range r.servers {
+		vars, ok := s.match(segments)
+		if !ok {
+			continue
+		}
+		for _, rt := range r.routes {
+			params, ok := rt.match(segments[len(s.segments):])
+			if !ok {
+				continue
+			}
+			m := &Match{
+				Template:        rt.template,
+				PathItem:        rt.item,
+				Operation:       rt.item.Operation(method),
+				Params:          params,
+				Server:          s.server,
+				ServerVariables: vars,
+			}
+			if m.Operation == nil {
+				return m, ErrMethodNotAllowed
+			}
+			return m, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// match matches the base path against the first segments, checking the
+// values of variables against their enum.
+func (s *server) match(segments []string) (map[string]string, bool) {
+	if len(segments) < len(s.segments) {
+		return nil, false
+	}
+	vars := map[string]string{}
+	for i, seg := range s.segments {
+		if !seg.match(segments[i], vars) {
+			return nil, false
+		}
+	}
+	for name, value := range vars {
+		v, ok := s.server.Variables[name]
+		if ok && len(v.Enum) > 0 && !contains(v.Enum, value) {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+func (r *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, seg := range r.segments {
+		if !seg.match(segments[i], params) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
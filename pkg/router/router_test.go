@@ -0,0 +1,121 @@
+package router
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSpec = `
+servers:
+- url: https://{env}.example.com/{version}
+  variables:
+    env:
+      default: api
+    version:
+      default: v1
+      enum: [v1, v2]
+paths:
+  /users/{id}:
+    get: {}
+    delete: {}
+  /users/me:
+    get: {}
+  /files/{name}.{ext}:
+    get: {}
+  /files/{path}:
+    get: {}
+`
+
+func TestRouter_Lookup(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(strings.NewReader(testSpec)).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	r := New(spec.Paths, spec.Servers)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		wantTemplate string
+		wantParams   map[string]string
+		wantVars     map[string]string
+		wantErr      error
+	}{
+		{
+			name:         "literal wins over template",
+			method:       "GET",
+			path:         "/v1/users/me",
+			wantTemplate: "/users/me",
+			wantParams:   map[string]string{},
+			wantVars:     map[string]string{"version": "v1"},
+		},
+		{
+			name:         "templated segment",
+			method:       "DELETE",
+			path:         "/v2/users/a%20b",
+			wantTemplate: "/users/{id}",
+			wantParams:   map[string]string{"id": "a b"},
+			wantVars:     map[string]string{"version": "v2"},
+		},
+		{
+			name:         "partly templated segment wins over templated one",
+			method:       "GET",
+			path:         "/v1/files/report.pdf",
+			wantTemplate: "/files/{name}.{ext}",
+			wantParams:   map[string]string{"name": "report", "ext": "pdf"},
+			wantVars:     map[string]string{"version": "v1"},
+		},
+		{
+			name:    "method not allowed on the literal path",
+			method:  "DELETE",
+			path:    "/v1/users/me",
+			wantErr: ErrMethodNotAllowed,
+		},
+		{
+			name:    "server variable outside its enum",
+			method:  "GET",
+			path:    "/v3/users/me",
+			wantErr: ErrNotFound,
+		},
+		{
+			name:         "bare path without base path",
+			method:       "GET",
+			path:         "/users/me",
+			wantTemplate: "/users/me",
+			wantParams:   map[string]string{},
+			wantVars:     map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Lookup(tt.method, tt.path)
+			if err != tt.wantErr {
+				t.Fatalf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Template != tt.wantTemplate {
+				t.Errorf("Lookup() template = %s, want %s", got.Template, tt.wantTemplate)
+			}
+			if !reflect.DeepEqual(got.Params, tt.wantParams) {
+				t.Errorf("Lookup() params = %v, want %v", got.Params, tt.wantParams)
+			}
+			if !reflect.DeepEqual(got.ServerVariables, tt.wantVars) {
+				t.Errorf("Lookup() server variables = %v, want %v", got.ServerVariables, tt.wantVars)
+			}
+			if got.Operation == nil {
+				t.Errorf("Lookup() operation = nil")
+			}
+		})
+	}
+
+	if got := spec.Servers[0].URL.String(); got != "https://{env}.example.com/{version}" {
+		t.Errorf("URL.String() = %s, want the URL as written", got)
+	}
+}
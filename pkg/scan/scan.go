@@ -0,0 +1,434 @@
+// Package scan extracts operations from the comments of Go functions.
+//
+// An operation is declared by a @route annotation in the doc comment of a
+// function, the other lines of which describe it:
+//
+//	// GetUser returns a user.
+//	//
+//	// @route GET /users/{id}
+//	// @summary Get a user
+//	// @id getUser
+//	// @tags users
+//	// @param id path int64 "id of the user"
+//	// @param fields query []string "fields to include"
+//	// @body UserPatch
+//	// @response 200 User
+//	// @response 404 Problem "no such user"
+//	// @security bearerAuth
+//	// @deprecated
+//	func GetUser(w http.ResponseWriter, r *http.Request)
+//
+// The text around the annotations becomes the description, and the name of
+// the function the default operationId. Types are
+// primitives (string, int32, int64, number, boolean...), component schemas
+// or arrays of them written []T.
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Operation is an operation declared on a function.
+type Operation struct {
+	Method    string
+	Path      string
+	Operation *openapi.Operation
+	// Pos is the position of the function.
+	Pos token.Position
+}
+
+// Packages scans the packages matched by patterns, as understood by
+// `go list` from dir, and returns their operations sorted by path and method.
+func Packages(dir string, patterns ...string) ([]*Operation, error) {
+	args := append([]string{"list", "-find", "-f", "{{.Dir}}"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	fset := token.NewFileSet()
+	var ops []*Operation
+	for _, pkgDir := range strings.Fields(string(out)) {
+		filenames, err := filepath.Glob(filepath.Join(pkgDir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, filename := range filenames {
+			if strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			found, err := File(fset, file)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, found...)
+		}
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return methodIndex(ops[i].Method) < methodIndex(ops[j].Method)
+	})
+	for i := 1; i < len(ops); i++ {
+		if ops[i].Path == ops[i-1].Path && ops[i].Method == ops[i-1].Method {
+			return nil, fmt.Errorf("%s: %s %s is already declared at %s", ops[i].Pos, strings.ToUpper(ops[i].Method), ops[i].Path, ops[i-1].Pos)
+		}
+	}
+	return ops, nil
+}
+
+func methodIndex(method string) int {
+	for i, m := range openapi.Methods {
+		if m == method {
+			return i
+		}
+	}
+	return len(openapi.Methods)
+}
+
+// File returns the operations declared on the functions of a file.
+func File(fset *token.FileSet, file *ast.File) ([]*Operation, error) {
+	var ops []*Operation
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		pos := fset.Position(fn.Pos())
+		op, err := parseComment(fn.Name.Name, fn.Doc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", pos, err)
+		}
+		if op != nil {
+			op.Pos = pos
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// parseComment parses the doc comment of a function, returning nil if it
+// has no @route.
+func parseComment(funcName, text string) (*Operation, error) {
+	var (
+		op          = &Operation{Operation: &openapi.Operation{OperationID: funcName}}
+		description []string
+		routed      bool
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "@") {
+			description = append(description, line)
+			continue
+		}
+
+		name, rest := trimmed, ""
+		if i := strings.IndexFunc(trimmed, unicode.IsSpace); i >= 0 {
+			name, rest = trimmed[:i], strings.TrimSpace(trimmed[i:])
+		}
+		args, err := fields(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if err := op.annotate(name[1:], rest, args); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		routed = routed || name == "@route"
+	}
+	if !routed {
+		return nil, nil
+	}
+
+	op.Operation.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	for _, name := range pathParams(op.Path) {
+		if !op.hasParam(name, "path") {
+			return nil, fmt.Errorf("path parameter '%s' of %s is not declared", name, op.Path)
+		}
+	}
+	if op.Operation.Responses == nil {
+		return nil, fmt.Errorf("%s %s declares no @response", strings.ToUpper(op.Method), op.Path)
+	}
+	return op, nil
+}
+
+// annotate applies an annotation, given both its raw text and its arguments.
+func (op *Operation) annotate(name, rest string, args []arg) error {
+	o := op.Operation
+	switch name {
+	case "route":
+		if len(args) != 2 {
+			return fmt.Errorf("want a method and a path")
+		}
+		op.Method = strings.ToLower(args[0].text)
+		if methodIndex(op.Method) == len(openapi.Methods) {
+			return fmt.Errorf("unknown method '%s'", args[0].text)
+		}
+		if !strings.HasPrefix(args[1].text, "/") {
+			return fmt.Errorf("path '%s' does not start with /", args[1].text)
+		}
+		op.Path = args[1].text
+	case "summary":
+		o.Summary = rest
+	case "id":
+		if len(args) != 1 {
+			return fmt.Errorf("want an operationId")
+		}
+		o.OperationID = args[0].text
+	case "tags":
+		o.Tags = append(o.Tags, strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })...)
+	case "deprecated":
+		o.Deprecated = true
+	case "security":
+		requirement := openapi.SecurityRequirement{}
+		for _, scheme := range args {
+			requirement[scheme.text] = []string{}
+		}
+		o.Security = append(o.Security, &requirement)
+	case "param":
+		return op.param(args)
+	case "body":
+		return op.body(args)
+	case "response":
+		return op.response(args)
+	default:
+		return fmt.Errorf("unknown annotation")
+	}
+	return nil
+}
+
+// param parses `name in type [required] ["description"]`.
+func (op *Operation) param(args []arg) error {
+	if len(args) < 3 {
+		return fmt.Errorf("want a name, a location and a type")
+	}
+	switch args[1].text {
+	case "path", "query", "header", "cookie":
+	default:
+		return fmt.Errorf("unknown location '%s'", args[1].text)
+	}
+	param := openapi.Parameter{
+		Name:     args[0].text,
+		In:       args[1].text,
+		Required: args[1].text == "path",
+		Schema:   schema(args[2].text),
+	}
+	for _, a := range args[3:] {
+		switch {
+		case a.quoted:
+			param.Description = a.text
+		case a.text == "required":
+			param.Required = true
+		default:
+			return fmt.Errorf("unexpected '%s', descriptions are quoted", a.text)
+		}
+	}
+	op.Operation.Parameters = append(op.Operation.Parameters, &openapi.ParameterOrRef{Parameter: param})
+	return nil
+}
+
+// body parses `type [media type] ["description"]`. Bodies are required.
+func (op *Operation) body(args []arg) error {
+	if len(args) < 1 || args[0].quoted {
+		return fmt.Errorf("want a type")
+	}
+	mediaType, description := "application/json", ""
+	for _, a := range args[1:] {
+		switch {
+		case a.quoted:
+			description = a.text
+		case strings.Contains(a.text, "/"):
+			mediaType = a.text
+		default:
+			return fmt.Errorf("unexpected '%s', descriptions are quoted", a.text)
+		}
+	}
+	op.Operation.RequestBody = &openapi.RequestBodyOrRef{RequestBody: openapi.RequestBody{
+		Description: description,
+		Required:    true,
+		Content:     map[string]*openapi.MediaType{mediaType: {Schema: schema(args[0].text)}},
+	}}
+	return nil
+}
+
+// response parses `status [type] ["description"]`, the description
+// defaulting to the text of the status.
+func (op *Operation) response(args []arg) error {
+	if len(args) < 1 {
+		return fmt.Errorf("want a status")
+	}
+	status := args[0].text
+	if status != "default" && !isStatus(status) {
+		return fmt.Errorf("invalid status '%s'", status)
+	}
+
+	var response openapi.Response
+	for _, a := range args[1:] {
+		switch {
+		case a.quoted:
+			response.Description = a.text
+		case response.Content == nil:
+			response.Content = map[string]*openapi.MediaType{"application/json": {Schema: schema(a.text)}}
+		default:
+			return fmt.Errorf("unexpected '%s', descriptions are quoted", a.text)
+		}
+	}
+	if response.Description == "" {
+		response.Description = statusText(status)
+	}
+
+	if op.Operation.Responses == nil {
+		op.Operation.Responses = &openapi.Responses{}
+	}
+	(*op.Operation.Responses)[status] = &openapi.ResponseOrRef{Response: response}
+	return nil
+}
+
+func (op *Operation) hasParam(name, in string) bool {
+	for _, p := range op.Operation.Parameters {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+func statusText(status string) string {
+	switch status {
+	case "default":
+		return "Default"
+	case "1XX":
+		return "Informational"
+	case "2XX":
+		return "Success"
+	case "3XX":
+		return "Redirection"
+	case "4XX":
+		return "Client error"
+	case "5XX":
+		return "Server error"
+	}
+	code, _ := strconv.Atoi(status)
+	return http.StatusText(code)
+}
+
+// isStatus reports whether s is a status code or a range like 4XX.
+func isStatus(s string) bool {
+	if len(s) != 3 || s[0] < '1' || s[0] > '5' {
+		return false
+	}
+	if s[1:] == "XX" {
+		return true
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+var primitives = map[string][2]string{
+	"string":  {"string", ""},
+	"bool":    {"boolean", ""},
+	"boolean": {"boolean", ""},
+	"int":     {"integer", ""},
+	"integer": {"integer", ""},
+	"int32":   {"integer", "int32"},
+	"int64":   {"integer", "int64"},
+	"number":  {"number", ""},
+	"float":   {"number", "float"},
+	"float32": {"number", "float"},
+	"float64": {"number", "double"},
+	"double":  {"number", "double"},
+	"date":    {"string", "date"},
+	"time":    {"string", "date-time"},
+	"binary":  {"string", "binary"},
+}
+
+// schema converts a type: a primitive, []T or the name of a component.
+func schema(typ string) *openapi.SchemaOrRef {
+	if strings.HasPrefix(typ, "[]") {
+		return &openapi.SchemaOrRef{Schema: openapi.Schema{Type: "array", Items: schema(typ[2:])}}
+	}
+	if p, ok := primitives[typ]; ok {
+		return &openapi.SchemaOrRef{Schema: openapi.Schema{Type: p[0], Format: p[1]}}
+	}
+	return &openapi.SchemaOrRef{Reference: openapi.Reference{Ref: "#/components/schemas/" + typ}}
+}
+
+// arg is an argument of an annotation.
+type arg struct {
+	text   string
+	quoted bool
+}
+
+// fields splits the arguments of an annotation on spaces, except within
+// double-quoted strings, which are unquoted.
+func fields(s string) ([]arg, error) {
+	var args []arg
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '"' {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			args = append(args, arg{text: s[:end]})
+			s = s[end:]
+			continue
+		}
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		text, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s: %v", s[:end+1], err)
+		}
+		args = append(args, arg{text: text, quoted: true})
+		s = s[end+1:]
+	}
+	return args, nil
+}
+
+// pathParams lists the parameters of a path template.
+func pathParams(path string) []string {
+	var names []string
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			return names
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return names
+		}
+		names = append(names, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
@@ -0,0 +1,200 @@
+package scan
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const testSource = `package api
+
+// GetUser returns a user.
+//
+// @route GET /users/{id}
+// @summary Get a user
+// @tags users, admin
+// @param id path int64 "id of the user"
+// @param fields query []string required
+// @response 200 User
+// @response 404 Problem "no such user"
+// @response default
+// @security bearerAuth
+func GetUser() {}
+
+// CreateUser creates a user.
+//
+// @route POST /users
+// @id createUser
+// @body User application/json "the user"
+// @response 201 User
+func (h *Handler) CreateUser() {}
+
+// helper is not an operation.
+func helper() {}
+`
+
+func TestFile(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "api.go", testSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := File(fset, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("got %d operations, want 2", len(ops))
+	}
+
+	got, _ := yaml.Marshal(ops[0].Operation)
+	want := `
+tags:
+- users
+- admin
+summary: Get a user
+description: GetUser returns a user.
+operationId: GetUser
+parameters:
+- name: id
+  in: path
+  description: id of the user
+  required: true
+  schema:
+    type: integer
+    format: int64
+- name: fields
+  in: query
+  required: true
+  schema:
+    type: array
+    items:
+      type: string
+responses:
+  "200":
+    description: OK
+    content:
+      application/json:
+        schema:
+          $ref: '#/components/schemas/User'
+  "404":
+    description: no such user
+    content:
+      application/json:
+        schema:
+          $ref: '#/components/schemas/Problem'
+  default:
+    description: Default
+security:
+- bearerAuth: []
+`
+	if ops[0].Method != "get" || ops[0].Path != "/users/{id}" {
+		t.Errorf("got %s %s, want get /users/{id}", ops[0].Method, ops[0].Path)
+	}
+	if strings.TrimSpace(string(got)) != strings.TrimSpace(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	create := ops[1].Operation
+	if create.OperationID != "createUser" || create.RequestBody == nil ||
+		create.RequestBody.Description != "the user" || create.RequestBody.Content["application/json"] == nil {
+		t.Errorf("unexpected operation %+v", create)
+	}
+	if ops[1].Pos.Line != 22 {
+		t.Errorf("got position %s, want line 22", ops[1].Pos)
+	}
+}
+
+func TestParseComment_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown method":     "@route FETCH /users\n@response 200",
+		"relative path":      "@route GET users\n@response 200",
+		"undeclared param":   "@route GET /users/{id}\n@response 200",
+		"no response":        "@route GET /users",
+		"bad status":         "@route GET /users\n@response 600",
+		"unquoted text":      "@route GET /users\n@response 200 User no such user",
+		"unknown location":   "@route GET /users\n@param id body string\n@response 200",
+		"unterminated":       "@route GET /users\n@response 200 \"ok\n",
+		"unknown annotation": "@route GET /users\n@respond 200",
+	}
+	for name, comment := range tests {
+		if _, err := parseComment("F", comment); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	op, err := parseComment("F", "F is not an operation.\n@summary nothing")
+	if err != nil || op != nil {
+		t.Errorf("got %v, %v, want no operation", op, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	root, err := ioutil.TempDir("", "scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(root, "api.go"), testSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := File(fset, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Write(root, openapi.DefaultLayout(), ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 4 || len(result.Conflicts) != 0 {
+		t.Fatalf("first write: %+v", result)
+	}
+
+	result, err = Write(root, openapi.DefaultLayout(), ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 0 || len(result.Unchanged) != 2 {
+		t.Fatalf("second write: %+v", result)
+	}
+
+	// edit one file by hand and change both operations
+	edited := filepath.Join(root, "paths", "users", "post.yml")
+	content, _ := ioutil.ReadFile(edited)
+	if err := ioutil.WriteFile(edited, append(content, "deprecated: true\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ops[0].Operation.Summary = "Fetch a user"
+	ops[1].Operation.Summary = "Create a user"
+
+	result, err = Write(root, openapi.DefaultLayout(), ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 1 || len(result.Conflicts) != 1 ||
+		result.Conflicts[0].Reason != "edited since generated" {
+		t.Fatalf("third write: %+v", result)
+	}
+	if got, _ := ioutil.ReadFile(edited); !strings.Contains(string(got), "deprecated: true") {
+		t.Error("edited file was overwritten")
+	}
+
+	result, err = Write(root, openapi.DefaultLayout(), ops, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 1 || len(result.Conflicts) != 0 {
+		t.Fatalf("forced write: %+v", result)
+	}
+}
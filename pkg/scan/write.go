@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	headerPrefix   = "# Generated by gopenapi scan from "
+	checksumPrefix = "# sha256: "
+)
+
+// Result reports what Write did, with files relative to the project.
+type Result struct {
+	Written   []string
+	Unchanged []string
+	Conflicts []*Conflict
+}
+
+// Conflict is a file which was not overwritten.
+type Conflict struct {
+	File      string
+	Operation *Operation
+	Reason    string
+}
+
+func (c *Conflict) String() string {
+	return fmt.Sprintf("%s: %s, declared at %s", c.File, c.Reason, c.Operation.Pos)
+}
+
+// Write writes the operations into the project at root, laid out as layout,
+// along with the index files of their paths when missing.
+//
+// Written files start with a header holding a checksum of their content, so
+// that a file can be updated by a later scan as long as it has not been
+// edited by hand. Files edited by hand, or not written by scan in the first
+// place, are reported as conflicts and left alone, unless force is set.
+func Write(root string, layout openapi.Layout, ops []*Operation, force bool) (*Result, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, op := range ops {
+		index := layout.PathItemFilename(root, op.Path)
+		if err := os.MkdirAll(filepath.Dir(index), 0o755); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(index); os.IsNotExist(err) {
+			if err := ioutil.WriteFile(index, []byte("{}\n"), 0o644); err != nil {
+				return nil, err
+			}
+			result.Written = append(result.Written, relative(root, index))
+		}
+
+		filename := layout.OperationFilename(root, op.Path, op.Method)
+		content, err := generate(abs, op)
+		if err != nil {
+			return nil, err
+		}
+
+		current, err := ioutil.ReadFile(filename)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		case bytes.Equal(current, content):
+			result.Unchanged = append(result.Unchanged, relative(root, filename))
+			continue
+		case !force:
+			if reason := edited(current); reason != "" {
+				result.Conflicts = append(result.Conflicts, &Conflict{
+					File:      relative(root, filename),
+					Operation: op,
+					Reason:    reason,
+				})
+				continue
+			}
+		}
+
+		if err := ioutil.WriteFile(filename, content, 0o644); err != nil {
+			return nil, err
+		}
+		result.Written = append(result.Written, relative(root, filename))
+	}
+	return result, nil
+}
+
+// generate renders the file of an operation.
+func generate(root string, op *Operation) ([]byte, error) {
+	body, err := yaml.Marshal(op.Operation)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op.Pos, err)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s%s; edit the comment of the function instead.\n", headerPrefix, filepath.ToSlash(relative(root, op.Pos.Filename)))
+	fmt.Fprintf(&b, "%s%s\n", checksumPrefix, checksum(body))
+	b.Write(body)
+	return b.Bytes(), nil
+}
+
+// edited tells why a file cannot be overwritten, if it cannot.
+func edited(content []byte) string {
+	r := bufio.NewReader(bytes.NewReader(content))
+	header, _ := r.ReadString('\n')
+	sum, _ := r.ReadString('\n')
+	if !strings.HasPrefix(header, headerPrefix) || !strings.HasPrefix(sum, checksumPrefix) {
+		return "not generated by scan"
+	}
+	body := content[len(header)+len(sum):]
+	if strings.TrimSpace(strings.TrimPrefix(sum, checksumPrefix)) != checksum(body) {
+		return "edited since generated"
+	}
+	return ""
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func relative(root, filename string) string {
+	if rel, err := filepath.Rel(root, filename); err == nil {
+		return rel
+	}
+	return filename
+}
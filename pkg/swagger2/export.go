@@ -0,0 +1,767 @@
+package swagger2
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Warning describes a construct which could not be represented in Swagger
+// 2.0 and was dropped or approximated.
+type Warning struct {
+	Location string
+	Message  string
+}
+
+// String ...
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Location, w.Message)
+}
+
+// FromOpenAPI downgrades an OpenAPI 3.0 document to Swagger 2.0.
+func FromOpenAPI(spec *openapi.OpenAPI) (*Swagger, []Warning) {
+	e := &exporter{spec: spec, bodies: map[string]string{}}
+
+	doc := &Swagger{
+		Swagger:  "2.0",
+		Info:     spec.Info,
+		Security: spec.Security,
+		Tags:     spec.Tags,
+	}
+	e.servers(doc, spec.Servers)
+	e.components(doc)
+
+	if len(spec.Paths) > 0 {
+		doc.Paths = map[string]*PathItem{}
+		for _, path := range sortedKeys(spec.Paths) {
+			doc.Paths[path] = e.pathItem("paths."+path, spec.Paths[path])
+		}
+	}
+
+	// media types are declared globally when every operation agrees
+	doc.Consumes = e.commonMediaTypes(doc, func(op *Operation) *[]string { return &op.Consumes })
+	doc.Produces = e.commonMediaTypes(doc, func(op *Operation) *[]string { return &op.Produces })
+
+	return doc, e.warnings
+}
+
+type exporter struct {
+	spec     *openapi.OpenAPI
+	warnings []Warning
+	// bodies maps the names of the request bodies exported as parameters
+	// to the names of the parameters. Others are inlined where used.
+	bodies map[string]string
+}
+
+func (e *exporter) warn(location, format string, args ...interface{}) {
+	e.warnings = append(e.warnings, Warning{
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (e *exporter) servers(doc *Swagger, servers []*openapi.Server) {
+	if len(servers) == 0 {
+		return
+	}
+
+	first := e.serverURL("servers[0]", servers[0])
+	doc.Host = first.Host
+	doc.BasePath = first.Path
+	if first.Scheme != "" {
+		doc.Schemes = []string{first.Scheme}
+	}
+
+	for i, server := range servers[1:] {
+		u := e.serverURL(fmt.Sprintf("servers[%d]", i+1), server)
+		if u.Host == first.Host && u.Path == first.Path && u.Scheme != "" {
+			doc.Schemes = append(doc.Schemes, u.Scheme)
+			continue
+		}
+		e.warn(fmt.Sprintf("servers[%d]", i+1), "only one host and base path can be represented, dropped '%s'", u)
+	}
+}
+
+// serverURL substitutes server variables with their default values.
+func (e *exporter) serverURL(location string, server *openapi.Server) *openapi.URL {
+	if server.URL == nil {
+		return openapi.MustParseURL("")
+	}
+	if len(server.Variables) == 0 {
+		return server.URL
+	}
+
+	e.warn(location, "server variables are not supported, using their default values")
+	raw := server.URL.String()
+	for name, variable := range server.Variables {
+		raw = strings.ReplaceAll(raw, "%7B"+name+"%7D", variable.Default)
+		raw = strings.ReplaceAll(raw, "{"+name+"}", variable.Default)
+	}
+	u, err := parseURL(raw)
+	if err != nil {
+		e.warn(location, "invalid server url '%s': %v", raw, err)
+		return openapi.MustParseURL("")
+	}
+	return u
+}
+
+func (e *exporter) components(doc *Swagger) {
+	components := e.spec.Components
+	if components == nil {
+		return
+	}
+
+	if len(components.Schemas) > 0 {
+		doc.Definitions = map[string]*openapi.SchemaOrRef{}
+		for _, name := range sortedKeys(components.Schemas) {
+			doc.Definitions[name] = e.schema("components.schemas."+name, components.Schemas[name])
+		}
+	}
+
+	if len(components.Parameters) > 0 || len(components.RequestBodies) > 0 {
+		doc.Parameters = map[string]*Parameter{}
+	}
+	for _, name := range sortedKeys(components.Parameters) {
+		if param := e.parameter("components.parameters."+name, components.Parameters[name]); param != nil {
+			doc.Parameters[name] = param
+		}
+	}
+	for _, name := range sortedKeys(components.RequestBodies) {
+		location := "components.requestBodies." + name
+		body := components.RequestBodies[name]
+		if isFormBody(body) {
+			// expanded into formData parameters where it is used
+			continue
+		}
+		params, _ := e.requestBody(location, body)
+		if len(params) != 1 {
+			continue
+		}
+		exported := name
+		for i := 1; doc.Parameters[exported] != nil || components.Parameters[exported] != nil; i++ {
+			exported = name + "Body"
+			if i > 1 {
+				exported += strconv.Itoa(i)
+			}
+		}
+		if exported != name {
+			e.warn(location, "conflicts with a parameter of the same name, exported as '%s'", exported)
+		}
+		doc.Parameters[exported] = params[0]
+		e.bodies[name] = exported
+	}
+
+	if len(components.Responses) > 0 {
+		doc.Responses = map[string]*Response{}
+		for _, name := range sortedKeys(components.Responses) {
+			doc.Responses[name], _ = e.response("components.responses."+name, components.Responses[name])
+		}
+	}
+
+	if len(components.SecuritySchemes) > 0 {
+		doc.SecurityDefinitions = map[string]*SecurityScheme{}
+		for _, name := range sortedKeys(components.SecuritySchemes) {
+			e.securityScheme(doc, name, components.SecuritySchemes[name])
+		}
+	}
+
+	for _, name := range sortedKeys(components.Headers) {
+		e.warn("components.headers."+name, "shared headers are not supported, dropped")
+	}
+	for _, name := range sortedKeys(components.Examples) {
+		e.warn("components.examples."+name, "shared examples are not supported, dropped")
+	}
+	for _, name := range sortedKeys(components.Links) {
+		e.warn("components.links."+name, "links are not supported, dropped")
+	}
+	for _, name := range sortedKeys(components.Callbacks) {
+		e.warn("components.callbacks."+name, "callbacks are not supported, dropped")
+	}
+}
+
+func (e *exporter) securityScheme(doc *Swagger, name string, ss *openapi.SecuritySchemeOrRef) {
+	location := "components.securitySchemes." + name
+	if ss.IsRef() {
+		e.warn(location, "references to security schemes are not supported, dropped")
+		return
+	}
+
+	switch ss.Type {
+	case "http":
+		switch strings.ToLower(ss.Scheme) {
+		case "basic":
+			doc.SecurityDefinitions[name] = &SecurityScheme{Type: "basic", Description: ss.Description}
+		case "bearer":
+			e.warn(location, "bearer authentication is approximated by an apiKey in the Authorization header")
+			doc.SecurityDefinitions[name] = &SecurityScheme{
+				Type:        "apiKey",
+				Description: ss.Description,
+				Name:        "Authorization",
+				In:          "header",
+			}
+		default:
+			e.warn(location, "http scheme '%s' is not supported, dropped", ss.Scheme)
+		}
+	case "apiKey":
+		if ss.In == "cookie" {
+			e.warn(location, "apiKey in cookie is not supported, dropped")
+			return
+		}
+		doc.SecurityDefinitions[name] = &SecurityScheme{
+			Type:        "apiKey",
+			Description: ss.Description,
+			Name:        ss.Name,
+			In:          ss.In,
+		}
+	case "oauth2":
+		if ss.Flows == nil {
+			e.warn(location, "oauth2 without flows, dropped")
+			return
+		}
+		var flows []struct {
+			name string
+			flow *openapi.OAuthFlow
+		}
+		for _, f := range []struct {
+			name string
+			flow *openapi.OAuthFlow
+		}{
+			{"implicit", ss.Flows.Implicit},
+			{"password", ss.Flows.Password},
+			{"application", ss.Flows.ClientCredentials},
+			{"accessCode", ss.Flows.AuthorizationCode},
+		} {
+			if f.flow != nil {
+				flows = append(flows, f)
+			}
+		}
+		if len(flows) > 1 {
+			e.warn(location, "only one oauth2 flow per scheme is supported, using '%s'", flows[0].name)
+		}
+		if len(flows) == 0 {
+			e.warn(location, "oauth2 without flows, dropped")
+			return
+		}
+		doc.SecurityDefinitions[name] = &SecurityScheme{
+			Type:             "oauth2",
+			Description:      ss.Description,
+			Flow:             flows[0].name,
+			AuthorizationURL: flows[0].flow.AuthorizationURL,
+			TokenURL:         flows[0].flow.TokenURL,
+			Scopes:           flows[0].flow.Scopes,
+		}
+	default:
+		e.warn(location, "security scheme type '%s' is not supported, dropped", ss.Type)
+	}
+}
+
+func (e *exporter) pathItem(location string, item *openapi.PathItem) *PathItem {
+	converted := &PathItem{Ref: exportRef(item.Ref)}
+	for i, param := range item.Parameters {
+		if p := e.parameter(fmt.Sprintf("%s.parameters[%d]", location, i), param); p != nil {
+			converted.Parameters = append(converted.Parameters, p)
+		}
+	}
+	if len(item.Servers) > 0 {
+		e.warn(location+".servers", "path level servers are not supported, dropped")
+	}
+
+	for _, op := range []struct {
+		method string
+		src    *openapi.Operation
+		dst    **Operation
+	}{
+		{"get", item.Get, &converted.Get},
+		{"put", item.Put, &converted.Put},
+		{"post", item.Post, &converted.Post},
+		{"delete", item.Delete, &converted.Delete},
+		{"options", item.Options, &converted.Options},
+		{"head", item.Head, &converted.Head},
+		{"patch", item.Patch, &converted.Patch},
+	} {
+		if op.src != nil {
+			*op.dst = e.operation(location+"."+op.method, op.src)
+		}
+	}
+	if item.Trace != nil {
+		e.warn(location+".trace", "trace operations are not supported, dropped")
+	}
+	return converted
+}
+
+func (e *exporter) operation(location string, op *openapi.Operation) *Operation {
+	converted := &Operation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: op.ExternalDocs,
+		OperationID:  op.OperationID,
+		Deprecated:   op.Deprecated,
+	}
+	if op.Security != nil {
+		converted.Security = make(SecurityRequirements, 0, len(op.Security))
+	}
+	for _, req := range op.Security {
+		if req != nil {
+			converted.Security = append(converted.Security, *req)
+		}
+	}
+
+	for i, param := range op.Parameters {
+		if p := e.parameter(fmt.Sprintf("%s.parameters[%d]", location, i), param); p != nil {
+			converted.Parameters = append(converted.Parameters, p)
+		}
+	}
+
+	if op.RequestBody != nil {
+		params, consumes := e.requestBody(location+".requestBody", op.RequestBody)
+		converted.Parameters = append(converted.Parameters, params...)
+		converted.Consumes = consumes
+	}
+
+	if op.Responses != nil {
+		converted.Responses = map[string]*Response{}
+		for _, code := range sortedKeys(*op.Responses) {
+			res, produces := e.response(location+".responses."+code, (*op.Responses)[code])
+			converted.Responses[code] = res
+			converted.Produces = appendUnique(converted.Produces, produces...)
+		}
+	}
+
+	if len(op.Callbacks) > 0 {
+		e.warn(location+".callbacks", "callbacks are not supported, dropped")
+	}
+	if len(op.Servers) > 0 {
+		e.warn(location+".servers", "operation level servers are not supported, dropped")
+	}
+	return converted
+}
+
+func (e *exporter) parameter(location string, param *openapi.ParameterOrRef) *Parameter {
+	if param.IsRef() {
+		resolved := e.spec.ResolveParameter(param)
+		if resolved != nil && (resolved.In == "cookie" || len(resolved.Content) > 0) {
+			// the component is not exported either, drop it as if inline
+			return e.parameter(location, resolved)
+		}
+		return &Parameter{Ref: exportRef(param.Ref)}
+	}
+
+	if param.In == "cookie" {
+		e.warn(location, "cookie parameters are not supported, dropped '%s'", param.Name)
+		return nil
+	}
+	if len(param.Content) > 0 {
+		e.warn(location, "parameter content is not supported, dropped '%s'", param.Name)
+		return nil
+	}
+
+	converted := &Parameter{
+		Name:            param.Name,
+		In:              param.In,
+		Description:     param.Description,
+		Required:        param.Required,
+		AllowEmptyValue: param.AllowEmptyValue,
+		Items:           e.items(location+".schema", param.Schema),
+	}
+	if converted.Type == "array" {
+		converted.CollectionFormat = e.collectionFormat(location, param)
+	}
+	return converted
+}
+
+// collectionFormat maps `style` and `explode` onto a 2.0 `collectionFormat`.
+func (e *exporter) collectionFormat(location string, param *openapi.ParameterOrRef) string {
+	style := param.Style
+	if style == "" {
+		style = "simple"
+		if param.In == "query" {
+			style = "form"
+		}
+	}
+	explode := style == "form"
+	if param.Explode != nil {
+		explode = *param.Explode
+	}
+
+	switch style {
+	case "form":
+		if explode {
+			return "multi"
+		}
+		return "csv"
+	case "simple":
+		return "csv"
+	case "spaceDelimited":
+		return "ssv"
+	case "pipeDelimited":
+		return "pipes"
+	default:
+		e.warn(location, "style '%s' is not supported, using csv", style)
+		return "csv"
+	}
+}
+
+// items converts the schema of a non-body parameter or header.
+func (e *exporter) items(location string, schema *openapi.SchemaOrRef) Items {
+	schema = e.spec.ResolveSchema(schema)
+	if schema == nil {
+		return Items{Type: "string"}
+	}
+
+	if schema.Type == "" || schema.Type == "object" {
+		e.warn(location, "only primitive and array schemas are supported, using string")
+		return Items{Type: "string"}
+	}
+
+	items := Items{
+		Type:             schema.Type,
+		Format:           schema.Format,
+		Default:          schema.Default,
+		Maximum:          schema.Maximum,
+		ExclusiveMaximum: schema.ExclusiveMaximum,
+		Minimum:          schema.Minimum,
+		ExclusiveMinimum: schema.ExclusiveMinimum,
+		MaxLength:        schema.MaxLength,
+		MinLength:        schema.MinLength,
+		Pattern:          schema.Pattern,
+		MaxItems:         schema.MaxItems,
+		MinItems:         schema.MinItems,
+		UniqueItems:      schema.UniqueItems,
+		Enum:             schema.Enum,
+		MultipleOf:       schema.MultipleOf,
+	}
+	if schema.Type == "array" && schema.Items != nil {
+		inner := e.items(location+".items", schema.Items)
+		items.Items = &inner
+	}
+	return items
+}
+
+func (e *exporter) requestBody(location string, body *openapi.RequestBodyOrRef) (params []*Parameter, consumes []string) {
+	if body.IsRef() {
+		resolved := e.spec.ResolveRequestBody(body)
+		if resolved == nil {
+			e.warn(location, "unresolved reference '%s', dropped", body.Ref)
+			return nil, nil
+		}
+		if exported, ok := e.bodies[openapi.UnescapePointer(strings.TrimPrefix(body.Ref, "#/components/requestBodies/"))]; ok {
+			return []*Parameter{{Ref: "#/parameters/" + openapi.EscapePointer(exported)}}, sortedKeys(resolved.Content)
+		}
+		// form bodies and those not exported are inlined
+		body = resolved
+	}
+
+	consumes = sortedKeys(body.Content)
+	if len(consumes) == 0 {
+		return nil, nil
+	}
+
+	if isFormBody(body) {
+		for _, mime := range consumes {
+			if mime != mimeForm && mime != mimeMultipart {
+				e.warn(location, "form data cannot be mixed with '%s', dropped", mime)
+			}
+		}
+		return e.formData(location, body.Content[preferredMediaType(body.Content, mimeMultipart, mimeForm)]), consumes
+	}
+
+	mime := preferredMediaType(body.Content, mimeJSON)
+	for _, other := range consumes {
+		if other != mime && !sameSchema(body.Content[other].Schema, body.Content[mime].Schema) {
+			e.warn(location, "only one body schema is supported, using the one of '%s'", mime)
+			break
+		}
+	}
+	return []*Parameter{{
+		Name:        "body",
+		In:          "body",
+		Description: body.Description,
+		Required:    body.Required,
+		Schema:      e.schema(location+".content."+mime+".schema", body.Content[mime].Schema),
+	}}, consumes
+}
+
+func (e *exporter) formData(location string, media *openapi.MediaType) []*Parameter {
+	schema := e.spec.ResolveSchema(media.Schema)
+	if schema == nil || len(schema.Properties) == 0 {
+		e.warn(location, "form data without properties, dropped")
+		return nil
+	}
+
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	var params []*Parameter
+	for _, name := range sortedKeys(schema.Properties) {
+		prop := e.spec.ResolveSchema(schema.Properties[name])
+		param := &Parameter{
+			Name:     name,
+			In:       "formData",
+			Required: required[name],
+		}
+		if prop != nil && prop.Type == "string" && prop.Format == "binary" {
+			param.Type = "file"
+			param.Description = prop.Description
+		} else {
+			param.Items = e.items(location+".properties."+name, prop)
+			if prop != nil {
+				param.Description = prop.Description
+			}
+			if param.Type == "array" {
+				param.CollectionFormat = "multi"
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func (e *exporter) response(location string, res *openapi.ResponseOrRef) (_ *Response, produces []string) {
+	if res.IsRef() {
+		if resolved := e.spec.ResolveResponse(res); resolved != nil {
+			produces = sortedKeys(resolved.Content)
+		}
+		return &Response{Ref: exportRef(res.Ref)}, produces
+	}
+
+	converted := &Response{Description: res.Description}
+
+	for _, name := range sortedKeys(res.Headers) {
+		header := res.Headers[name]
+		if header.IsRef() {
+			e.warn(location+".headers."+name, "references to headers are not supported, dropped")
+			continue
+		}
+		if converted.Headers == nil {
+			converted.Headers = map[string]*Header{}
+		}
+		converted.Headers[name] = &Header{
+			Description: header.Description,
+			Items:       e.items(location+".headers."+name+".schema", header.Schema),
+		}
+	}
+
+	produces = sortedKeys(res.Content)
+	if len(produces) > 0 {
+		mime := preferredMediaType(res.Content, mimeJSON)
+		for _, other := range produces {
+			if other != mime && !sameSchema(res.Content[other].Schema, res.Content[mime].Schema) {
+				e.warn(location, "only one response schema is supported, using the one of '%s'", mime)
+				break
+			}
+		}
+		converted.Schema = e.schema(location+".content."+mime+".schema", res.Content[mime].Schema)
+
+		for _, mime := range produces {
+			if example := res.Content[mime].Example; example != nil {
+				if converted.Examples == nil {
+					converted.Examples = map[string]openapi.Any{}
+				}
+				converted.Examples[mime] = example
+			}
+			if len(res.Content[mime].Examples) > 0 {
+				e.warn(location+".content."+mime+".examples", "named examples are not supported, dropped")
+			}
+		}
+	}
+
+	if len(res.Links) > 0 {
+		e.warn(location+".links", "links are not supported, dropped")
+	}
+	return converted, produces
+}
+
+// schema rewrites references and drops keywords unknown to Swagger 2.0.
+func (e *exporter) schema(location string, schema *openapi.SchemaOrRef) *openapi.SchemaOrRef {
+	if schema == nil {
+		return nil
+	}
+	converted := *schema
+	converted.Ref = exportRef(schema.Ref)
+
+	if len(schema.OneOf) > 0 {
+		e.warn(location, "oneOf is not supported, dropped")
+		converted.OneOf = nil
+	}
+	if len(schema.AnyOf) > 0 {
+		e.warn(location, "anyOf is not supported, dropped")
+		converted.AnyOf = nil
+	}
+	if schema.Not != nil {
+		e.warn(location, "not is not supported, dropped")
+		converted.Not = nil
+	}
+	if schema.Nullable {
+		e.warn(location, "nullable is not supported, dropped")
+		converted.Nullable = false
+	}
+	if schema.WriteOnly {
+		e.warn(location, "writeOnly is not supported, dropped")
+		converted.WriteOnly = false
+	}
+	if schema.Deprecated {
+		e.warn(location, "deprecated schemas are not supported, dropped")
+		converted.Deprecated = false
+	}
+	if schema.Discriminator != nil {
+		e.warn(location, "discriminator objects are not supported, dropped")
+		converted.Discriminator = nil
+	}
+
+	if schema.Properties != nil {
+		converted.Properties = map[string]*openapi.SchemaOrRef{}
+		for _, name := range sortedKeys(schema.Properties) {
+			converted.Properties[name] = e.schema(location+".properties."+name, schema.Properties[name])
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		converted.AdditionalProperties = &openapi.SchemaOrBool{
+			Bool:   schema.AdditionalProperties.Bool,
+			Schema: e.schema(location+".additionalProperties", schema.AdditionalProperties.Schema),
+		}
+	}
+	converted.Items = e.schema(location+".items", schema.Items)
+	if schema.AllOf != nil {
+		converted.AllOf = make([]*openapi.SchemaOrRef, len(schema.AllOf))
+		for i, s := range schema.AllOf {
+			converted.AllOf[i] = e.schema(fmt.Sprintf("%s.allOf[%d]", location, i), s)
+		}
+	}
+	return &converted
+}
+
+// commonMediaTypes hoists media types shared by all operations to the top
+// level and removes them from the operations.
+func (e *exporter) commonMediaTypes(doc *Swagger, field func(op *Operation) *[]string) []string {
+	var (
+		common []string
+		first  = true
+	)
+	eachOperation(doc, func(op *Operation) {
+		mimes := *field(op)
+		if len(mimes) == 0 {
+			return
+		}
+		if first {
+			common, first = mimes, false
+			return
+		}
+		if !sameStrings(common, mimes) {
+			common = nil
+		}
+	})
+	if len(common) == 0 {
+		return nil
+	}
+
+	eachOperation(doc, func(op *Operation) {
+		*field(op) = nil
+	})
+	return common
+}
+
+func eachOperation(doc *Swagger, f func(op *Operation)) {
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		for _, op := range []*Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch} {
+			if op != nil {
+				f(op)
+			}
+		}
+	}
+}
+
+func isFormBody(body *openapi.RequestBodyOrRef) bool {
+	if body == nil {
+		return false
+	}
+	for mime := range body.Content {
+		if mime == mimeForm || mime == mimeMultipart {
+			return true
+		}
+	}
+	return false
+}
+
+func preferredMediaType(content map[string]*openapi.MediaType, preferred ...string) string {
+	for _, mime := range preferred {
+		if _, ok := content[mime]; ok {
+			return mime
+		}
+	}
+	return sortedKeys(content)[0]
+}
+
+func sameSchema(a, b *openapi.SchemaOrRef) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range s {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s = append(s, v)
+		}
+	}
+	sort.Strings(s)
+	return s
+}
+
+func exportRef(ref string) string {
+	for from, to := range map[string]string{
+		"#/components/schemas/":    "#/definitions/",
+		"#/components/parameters/": "#/parameters/",
+		"#/components/responses/":  "#/responses/",
+	} {
+		if strings.HasPrefix(ref, from) {
+			return to + strings.TrimPrefix(ref, from)
+		}
+	}
+	return ref
+}
+
+func parseURL(raw string) (*openapi.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &openapi.URL{URL: u}, nil
+}
+
+// sortedKeys returns the keys of a map with string keys in order, so that
+// warnings are reported deterministically.
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,247 @@
+package swagger2
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFromOpenAPI(t *testing.T) {
+	r := strings.NewReader(`
+openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+- url: https://api.example.com/v1
+- url: https://staging.example.com/v1
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        "201":
+          description: created
+components:
+  schemas:
+    Pet:
+      oneOf:
+      - $ref: '#/components/schemas/Cat'
+      - $ref: '#/components/schemas/Dog'
+    Cat:
+      type: object
+    Dog:
+      type: object
+`)
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(r).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, warnings := FromOpenAPI(&spec)
+
+	if got.Host != "api.example.com" || got.BasePath != "/v1" {
+		t.Errorf("Host, BasePath = %s, %s, want api.example.com, /v1", got.Host, got.BasePath)
+	}
+
+	params := got.Paths["/pets"].Post.Parameters
+	if len(params) != 1 || params[0].In != "body" || params[0].Schema.Ref != "#/definitions/Pet" {
+		t.Errorf("Parameters = %+v, want a body parameter referring #/definitions/Pet", params)
+	}
+
+	wantWarnings := []string{
+		"servers[1]",
+		"components.schemas.Pet",
+	}
+	if len(warnings) != len(wantWarnings) {
+		t.Fatalf("warnings = %v, want %d warnings", warnings, len(wantWarnings))
+	}
+	for _, location := range wantWarnings {
+		found := false
+		for _, w := range warnings {
+			if w.Location == location {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no warning for %s in %v", location, warnings)
+		}
+	}
+}
+
+func TestFromOpenAPI_RequestBodies(t *testing.T) {
+	r := strings.NewReader(`
+openapi: 3.0.0
+paths:
+  /pets:
+    post:
+      parameters:
+      - $ref: '#/components/parameters/Pet'
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+    put:
+      requestBody:
+        $ref: '#/components/requestBodies/Empty'
+components:
+  parameters:
+    Pet:
+      name: pet
+      in: query
+      schema:
+        type: string
+  requestBodies:
+    Pet:
+      content:
+        application/json:
+          schema:
+            type: object
+    Empty:
+      description: nothing to convert
+`)
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(r).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, warnings := FromOpenAPI(&spec)
+
+	if p := got.Parameters["Pet"]; p == nil || p.In != "query" {
+		t.Errorf("Parameters[Pet] = %+v, want the query parameter", p)
+	}
+	if p := got.Parameters["PetBody"]; p == nil || p.In != "body" {
+		t.Errorf("Parameters[PetBody] = %+v, want the request body", p)
+	}
+	params := got.Paths["/pets"].Post.Parameters
+	if len(params) != 2 || params[0].Ref != "#/parameters/Pet" || params[1].Ref != "#/parameters/PetBody" {
+		t.Errorf("Parameters = %+v, want references to Pet and PetBody", params)
+	}
+	if _, ok := got.Parameters["Empty"]; ok {
+		t.Errorf("Parameters[Empty] is exported")
+	}
+	for _, p := range got.Paths["/pets"].Put.Parameters {
+		if p.Ref != "" {
+			t.Errorf("Put.Parameters refer %s, which is not exported", p.Ref)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Location != "components.requestBodies.Pet" {
+		t.Errorf("warnings = %v, want one for components.requestBodies.Pet", warnings)
+	}
+}
+
+func TestFromOpenAPI_EmptySecurity(t *testing.T) {
+	r := strings.NewReader(`
+openapi: 3.0.0
+security:
+- key: []
+paths:
+  /health:
+    get:
+      security: []
+      responses:
+        "200":
+          description: ok
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+components:
+  securitySchemes:
+    key:
+      type: apiKey
+      in: header
+      name: X-Key
+`)
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(r).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	exported, _ := FromOpenAPI(&spec)
+	b, err := yaml.Marshal(exported)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var doc Swagger
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	got, err := ToOpenAPI(&doc)
+	if err != nil {
+		t.Fatalf("ToOpenAPI() error = %v", err)
+	}
+
+	if security := got.Paths["/health"].Get.Security; security == nil || len(security) != 0 {
+		t.Errorf("Paths[/health].Get.Security = %v, want an empty list", security)
+	}
+	if security := got.Paths["/pets"].Get.Security; security != nil {
+		t.Errorf("Paths[/pets].Get.Security = %v, want nil", security)
+	}
+}
+
+func TestFromOpenAPI_UnsupportedParameters(t *testing.T) {
+	r := strings.NewReader(`
+openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+      - $ref: '#/components/parameters/Session'
+      - $ref: '#/components/parameters/Filter'
+      - $ref: '#/components/parameters/Limit'
+components:
+  parameters:
+    Session:
+      name: session
+      in: cookie
+      schema:
+        type: string
+    Filter:
+      name: filter
+      in: query
+      content:
+        application/json:
+          schema:
+            type: object
+    Limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+`)
+
+	var spec openapi.OpenAPI
+	if err := yaml.NewDecoder(r).Decode(&spec); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, warnings := FromOpenAPI(&spec)
+
+	if len(got.Parameters) != 1 || got.Parameters["Limit"] == nil {
+		t.Errorf("Parameters = %v, want Limit only", got.Parameters)
+	}
+	params := got.Paths["/pets"].Get.Parameters
+	if len(params) != 1 || params[0].Ref != "#/parameters/Limit" {
+		t.Errorf("Get.Parameters = %+v, want a reference to Limit only", params)
+	}
+	dropped := 0
+	for _, w := range warnings {
+		if strings.HasPrefix(w.Location, "paths./pets.get.parameters") {
+			dropped++
+		}
+	}
+	if dropped != 2 {
+		t.Errorf("warnings = %v, want two for the parameters of the operation", warnings)
+	}
+}
@@ -0,0 +1,537 @@
+package swagger2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+const (
+	openAPIVersion = "3.0.0"
+
+	mimeJSON      = "application/json"
+	mimeForm      = "application/x-www-form-urlencoded"
+	mimeMultipart = "multipart/form-data"
+)
+
+// ToOpenAPI converts a Swagger 2.0 document into the OpenAPI 3.0 model.
+func ToOpenAPI(doc *Swagger) (*openapi.OpenAPI, error) {
+	c := &importer{doc: doc}
+
+	components, err := c.components()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := openapi.Paths{}
+	for path, item := range doc.Paths {
+		pathItem, err := c.pathItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("paths.%s: %v", path, err)
+		}
+		paths[path] = pathItem
+	}
+
+	servers, err := c.servers(doc.Schemes)
+	if err != nil {
+		return nil, err
+	}
+
+	info := doc.Info
+	if info == nil {
+		info = &openapi.Info{}
+	}
+
+	return &openapi.OpenAPI{
+		Version:    openAPIVersion,
+		Info:       info,
+		Servers:    servers,
+		Paths:      paths,
+		Components: components,
+		Security:   doc.Security,
+		Tags:       doc.Tags,
+	}, nil
+}
+
+type importer struct {
+	doc *Swagger
+}
+
+func (c *importer) servers(schemes []string) ([]*openapi.Server, error) {
+	if c.doc.Host == "" && c.doc.BasePath == "" {
+		return nil, nil
+	}
+	if c.doc.Host == "" {
+		// without a host the base path is relative to the document
+		u, err := parseURL(c.doc.BasePath)
+		if err != nil {
+			return nil, fmt.Errorf("basePath: %v", err)
+		}
+		return []*openapi.Server{{URL: u}}, nil
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	servers := make([]*openapi.Server, 0, len(schemes))
+	for _, scheme := range schemes {
+		u, err := parseURL(fmt.Sprintf("%s://%s%s", scheme, c.doc.Host, c.doc.BasePath))
+		if err != nil {
+			return nil, fmt.Errorf("host and basePath: %v", err)
+		}
+		servers = append(servers, &openapi.Server{URL: u})
+	}
+	return servers, nil
+}
+
+func (c *importer) components() (*openapi.Components, error) {
+	components := &openapi.Components{
+		Schemas:         map[string]*openapi.SchemaOrRef{},
+		Responses:       map[string]*openapi.ResponseOrRef{},
+		Parameters:      map[string]*openapi.ParameterOrRef{},
+		RequestBodies:   map[string]*openapi.RequestBodyOrRef{},
+		SecuritySchemes: map[string]*openapi.SecuritySchemeOrRef{},
+	}
+
+	for name, schema := range c.doc.Definitions {
+		components.Schemas[name] = convertSchema(schema)
+	}
+
+	for name, param := range c.doc.Parameters {
+		switch param.In {
+		case "body":
+			components.RequestBodies[name] = c.requestBody([]*Parameter{param}, c.doc.Consumes)
+		case "formData":
+			// form parameters cannot be shared on their own in 3.0, so they
+			// are expanded into every operation that references them.
+		default:
+			components.Parameters[name] = convertParameter(param)
+		}
+	}
+
+	for name, res := range c.doc.Responses {
+		components.Responses[name] = c.response(res, c.doc.Produces)
+	}
+
+	for name, ss := range c.doc.SecurityDefinitions {
+		scheme, err := convertSecurityScheme(ss)
+		if err != nil {
+			return nil, fmt.Errorf("securityDefinitions.%s: %v", name, err)
+		}
+		components.SecuritySchemes[name] = scheme
+	}
+
+	return components, nil
+}
+
+func (c *importer) pathItem(item *PathItem) (*openapi.PathItem, error) {
+	shared, body, err := c.splitParameters(item.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	pathItem := &openapi.PathItem{
+		Ref:        convertRef(item.Ref),
+		Parameters: shared,
+	}
+
+	for _, op := range []struct {
+		src *Operation
+		dst **openapi.Operation
+	}{
+		{item.Get, &pathItem.Get},
+		{item.Put, &pathItem.Put},
+		{item.Post, &pathItem.Post},
+		{item.Delete, &pathItem.Delete},
+		{item.Options, &pathItem.Options},
+		{item.Head, &pathItem.Head},
+		{item.Patch, &pathItem.Patch},
+	} {
+		if op.src == nil {
+			continue
+		}
+		converted, err := c.operation(op.src, body)
+		if err != nil {
+			return nil, err
+		}
+		*op.dst = converted
+	}
+
+	return pathItem, nil
+}
+
+func (c *importer) operation(op *Operation, inherited []*Parameter) (*openapi.Operation, error) {
+	params, body, err := c.splitParameters(op.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	body = mergeBodyParameters(inherited, body)
+
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = c.doc.Consumes
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = c.doc.Produces
+	}
+
+	converted := &openapi.Operation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: op.ExternalDocs,
+		OperationID:  op.OperationID,
+		Parameters:   params,
+		Deprecated:   op.Deprecated,
+	}
+	if op.Security != nil {
+		converted.Security = make(openapi.SecurityRequirements, 0, len(op.Security))
+	}
+	for i := range op.Security {
+		converted.Security = append(converted.Security, &op.Security[i])
+	}
+
+	if len(body) == 1 && body[0].Ref != "" {
+		name := strings.TrimPrefix(body[0].Ref, "#/parameters/")
+		converted.RequestBody = &openapi.RequestBodyOrRef{
+			Reference: openapi.Reference{Ref: "#/components/requestBodies/" + name},
+		}
+	} else if len(body) > 0 {
+		converted.RequestBody = c.requestBody(body, consumes)
+	}
+
+	if len(op.Responses) > 0 {
+		responses := openapi.Responses{}
+		for code, res := range op.Responses {
+			responses[code] = c.response(res, produces)
+		}
+		converted.Responses = &responses
+	}
+
+	if len(op.Schemes) > 0 {
+		if converted.Servers, err = c.servers(op.Schemes); err != nil {
+			return nil, err
+		}
+	}
+
+	return converted, nil
+}
+
+// splitParameters separates parameters which stay parameters in 3.0 from
+// `body` and `formData` ones, which become the request body. References to
+// shared body parameters are kept unresolved.
+func (c *importer) splitParameters(params []*Parameter) (_ []*openapi.ParameterOrRef, body []*Parameter, err error) {
+	var converted []*openapi.ParameterOrRef
+	for _, param := range params {
+		if param.Ref != "" {
+			name := strings.TrimPrefix(param.Ref, "#/parameters/")
+			shared, ok := c.doc.Parameters[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("unresolved parameter reference '%s'", param.Ref)
+			}
+			switch shared.In {
+			case "body":
+				body = append(body, param)
+			case "formData":
+				body = append(body, shared)
+			default:
+				converted = append(converted, &openapi.ParameterOrRef{
+					Reference: openapi.Reference{Ref: convertRef(param.Ref)},
+				})
+			}
+			continue
+		}
+
+		switch param.In {
+		case "body", "formData":
+			body = append(body, param)
+		default:
+			converted = append(converted, convertParameter(param))
+		}
+	}
+	return converted, body, nil
+}
+
+// mergeBodyParameters lets operation level parameters override path level
+// ones with the same name.
+func mergeBodyParameters(inherited, own []*Parameter) []*Parameter {
+	merged := append([]*Parameter{}, own...)
+	for _, param := range inherited {
+		overridden := false
+		for _, o := range own {
+			if o.Name == param.Name && o.In == param.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+func (c *importer) requestBody(params []*Parameter, consumes []string) *openapi.RequestBodyOrRef {
+	body := &openapi.RequestBodyOrRef{}
+
+	for _, param := range params {
+		if param.In == "body" || param.Ref != "" {
+			if len(consumes) == 0 {
+				consumes = []string{mimeJSON}
+			}
+			var schema *openapi.SchemaOrRef
+			if param.Ref != "" {
+				// shared body parameter referenced next to form data; inline it
+				name := strings.TrimPrefix(param.Ref, "#/parameters/")
+				param = c.doc.Parameters[name]
+			}
+			schema = convertSchema(param.Schema)
+
+			body.Description = param.Description
+			body.Required = param.Required
+			body.Content = map[string]*openapi.MediaType{}
+			for _, mime := range consumes {
+				body.Content[mime] = &openapi.MediaType{Schema: schema}
+			}
+			return body
+		}
+	}
+
+	// all remaining parameters are form data
+	schema := &openapi.SchemaOrRef{Schema: openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.SchemaOrRef{},
+	}}
+	multipart := false
+	for _, param := range params {
+		prop := itemsSchema(&param.Items)
+		prop.Description = param.Description
+		if param.Type == "file" {
+			prop.Type = "string"
+			prop.Format = "binary"
+			multipart = true
+		}
+		schema.Properties[param.Name] = prop
+		if param.Required {
+			schema.Required = append(schema.Required, param.Name)
+			body.Required = true
+		}
+	}
+	sort.Strings(schema.Required)
+
+	var mimes []string
+	for _, mime := range consumes {
+		if mime == mimeForm || mime == mimeMultipart {
+			mimes = append(mimes, mime)
+		}
+	}
+	if len(mimes) == 0 {
+		if multipart {
+			mimes = []string{mimeMultipart}
+		} else {
+			mimes = []string{mimeForm}
+		}
+	}
+
+	body.Content = map[string]*openapi.MediaType{}
+	for _, mime := range mimes {
+		body.Content[mime] = &openapi.MediaType{Schema: schema}
+	}
+	return body
+}
+
+func (c *importer) response(res *Response, produces []string) *openapi.ResponseOrRef {
+	if res.Ref != "" {
+		return &openapi.ResponseOrRef{Reference: openapi.Reference{Ref: convertRef(res.Ref)}}
+	}
+
+	converted := &openapi.ResponseOrRef{Response: openapi.Response{
+		Description: res.Description,
+	}}
+
+	if len(res.Headers) > 0 {
+		converted.Headers = map[string]*openapi.HeaderOrRef{}
+		for name, header := range res.Headers {
+			converted.Headers[name] = &openapi.HeaderOrRef{Header: openapi.Header{
+				Description: header.Description,
+				Schema:      itemsSchema(&header.Items),
+			}}
+		}
+	}
+
+	if res.Schema != nil {
+		if len(produces) == 0 {
+			produces = []string{mimeJSON}
+		}
+		schema := convertSchema(res.Schema)
+		converted.Content = map[string]*openapi.MediaType{}
+		for _, mime := range produces {
+			converted.Content[mime] = &openapi.MediaType{
+				Schema:  schema,
+				Example: res.Examples[mime],
+			}
+		}
+	}
+
+	return converted
+}
+
+func convertParameter(param *Parameter) *openapi.ParameterOrRef {
+	converted := &openapi.ParameterOrRef{Parameter: openapi.Parameter{
+		Name:            param.Name,
+		In:              param.In,
+		Description:     param.Description,
+		Required:        param.Required,
+		AllowEmptyValue: param.AllowEmptyValue,
+		Schema:          itemsSchema(&param.Items),
+	}}
+
+	if param.Type == "array" {
+		converted.Style, converted.Explode = collectionStyle(param.In, param.CollectionFormat)
+	}
+	return converted
+}
+
+// collectionStyle maps a 2.0 `collectionFormat` onto `style` and `explode`.
+func collectionStyle(in, format string) (style string, explode *bool) {
+	no, yes := false, true
+	switch format {
+	case "ssv":
+		return "spaceDelimited", &no
+	case "pipes":
+		return "pipeDelimited", &no
+	case "multi":
+		return "form", &yes
+	default: // csv
+		if in == "query" || in == "cookie" {
+			return "form", &no
+		}
+		return "simple", &no
+	}
+}
+
+func itemsSchema(items *Items) *openapi.SchemaOrRef {
+	if items == nil {
+		return nil
+	}
+	return &openapi.SchemaOrRef{Schema: openapi.Schema{
+		Type:             items.Type,
+		Format:           items.Format,
+		Items:            itemsSchema(items.Items),
+		Default:          items.Default,
+		Maximum:          items.Maximum,
+		ExclusiveMaximum: items.ExclusiveMaximum,
+		Minimum:          items.Minimum,
+		ExclusiveMinimum: items.ExclusiveMinimum,
+		MaxLength:        items.MaxLength,
+		MinLength:        items.MinLength,
+		Pattern:          items.Pattern,
+		MaxItems:         items.MaxItems,
+		MinItems:         items.MinItems,
+		UniqueItems:      items.UniqueItems,
+		Enum:             items.Enum,
+		MultipleOf:       items.MultipleOf,
+	}}
+}
+
+// convertSchema rewrites references inside a schema to their 3.0 location.
+// Swagger 2.0 schemas are otherwise a subset of the 3.0 ones.
+func convertSchema(schema *openapi.SchemaOrRef) *openapi.SchemaOrRef {
+	if schema == nil {
+		return nil
+	}
+	converted := *schema
+	converted.Ref = convertRef(schema.Ref)
+	if schema.Type == "file" {
+		converted.Type = "string"
+		converted.Format = "binary"
+	}
+
+	if schema.Properties != nil {
+		converted.Properties = map[string]*openapi.SchemaOrRef{}
+		for name, prop := range schema.Properties {
+			converted.Properties[name] = convertSchema(prop)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		converted.AdditionalProperties = &openapi.SchemaOrBool{
+			Bool:   schema.AdditionalProperties.Bool,
+			Schema: convertSchema(schema.AdditionalProperties.Schema),
+		}
+	}
+	converted.Items = convertSchema(schema.Items)
+	converted.Not = convertSchema(schema.Not)
+	converted.AllOf = convertSchemas(schema.AllOf)
+	converted.OneOf = convertSchemas(schema.OneOf)
+	converted.AnyOf = convertSchemas(schema.AnyOf)
+	return &converted
+}
+
+func convertSchemas(schemas []*openapi.SchemaOrRef) []*openapi.SchemaOrRef {
+	if schemas == nil {
+		return nil
+	}
+	converted := make([]*openapi.SchemaOrRef, len(schemas))
+	for i, schema := range schemas {
+		converted[i] = convertSchema(schema)
+	}
+	return converted
+}
+
+func convertSecurityScheme(ss *SecurityScheme) (*openapi.SecuritySchemeOrRef, error) {
+	converted := &openapi.SecuritySchemeOrRef{SecurityScheme: openapi.SecurityScheme{
+		Description: ss.Description,
+	}}
+
+	switch ss.Type {
+	case "basic":
+		converted.Type = "http"
+		converted.Scheme = "basic"
+	case "apiKey":
+		converted.Type = "apiKey"
+		converted.Name = ss.Name
+		converted.In = ss.In
+	case "oauth2":
+		flow := &openapi.OAuthFlow{
+			AuthorizationURL: ss.AuthorizationURL,
+			TokenURL:         ss.TokenURL,
+			Scopes:           ss.Scopes,
+		}
+		if flow.Scopes == nil {
+			flow.Scopes = map[string]string{}
+		}
+		converted.Type = "oauth2"
+		converted.Flows = &openapi.OAuthFlows{}
+		switch ss.Flow {
+		case "implicit":
+			converted.Flows.Implicit = flow
+		case "password":
+			converted.Flows.Password = flow
+		case "application":
+			converted.Flows.ClientCredentials = flow
+		case "accessCode":
+			converted.Flows.AuthorizationCode = flow
+		default:
+			return nil, fmt.Errorf("unknown oauth2 flow '%s'", ss.Flow)
+		}
+	default:
+		return nil, fmt.Errorf("unknown security scheme type '%s'", ss.Type)
+	}
+	return converted, nil
+}
+
+func convertRef(ref string) string {
+	for from, to := range map[string]string{
+		"#/definitions/": "#/components/schemas/",
+		"#/parameters/":  "#/components/parameters/",
+		"#/responses/":   "#/components/responses/",
+	} {
+		if strings.HasPrefix(ref, from) {
+			return to + strings.TrimPrefix(ref, from)
+		}
+	}
+	return ref
+}
@@ -0,0 +1,165 @@
+package swagger2
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func TestToOpenAPI(t *testing.T) {
+	r := strings.NewReader(`
+swagger: "2.0"
+info:
+  title: Pets
+  version: 1.0.0
+host: api.example.com
+basePath: /v1
+schemes: [https]
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+      - name: pet
+        in: body
+        required: true
+        schema:
+          $ref: '#/definitions/Pet'
+      - name: tags
+        in: query
+        type: array
+        items:
+          type: string
+      responses:
+        "201":
+          description: created
+          schema:
+            $ref: '#/definitions/Pet'
+definitions:
+  Pet:
+    type: object
+    properties:
+      name:
+        type: string
+securityDefinitions:
+  basic:
+    type: basic
+`)
+
+	var doc Swagger
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, err := ToOpenAPI(&doc)
+	if err != nil {
+		t.Fatalf("ToOpenAPI() error = %v", err)
+	}
+
+	if len(got.Servers) != 1 || got.Servers[0].URL.String() != "https://api.example.com/v1" {
+		t.Errorf("Servers = %v, want [https://api.example.com/v1]", got.Servers)
+	}
+
+	op := got.Paths["/pets"].Post
+	if op == nil {
+		t.Fatalf("Paths[/pets].Post is nil")
+	}
+
+	wantBody := &openapi.RequestBodyOrRef{RequestBody: openapi.RequestBody{
+		Required: true,
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: &openapi.SchemaOrRef{
+				Reference: openapi.Reference{Ref: "#/components/schemas/Pet"},
+			}},
+		},
+	}}
+	if !reflect.DeepEqual(op.RequestBody, wantBody) {
+		t.Errorf("RequestBody = %+v, want %+v", op.RequestBody, wantBody)
+	}
+
+	if len(op.Parameters) != 1 {
+		t.Fatalf("len(Parameters) = %d, want 1", len(op.Parameters))
+	}
+	if param := op.Parameters[0]; param.Style != "form" || param.Explode == nil || *param.Explode {
+		t.Errorf("Parameters[0] style = %s, explode = %v, want form, false", param.Style, param.Explode)
+	}
+
+	res := (*op.Responses)["201"]
+	if ref := res.Content["application/json"].Schema.Ref; ref != "#/components/schemas/Pet" {
+		t.Errorf("Responses[201] schema ref = %s, want #/components/schemas/Pet", ref)
+	}
+
+	if ss := got.Components.SecuritySchemes["basic"]; ss.Type != "http" || ss.Scheme != "basic" {
+		t.Errorf("SecuritySchemes[basic] = %+v, want http basic", ss)
+	}
+}
+
+func TestToOpenAPI_InvalidServer(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  Swagger
+	}{
+		{"host", Swagger{Host: "api example.com", BasePath: "/v1"}},
+		{"basePath", Swagger{BasePath: "%zz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToOpenAPI(&tt.doc); err == nil {
+				t.Errorf("ToOpenAPI() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestToOpenAPI_EmptySecurity(t *testing.T) {
+	r := strings.NewReader(`
+swagger: "2.0"
+info:
+  title: Pets
+  version: 1.0.0
+security:
+- api_key: []
+paths:
+  /health:
+    get:
+      security: []
+      responses:
+        "200":
+          description: ok
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+`)
+
+	var doc Swagger
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	got, err := ToOpenAPI(&doc)
+	if err != nil {
+		t.Fatalf("ToOpenAPI() error = %v", err)
+	}
+
+	if security := got.Paths["/health"].Get.Security; security == nil || len(security) != 0 {
+		t.Errorf("Paths[/health].Get.Security = %v, want an empty list", security)
+	}
+	if security := got.Paths["/pets"].Get.Security; security != nil {
+		t.Errorf("Paths[/pets].Get.Security = %v, want nil", security)
+	}
+
+	b, err := yaml.Marshal(got.Paths["/health"].Get)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(b), "security: []") {
+		t.Errorf("Marshal() = %s, want security: []", b)
+	}
+}
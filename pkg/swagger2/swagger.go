@@ -0,0 +1,161 @@
+package swagger2
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+// Swagger ...
+type Swagger struct {
+	Swagger             string                          `json:"swagger,omitempty" yaml:"swagger,omitempty"`
+	Info                *openapi.Info                   `json:"info,omitempty" yaml:"info,omitempty"`
+	Host                string                          `json:"host,omitempty" yaml:"host,omitempty"`
+	BasePath            string                          `json:"basePath,omitempty" yaml:"basePath,omitempty"`
+	Schemes             []string                        `json:"schemes,omitempty" yaml:"schemes,omitempty"`
+	Consumes            []string                        `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+	Produces            []string                        `json:"produces,omitempty" yaml:"produces,omitempty"`
+	Paths               map[string]*PathItem            `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Definitions         map[string]*openapi.SchemaOrRef `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+	Parameters          map[string]*Parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Responses           map[string]*Response            `json:"responses,omitempty" yaml:"responses,omitempty"`
+	SecurityDefinitions map[string]*SecurityScheme      `json:"securityDefinitions,omitempty" yaml:"securityDefinitions,omitempty"`
+	Security            []openapi.SecurityRequirement   `json:"security,omitempty" yaml:"security,omitempty"`
+	Tags                []*openapi.Tag                  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ExternalDocs        *openapi.ExternalDocumentation  `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// PathItem ...
+type PathItem struct {
+	Ref        string       `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Get        *Operation   `json:"get,omitempty" yaml:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty" yaml:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty" yaml:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Parameters []*Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Operation ...
+type Operation struct {
+	Tags         []string                       `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary      string                         `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description  string                         `json:"description,omitempty" yaml:"description,omitempty"`
+	ExternalDocs *openapi.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	OperationID  string                         `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Consumes     []string                       `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+	Produces     []string                       `json:"produces,omitempty" yaml:"produces,omitempty"`
+	Parameters   []*Parameter                   `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Responses    map[string]*Response           `json:"responses,omitempty" yaml:"responses,omitempty"`
+	Schemes      []string                       `json:"schemes,omitempty" yaml:"schemes,omitempty"`
+	Deprecated   bool                           `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Security     SecurityRequirements           `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// SecurityRequirements are the requirements of an operation, where an empty
+// list opts out of those of the document.
+type SecurityRequirements []openapi.SecurityRequirement
+
+// IsZero tells YAML to write an empty list, and to omit nil only.
+func (s SecurityRequirements) IsZero() bool {
+	return s == nil
+}
+
+// Items describes the type of items in an array parameter or header.
+type Items struct {
+	Type             string        `json:"type,omitempty" yaml:"type,omitempty"`
+	Format           string        `json:"format,omitempty" yaml:"format,omitempty"`
+	Items            *Items        `json:"items,omitempty" yaml:"items,omitempty"`
+	CollectionFormat string        `json:"collectionFormat,omitempty" yaml:"collectionFormat,omitempty"`
+	Default          openapi.Any   `json:"default,omitempty" yaml:"default,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum bool          `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum bool          `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MaxLength        *uint64       `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinLength        *uint64       `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	Pattern          string        `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MaxItems         *uint64       `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems         *uint64       `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	UniqueItems      bool          `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	Enum             []openapi.Any `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MultipleOf       *float64      `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+}
+
+// Parameter ...
+type Parameter struct {
+	Ref             string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name            string               `json:"name,omitempty" yaml:"name,omitempty"`
+	In              string               `json:"in,omitempty" yaml:"in,omitempty"`
+	Description     string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Required        bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema          *openapi.SchemaOrRef `json:"schema,omitempty" yaml:"schema,omitempty"`
+	AllowEmptyValue bool                 `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
+
+	Items `yaml:",inline"`
+}
+
+// Response ...
+type Response struct {
+	Ref         string                 `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Schema      *openapi.SchemaOrRef   `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Headers     map[string]*Header     `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Examples    map[string]openapi.Any `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+// Header ...
+type Header struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	Items `yaml:",inline"`
+}
+
+// SecurityScheme ...
+type SecurityScheme struct {
+	Type             string            `json:"type,omitempty" yaml:"type,omitempty"`
+	Description      string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Name             string            `json:"name,omitempty" yaml:"name,omitempty"`
+	In               string            `json:"in,omitempty" yaml:"in,omitempty"`
+	Flow             string            `json:"flow,omitempty" yaml:"flow,omitempty"`
+	AuthorizationURL *openapi.URL      `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenURL         *openapi.URL      `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+// Load reads a Swagger 2.0 document. Files with a `.json` extension are
+// decoded as JSON, everything else as YAML.
+func Load(filename string) (_ *Swagger, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var doc Swagger
+	if filepath.Ext(filename) == ".json" {
+		err = json.NewDecoder(f).Decode(&doc)
+	} else {
+		err = yaml.NewDecoder(f).Decode(&doc)
+	}
+	if err != nil {
+		return
+	}
+	return &doc, nil
+}
+
+// DumpInOneFile ...
+func DumpInOneFile(output string, doc *Swagger) (err error) {
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return yaml.NewEncoder(f).Encode(doc)
+}
@@ -0,0 +1,214 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"github.com/cry999/gopenapi/pkg/router"
+)
+
+// errors reported by the middleware for requests which do not hit an
+// operation, or whose body is larger than allowed
+var (
+	ErrNotFound         = router.ErrNotFound
+	ErrMethodNotAllowed = router.ErrMethodNotAllowed
+	ErrBodyTooLarge     = errors.New("the request body is too large")
+)
+
+// DefaultMaxBodySize is the size of the largest request body read by the
+// middleware, unless set by WithMaxBodySize.
+const DefaultMaxBodySize = 10 << 20
+
+// ErrorHandler answers a request which failed validation. err is an *Error
+// for invalid requests, a *ResponseError for invalid responses, or one of
+// ErrNotFound, ErrMethodNotAllowed and ErrBodyTooLarge.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Option configures the middleware.
+type Option func(*middleware)
+
+// WithResponseValidation enables the validation of responses, which are
+// buffered until validated. It is meant for tests.
+func WithResponseValidation(enabled bool) Option {
+	return func(m *middleware) { m.responses = enabled }
+}
+
+// WithErrorHandler replaces DefaultErrorHandler.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(m *middleware) { m.errorHandler = h }
+}
+
+// WithMaxBodySize limits the size of request bodies, which are read in
+// memory to be validated. Larger bodies are reported as ErrBodyTooLarge. A
+// size of 0 or less removes the limit.
+func WithMaxBodySize(size int64) Option {
+	return func(m *middleware) { m.maxBodySize = size }
+}
+
+type middleware struct {
+	validator    *Validator
+	router       *router.Router
+	responses    bool
+	maxBodySize  int64
+	errorHandler ErrorHandler
+}
+
+// Middleware validates requests against the operations of spec before
+// passing them to the next handler. The decoded request is available to it
+// through InputFromContext.
+func Middleware(spec *openapi.OpenAPI, opts ...Option) func(http.Handler) http.Handler {
+	m := &middleware{
+		validator:    New(spec),
+		router:       router.New(spec.Paths, spec.Servers),
+		maxBodySize:  DefaultMaxBodySize,
+		errorHandler: DefaultErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serve(next, w, r)
+		})
+	}
+}
+
+func (m *middleware) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	match, err := m.router.Match(r)
+	if err != nil {
+		m.errorHandler(w, r, err)
+		return
+	}
+	op := match.Operation
+
+	var body *maxBytesBody
+	if m.maxBodySize > 0 && r.Body != nil && r.Body != http.NoBody {
+		body = &maxBytesBody{ReadCloser: http.MaxBytesReader(w, r.Body, m.maxBodySize), limit: m.maxBodySize}
+		r.Body = body
+	}
+	input, err := m.validator.Request(r, match.PathItem, op, match.Params)
+	if body != nil && body.exceeded {
+		m.errorHandler(w, r, ErrBodyTooLarge)
+		return
+	}
+	if err != nil {
+		m.errorHandler(w, r, err)
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), inputKey{}, input))
+
+	if !m.responses {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+	if rec.header.Get("Content-Type") == "" && rec.body.Len() > 0 {
+		// as net/http would do when writing the body
+		rec.header.Set("Content-Type", http.DetectContentType(rec.body.Bytes()))
+	}
+	if err := m.validator.Response(op, rec.status, rec.header, rec.body.Bytes()); err != nil {
+		m.errorHandler(w, r, err)
+		return
+	}
+	for name, values := range rec.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
+
+type inputKey struct{}
+
+// InputFromContext returns the request decoded by the middleware, if any.
+func InputFromContext(ctx context.Context) *Input {
+	input, _ := ctx.Value(inputKey{}).(*Input)
+	return input
+}
+
+// DefaultErrorHandler answers with an RFC 7807 problem detail listing the
+// violations.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status, detail := http.StatusBadRequest, err.Error()
+	var violations []*Violation
+	switch err := err.(type) {
+	case *Error:
+		status, detail, violations = err.Status(), "the request does not conform to the API", err.Violations
+	case *ResponseError:
+		status, detail, violations = err.Status(), "the response does not conform to the API", err.Violations
+	default:
+		switch err {
+		case ErrNotFound:
+			status, detail = http.StatusNotFound, fmt.Sprintf("no path matches '%s'", r.URL.Path)
+		case ErrMethodNotAllowed:
+			status, detail = http.StatusMethodNotAllowed, fmt.Sprintf("%s is not defined for '%s'", r.Method, r.URL.Path)
+		case ErrBodyTooLarge:
+			status = http.StatusRequestEntityTooLarge
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(&struct {
+		Title  string       `json:"title"`
+		Status int          `json:"status"`
+		Detail string       `json:"detail,omitempty"`
+		Errors []*Violation `json:"errors,omitempty"`
+	}{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Errors: violations,
+	})
+}
+
+// maxBytesBody tells a body larger than its limit, which
+// http.MaxBytesReader reports as any other error, from other failures.
+type maxBytesBody struct {
+	io.ReadCloser
+	read, limit int64
+	exceeded    bool
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// responseRecorder buffers a response until it is validated.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status, r.wroteHeader = status, true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
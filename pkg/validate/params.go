@@ -0,0 +1,287 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// parameter locations
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InCookie = "cookie"
+	InBody   = "body"
+)
+
+// Parameter decodes the raw value of a parameter according to its `style`,
+// `explode` and schema, and validates it. It returns whether the parameter
+// was present in the request.
+func (v *Validator) Parameter(r *http.Request, param *openapi.Parameter, pathParams map[string]string) (_ interface{}, present bool, violations []*Violation) {
+	fail := func(format string, args ...interface{}) (interface{}, bool, []*Violation) {
+		return nil, true, []*Violation{{
+			In:      param.In,
+			Name:    param.Name,
+			Message: fmt.Sprintf(format, args...),
+		}}
+	}
+
+	d := decoder{param: param, schema: v.spec.ResolveSchema(param.Schema), spec: v.spec}
+	raw, present := d.raw(r, pathParams)
+	if !present {
+		if param.Required {
+			return nil, false, []*Violation{{In: param.In, Name: param.Name, Message: "is required"}}
+		}
+		return nil, false, nil
+	}
+
+	var (
+		value interface{}
+		err   error
+	)
+	if len(param.Content) > 0 {
+		// parameters with content are serialized as a whole, e.g. as JSON
+		var mediaType string
+		for mediaType = range param.Content {
+			break
+		}
+		d.schema = v.spec.ResolveSchema(param.Content[mediaType].Schema)
+		if err = json.Unmarshal([]byte(first(raw)), &value); err != nil {
+			return fail("must be valid JSON: %v", err)
+		}
+	} else if value, err = d.decode(raw); err != nil {
+		return fail("%v", err)
+	}
+
+	for _, violation := range v.Value(d.schema, value, Request) {
+		violation.In, violation.Name = param.In, param.Name
+		violations = append(violations, violation)
+	}
+	return value, true, violations
+}
+
+type decoder struct {
+	spec   *openapi.OpenAPI
+	param  *openapi.Parameter
+	schema *openapi.SchemaOrRef
+}
+
+func (d *decoder) style() string {
+	if d.param.Style != "" {
+		return d.param.Style
+	}
+	switch d.param.In {
+	case InQuery, InCookie:
+		return "form"
+	default:
+		return "simple"
+	}
+}
+
+func (d *decoder) explode() bool {
+	if d.param.Explode != nil {
+		return *d.param.Explode
+	}
+	return d.style() == "form"
+}
+
+func (d *decoder) typ() string {
+	if d.schema == nil {
+		return "string"
+	}
+	if d.schema.Type == "" && len(d.schema.Properties) > 0 {
+		return "object"
+	}
+	return d.schema.Type
+}
+
+// raw extracts the serialized values of the parameter. Only exploded query
+// arrays and objects have several values.
+func (d *decoder) raw(r *http.Request, pathParams map[string]string) ([]string, bool) {
+	name := d.param.Name
+	switch d.param.In {
+	case InPath:
+		value, ok := pathParams[name]
+		return []string{value}, ok
+	case InHeader:
+		values := r.Header.Values(name)
+		return []string{strings.Join(values, ",")}, len(values) > 0
+	case InCookie:
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return nil, false
+		}
+		return []string{cookie.Value}, true
+	case InQuery:
+		query := r.URL.Query()
+		if d.typ() == "object" && (d.explode() || d.style() == "deepObject") {
+			return d.rawQueryObject(query)
+		}
+		values, ok := query[name]
+		return values, ok
+	}
+	return nil, false
+}
+
+// rawQueryObject collects exploded object properties as `key=value` pairs.
+func (d *decoder) rawQueryObject(query url.Values) ([]string, bool) {
+	var pairs []string
+	for key, values := range query {
+		prop := key
+		if d.style() == "deepObject" {
+			prefix := d.param.Name + "["
+			if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") {
+				continue
+			}
+			prop = key[len(prefix) : len(key)-1]
+		} else if _, ok := d.schema.Properties[key]; !ok {
+			continue
+		}
+		for _, value := range values {
+			pairs = append(pairs, prop+"="+value)
+		}
+	}
+	return pairs, len(pairs) > 0
+}
+
+func (d *decoder) decode(raw []string) (interface{}, error) {
+	style, explode := d.style(), d.explode()
+
+	switch d.typ() {
+	case "array":
+		var items []string
+		if d.param.In == InQuery && style == "form" && explode {
+			items = raw
+		} else {
+			items = d.split(first(raw), explode)
+		}
+		var itemSchema *openapi.SchemaOrRef
+		if d.schema != nil {
+			itemSchema = d.schema.Items
+		}
+		arr := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			value, err := coerce(d.spec.ResolveSchema(itemSchema), item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		return arr, nil
+
+	case "object":
+		var pairs []string
+		if d.param.In == InQuery && (explode || style == "deepObject") {
+			pairs = raw
+		} else if explode {
+			pairs = d.split(first(raw), true)
+		} else {
+			// non exploded objects alternate keys and values
+			parts := d.split(first(raw), false)
+			if len(parts)%2 != 0 {
+				return nil, fmt.Errorf("must be a list of key and value pairs")
+			}
+			for i := 0; i < len(parts); i += 2 {
+				pairs = append(pairs, parts[i]+"="+parts[i+1])
+			}
+		}
+		obj := map[string]interface{}{}
+		for _, pair := range pairs {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("must be a list of key=value pairs")
+			}
+			value, err := coerce(d.spec.ResolveSchema(d.schema.Properties[kv[0]]), kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s %v", kv[0], err)
+			}
+			obj[kv[0]] = value
+		}
+		return obj, nil
+
+	default:
+		value := first(raw)
+		switch style {
+		case "label":
+			value = strings.TrimPrefix(value, ".")
+		case "matrix":
+			value = strings.TrimPrefix(value, ";"+d.param.Name+"=")
+		}
+		return coerce(d.schema, value)
+	}
+}
+
+// split separates the items of a serialized array or exploded object.
+func (d *decoder) split(value string, explode bool) []string {
+	name := d.param.Name
+	switch d.style() {
+	case "label":
+		value = strings.TrimPrefix(value, ".")
+		if explode {
+			return splitNonEmpty(value, ".")
+		}
+	case "matrix":
+		if explode {
+			var items []string
+			for _, part := range splitNonEmpty(value, ";") {
+				items = append(items, strings.TrimPrefix(part, name+"="))
+			}
+			return items
+		}
+		value = strings.TrimPrefix(value, ";"+name+"=")
+	case "spaceDelimited":
+		return splitNonEmpty(value, " ")
+	case "pipeDelimited":
+		return splitNonEmpty(value, "|")
+	}
+	return splitNonEmpty(value, ",")
+}
+
+// coerce converts a serialized primitive into the type of its schema.
+func coerce(schema *openapi.SchemaOrRef, raw string) (interface{}, error) {
+	if schema == nil {
+		return raw, nil
+	}
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return n, nil
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+func splitNonEmpty(s, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, sep)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
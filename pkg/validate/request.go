@@ -0,0 +1,238 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// maxMemory is the memory used to parse multipart bodies, the rest is
+// stored on disk.
+const maxMemory = 32 << 20
+
+// Input is a request decoded according to its operation.
+type Input struct {
+	Path   map[string]interface{}
+	Query  map[string]interface{}
+	Header map[string]interface{}
+	Cookie map[string]interface{}
+
+	// MediaType is the media type of the request body, if any.
+	MediaType string
+	Body      interface{}
+}
+
+// Request decodes the parameters and the body of r and validates them
+// against the operation. pathParams holds the raw values of the templated
+// path segments. The body of r can be read again afterwards. The returned
+// error is an *Error when the request does not conform.
+func (v *Validator) Request(r *http.Request, item *openapi.PathItem, op *openapi.Operation, pathParams map[string]string) (*Input, error) {
+	input := &Input{
+		Path:   map[string]interface{}{},
+		Query:  map[string]interface{}{},
+		Header: map[string]interface{}{},
+		Cookie: map[string]interface{}{},
+	}
+
+	var violations []*Violation
+	for _, param := range v.spec.OperationParameters(item, op) {
+		value, present, vs := v.Parameter(r, param, pathParams)
+		violations = append(violations, vs...)
+		if !present {
+			continue
+		}
+		switch param.In {
+		case InPath:
+			input.Path[param.Name] = value
+		case InQuery:
+			input.Query[param.Name] = value
+		case InHeader:
+			input.Header[param.Name] = value
+		case InCookie:
+			input.Cookie[param.Name] = value
+		}
+	}
+
+	mediaType, body, vs := v.body(r, v.spec.ResolveRequestBody(op.RequestBody))
+	input.MediaType, input.Body = mediaType, body
+	violations = append(violations, vs...)
+
+	if len(violations) > 0 {
+		return input, &Error{Violations: violations}
+	}
+	return input, nil
+}
+
+func (v *Validator) body(r *http.Request, body *openapi.RequestBodyOrRef) (string, interface{}, []*Violation) {
+	malformed := func(msg string) []*Violation {
+		return []*Violation{{In: InBody, Message: msg, malformed: true}}
+	}
+
+	raw, err := readBody(r)
+	if err != nil {
+		return "", nil, malformed("failed to read body: " + err.Error())
+	}
+	if body == nil {
+		return "", nil, nil
+	}
+	if len(raw) == 0 {
+		if body.Required {
+			return "", nil, malformed("is required")
+		}
+		return "", nil, nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil, malformed("invalid Content-Type: " + err.Error())
+	}
+	key := matchMediaType(body.Content, mediaType)
+	if key == "" {
+		return mediaType, nil, malformed("unsupported media type '" + mediaType + "'")
+	}
+	schema := body.Content[key].Schema
+
+	var value interface{}
+	switch {
+	case isJSON(mediaType):
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			return mediaType, nil, malformed("must be valid JSON: " + err.Error())
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(raw))
+		if err != nil {
+			return mediaType, nil, malformed("invalid form: " + err.Error())
+		}
+		obj, vs := v.form(schema, form, nil)
+		if len(vs) > 0 {
+			return mediaType, obj, vs
+		}
+		value = obj
+	case mediaType == "multipart/form-data":
+		reader := multipart.NewReader(bytes.NewReader(raw), params["boundary"])
+		form, err := reader.ReadForm(maxMemory)
+		if err != nil {
+			return mediaType, nil, malformed("invalid multipart form: " + err.Error())
+		}
+		defer form.RemoveAll()
+		files := map[string]bool{}
+		for name := range form.File {
+			files[name] = true
+		}
+		obj, vs := v.form(schema, form.Value, files)
+		if len(vs) > 0 {
+			return mediaType, obj, vs
+		}
+		value = obj
+	case strings.HasPrefix(mediaType, "text/"):
+		value = string(raw)
+	default:
+		// binary payloads are not inspected
+		return mediaType, raw, nil
+	}
+
+	var violations []*Violation
+	for _, violation := range v.Value(schema, value, Request) {
+		violation.In = InBody
+		violations = append(violations, violation)
+	}
+	return mediaType, value, violations
+}
+
+// form converts form fields into an object following the property types of
+// the schema. Uploaded files are represented by their field name.
+func (v *Validator) form(sor *openapi.SchemaOrRef, values url.Values, files map[string]bool) (map[string]interface{}, []*Violation) {
+	schema := v.spec.ResolveSchema(sor)
+	obj := map[string]interface{}{}
+	var violations []*Violation
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var prop *openapi.SchemaOrRef
+		if schema != nil {
+			prop = v.spec.ResolveSchema(schema.Properties[name])
+		}
+		if prop != nil && prop.Type == "array" {
+			arr := make([]interface{}, 0, len(values[name]))
+			for _, raw := range values[name] {
+				item, err := coerce(v.spec.ResolveSchema(prop.Items), raw)
+				if err != nil {
+					violations = append(violations, &Violation{In: InBody, Pointer: "/" + escapePointer(name), Message: err.Error()})
+					continue
+				}
+				arr = append(arr, item)
+			}
+			obj[name] = arr
+			continue
+		}
+		value, err := coerce(prop, values.Get(name))
+		if err != nil {
+			violations = append(violations, &Violation{In: InBody, Pointer: "/" + escapePointer(name), Message: err.Error()})
+			continue
+		}
+		obj[name] = value
+	}
+	for name := range files {
+		// binary strings are only checked for presence
+		obj[name] = ""
+	}
+	return obj, violations
+}
+
+// readBody reads the whole body and puts it back for later readers.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	raw, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	return raw, err
+}
+
+// matchMediaType returns the key of content matching mediaType, allowing
+// wildcards such as `image/*` in the document.
+func matchMediaType(content map[string]*openapi.MediaType, mediaType string) string {
+	if _, ok := content[mediaType]; ok {
+		return mediaType
+	}
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		base, _, err := mime.ParseMediaType(key)
+		if err != nil {
+			continue
+		}
+		if base == mediaType || base == "*/*" ||
+			(strings.HasSuffix(base, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(base, "*"))) {
+			return key
+		}
+	}
+	return ""
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// InStatus locates violations of the status code of a response.
+const InStatus = "status"
+
+// ResponseError is returned when a response does not conform to the
+// document, which is a bug of the server.
+type ResponseError struct {
+	Violations []*Violation
+}
+
+// Error ...
+func (e *ResponseError) Error() string {
+	return "invalid response: " + (&Error{Violations: e.Violations}).Error()
+}
+
+// Status is always 500 Internal Server Error.
+func (e *ResponseError) Status() int {
+	return http.StatusInternalServerError
+}
+
+// Response validates the status, headers and body of a response to the
+// operation. The returned error is a *ResponseError when the response does
+// not conform.
+func (v *Validator) Response(op *openapi.Operation, status int, header http.Header, body []byte) error {
+	res := v.spec.ResolveResponse(documentedResponse(op, status))
+	if res == nil {
+		return &ResponseError{Violations: []*Violation{{
+			In:      InStatus,
+			Message: fmt.Sprintf("%d is not documented", status),
+		}}}
+	}
+
+	var violations []*Violation
+	for name, hor := range res.Headers {
+		h := v.spec.ResolveHeader(hor)
+		if h == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		// headers are serialized like header parameters
+		param := &openapi.Parameter{
+			Name:     name,
+			In:       InHeader,
+			Required: h.Required,
+			Style:    h.Style,
+			Explode:  h.Explode,
+			Schema:   h.Schema,
+			Content:  h.Content,
+		}
+		_, _, vs := v.Parameter(&http.Request{Header: header}, param, nil)
+		violations = append(violations, vs...)
+	}
+	violations = append(violations, v.responseBody(res, header.Get("Content-Type"), body)...)
+
+	if len(violations) > 0 {
+		return &ResponseError{Violations: violations}
+	}
+	return nil
+}
+
+func (v *Validator) responseBody(res *openapi.ResponseOrRef, contentType string, body []byte) []*Violation {
+	fail := func(msg string) []*Violation {
+		return []*Violation{{In: InBody, Message: msg}}
+	}
+
+	if len(res.Content) == 0 {
+		if len(body) > 0 {
+			return fail("no content is documented")
+		}
+		return nil
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fail("invalid Content-Type: " + err.Error())
+	}
+	key := matchMediaType(res.Content, mediaType)
+	if key == "" {
+		return fail("undocumented media type '" + mediaType + "'")
+	}
+
+	var value interface{}
+	switch {
+	case isJSON(mediaType):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&value); err != nil {
+			return fail("must be valid JSON: " + err.Error())
+		}
+	case strings.HasPrefix(mediaType, "text/"):
+		value = string(body)
+	default:
+		return nil
+	}
+
+	violations := v.Value(res.Content[key].Schema, value, Response)
+	for _, violation := range violations {
+		violation.In = InBody
+	}
+	return violations
+}
+
+// documentedResponse returns the response documented for status, its range
+// such as `4XX`, or the default one.
+func documentedResponse(op *openapi.Operation, status int) *openapi.ResponseOrRef {
+	if op.Responses == nil {
+		return nil
+	}
+	responses := *op.Responses
+	code := strconv.Itoa(status)
+	if res, ok := responses[code]; ok {
+		return res
+	}
+	if res, ok := responses[code[:1]+"XX"]; ok {
+		return res
+	}
+	return responses["default"]
+}
@@ -0,0 +1,439 @@
+package validate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Direction tells whether a value is sent by the client or returned by the
+// server, which decides how `readOnly` and `writeOnly` are treated.
+type Direction int
+
+// directions
+const (
+	Request Direction = iota
+	Response
+	// Stored is a value kept by the server, which holds both read only and
+	// write only properties.
+	Stored
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	patternsMu sync.Mutex
+	patterns   = map[string]*regexp.Regexp{}
+)
+
+// Value validates a decoded JSON value against a schema. Numbers may be
+// float64, json.Number or any Go integer type.
+func (v *Validator) Value(schema *openapi.SchemaOrRef, value interface{}, dir Direction) []*Violation {
+	s := &schemaValidator{spec: v.spec, dir: dir}
+	s.validate("", schema, value, 0)
+	return s.violations
+}
+
+type schemaValidator struct {
+	spec       *openapi.OpenAPI
+	dir        Direction
+	violations []*Violation
+}
+
+// maxDepth stops validation of values nested deeper than any sane document.
+const maxDepth = 64
+
+func (s *schemaValidator) fail(pointer, format string, args ...interface{}) {
+	s.violations = append(s.violations, &Violation{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *schemaValidator) validate(pointer string, sor *openapi.SchemaOrRef, value interface{}, depth int) {
+	if sor == nil {
+		return
+	}
+	if depth > maxDepth {
+		s.fail(pointer, "value is nested too deeply")
+		return
+	}
+	resolved := s.spec.ResolveSchema(sor)
+	if resolved == nil {
+		s.fail(pointer, "unresolved schema reference '%s'", sor.Ref)
+		return
+	}
+	schema := &resolved.Schema
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			s.fail(pointer, "must not be null")
+		}
+		return
+	}
+
+	for _, sub := range schema.AllOf {
+		s.validate(pointer, sub, value, depth+1)
+	}
+	if len(schema.OneOf) > 0 {
+		s.oneOf(pointer, schema, value, depth)
+	}
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, sub := range schema.AnyOf {
+			if s.matches(pointer, sub, value, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			s.fail(pointer, "must match at least one of the anyOf schemas")
+		}
+	}
+	if schema.Not != nil && s.matches(pointer, schema.Not, value, depth) {
+		s.fail(pointer, "must not match the schema in not")
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		s.fail(pointer, "must be one of %v", schema.Enum)
+	}
+
+	switch schema.Type {
+	case "":
+		// untyped schemas only constrain through the keywords above, plus
+		// properties when the value happens to be an object
+		if obj, ok := value.(map[string]interface{}); ok {
+			s.object(pointer, schema, obj, depth)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			s.fail(pointer, "must be a string")
+			return
+		}
+		s.string(pointer, schema, str)
+	case "integer", "number":
+		n, ok := toFloat(value)
+		if !ok && schema.Type == "integer" {
+			s.fail(pointer, "must be an integer")
+			return
+		}
+		if !ok {
+			s.fail(pointer, "must be a number")
+			return
+		}
+		if schema.Type == "integer" && n != math.Trunc(n) {
+			s.fail(pointer, "must be an integer")
+			return
+		}
+		s.number(pointer, schema, n)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			s.fail(pointer, "must be a boolean")
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			s.fail(pointer, "must be an array")
+			return
+		}
+		s.array(pointer, schema, arr, depth)
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			s.fail(pointer, "must be an object")
+			return
+		}
+		s.object(pointer, schema, obj, depth)
+	}
+}
+
+// matches reports whether the value validates against the schema without
+// recording violations.
+func (s *schemaValidator) matches(pointer string, sor *openapi.SchemaOrRef, value interface{}, depth int) bool {
+	sub := &schemaValidator{spec: s.spec, dir: s.dir}
+	sub.validate(pointer, sor, value, depth+1)
+	return len(sub.violations) == 0
+}
+
+func (s *schemaValidator) oneOf(pointer string, schema *openapi.Schema, value interface{}, depth int) {
+	if target := s.discriminated(schema, value); target != nil {
+		s.validate(pointer, target, value, depth+1)
+		return
+	}
+
+	matched := 0
+	for _, sub := range schema.OneOf {
+		if s.matches(pointer, sub, value, depth) {
+			matched++
+		}
+	}
+	if matched != 1 {
+		s.fail(pointer, "must match exactly one of the oneOf schemas, matched %d", matched)
+	}
+}
+
+// discriminated returns the oneOf schema selected by the discriminator.
+func (s *schemaValidator) discriminated(schema *openapi.Schema, value interface{}) *openapi.SchemaOrRef {
+	if schema.Discriminator == nil {
+		return nil
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	name, ok := obj[schema.Discriminator.PropertyName].(string)
+	if !ok {
+		return nil
+	}
+	ref, ok := schema.Discriminator.Mapping[name]
+	if !ok {
+		ref = "#/components/schemas/" + name
+	}
+	for _, sub := range schema.OneOf {
+		if sub.Ref == ref {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (s *schemaValidator) string(pointer string, schema *openapi.Schema, str string) {
+	length := uint64(utf8.RuneCountInString(str))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		s.fail(pointer, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		s.fail(pointer, "must be at most %d characters", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err != nil {
+			s.fail(pointer, "invalid pattern '%s' in schema: %v", schema.Pattern, err)
+		} else if !re.MatchString(str) {
+			s.fail(pointer, "must match pattern '%s'", schema.Pattern)
+		}
+	}
+	if msg := checkFormat(schema.Format, str); msg != "" {
+		s.fail(pointer, "%s", msg)
+	}
+}
+
+func (s *schemaValidator) number(pointer string, schema *openapi.Schema, n float64) {
+	if schema.Minimum != nil {
+		if schema.ExclusiveMinimum && n <= *schema.Minimum {
+			s.fail(pointer, "must be greater than %v", *schema.Minimum)
+		} else if n < *schema.Minimum {
+			s.fail(pointer, "must be greater than or equal to %v", *schema.Minimum)
+		}
+	}
+	if schema.Maximum != nil {
+		if schema.ExclusiveMaximum && n >= *schema.Maximum {
+			s.fail(pointer, "must be less than %v", *schema.Maximum)
+		} else if n > *schema.Maximum {
+			s.fail(pointer, "must be less than or equal to %v", *schema.Maximum)
+		}
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+		if q := n / *schema.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			s.fail(pointer, "must be a multiple of %v", *schema.MultipleOf)
+		}
+	}
+	switch schema.Format {
+	case "int32":
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			s.fail(pointer, "must fit in int32")
+		}
+	case "float":
+		if math.Abs(n) > math.MaxFloat32 {
+			s.fail(pointer, "must fit in float")
+		}
+	}
+}
+
+func (s *schemaValidator) array(pointer string, schema *openapi.Schema, arr []interface{}, depth int) {
+	n := uint64(len(arr))
+	if schema.MinItems != nil && n < *schema.MinItems {
+		s.fail(pointer, "must have at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && n > *schema.MaxItems {
+		s.fail(pointer, "must have at most %d items", *schema.MaxItems)
+	}
+	if schema.UniqueItems {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					s.fail(pointer, "items must be unique, %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	for i, item := range arr {
+		s.validate(pointer+"/"+strconv.Itoa(i), schema.Items, item, depth+1)
+	}
+}
+
+func (s *schemaValidator) object(pointer string, schema *openapi.Schema, obj map[string]interface{}, depth int) {
+	n := uint64(len(obj))
+	if schema.MinProperties != nil && n < *schema.MinProperties {
+		s.fail(pointer, "must have at least %d properties", *schema.MinProperties)
+	}
+	if schema.MaxProperties != nil && n > *schema.MaxProperties {
+		s.fail(pointer, "must have at most %d properties", *schema.MaxProperties)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; ok {
+			continue
+		}
+		// read only properties are not sent by clients, and write only ones
+		// are not returned by servers
+		if prop := s.spec.ResolveSchema(schema.Properties[name]); prop != nil {
+			if (s.dir == Request && prop.ReadOnly) || (s.dir == Response && prop.WriteOnly) {
+				continue
+			}
+		}
+		s.fail(pointer+"/"+escapePointer(name), "is required")
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := pointer + "/" + escapePointer(name)
+		if prop, ok := schema.Properties[name]; ok {
+			if resolved := s.spec.ResolveSchema(prop); resolved != nil {
+				if s.dir == Request && resolved.ReadOnly {
+					s.fail(p, "is read only")
+				}
+				if s.dir == Response && resolved.WriteOnly {
+					s.fail(p, "is write only")
+				}
+			}
+			s.validate(p, prop, obj[name], depth+1)
+			continue
+		}
+
+		additional := schema.AdditionalProperties
+		switch {
+		case additional == nil:
+		case additional.Schema != nil:
+			s.validate(p, additional.Schema, obj[name], depth+1)
+		case !additional.Bool:
+			s.fail(p, "is not allowed")
+		}
+	}
+}
+
+func checkFormat(format, str string) string {
+	switch format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(str) {
+			return "must be a UUID"
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(str); err != nil || addr.Address != str {
+			return "must be an email address"
+		}
+	case "ipv4":
+		if ip := net.ParseIP(str); ip == nil || ip.To4() == nil {
+			return "must be an IPv4 address"
+		}
+	case "ipv6":
+		if ip := net.ParseIP(str); ip == nil || ip.To4() != nil {
+			return "must be an IPv6 address"
+		}
+	case "uri":
+		if u, err := url.Parse(str); err != nil || !u.IsAbs() {
+			return "must be an absolute URI"
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+			return "must be base64 encoded"
+		}
+	}
+	return ""
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+
+	if re, ok := patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns[pattern] = re
+	return re, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func inEnum(enum []openapi.Any, value interface{}) bool {
+	for _, e := range enum {
+		if equal(openapi.NormalizeAny(e), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares decoded values, treating all numeric types alike.
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
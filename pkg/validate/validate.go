@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+)
+
+// Violation is a single mismatch between a request or response and the
+// document.
+type Violation struct {
+	// In is where the violation was found: path, query, header, cookie or
+	// body.
+	In string `json:"in,omitempty"`
+	// Name is the name of the parameter or header, if any.
+	Name string `json:"name,omitempty"`
+	// Pointer is a JSON pointer into the offending value.
+	Pointer string `json:"pointer,omitempty"`
+	Message string `json:"message"`
+
+	// malformed is set when the request could not be decoded at all.
+	malformed bool
+}
+
+// String ...
+func (v *Violation) String() string {
+	location := v.In
+	if v.Name != "" {
+		location += " " + v.Name
+	}
+	if v.Pointer != "" {
+		location += " " + v.Pointer
+	}
+	return strings.TrimSpace(location) + ": " + v.Message
+}
+
+// Error is returned when a request or response does not conform to the
+// document.
+type Error struct {
+	Violations []*Violation
+}
+
+// Error ...
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+	return fmt.Sprintf("%d violations: %s", len(e.Violations), strings.Join(messages, "; "))
+}
+
+// Status returns 400 Bad Request when parameters are invalid or the body
+// cannot be decoded, and 422 Unprocessable Entity when only the contents of
+// the body are invalid.
+func (e *Error) Status() int {
+	for _, v := range e.Violations {
+		if v.In != InBody || v.malformed {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// Validator validates requests and values against a document.
+type Validator struct {
+	spec *openapi.OpenAPI
+}
+
+// New ...
+func New(spec *openapi.OpenAPI) *Validator {
+	return &Validator{spec: spec}
+}
@@ -0,0 +1,280 @@
+package validate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cry999/gopenapi/pkg/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+func schemaOf(t *testing.T, doc string) *openapi.SchemaOrRef {
+	t.Helper()
+
+	var schema openapi.SchemaOrRef
+	if err := yaml.Unmarshal([]byte(doc), &schema); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return &schema
+}
+
+func TestParameter(t *testing.T) {
+	v := New(&openapi.OpenAPI{})
+
+	tests := []struct {
+		name   string
+		param  openapi.Parameter
+		schema string
+		target string
+		path   map[string]string
+		want   interface{}
+	}{
+		{
+			name:   "exploded query array",
+			param:  openapi.Parameter{Name: "id", In: InQuery},
+			schema: "{type: array, items: {type: integer}}",
+			target: "/?id=1&id=2",
+			want:   []interface{}{int64(1), int64(2)},
+		},
+		{
+			name:   "pipe delimited query array",
+			param:  openapi.Parameter{Name: "id", In: InQuery, Style: "pipeDelimited"},
+			schema: "{type: array}",
+			target: "/?id=a|b",
+			want:   []interface{}{"a", "b"},
+		},
+		{
+			name:   "deep object",
+			param:  openapi.Parameter{Name: "filter", In: InQuery, Style: "deepObject"},
+			schema: "{type: object, properties: {age: {type: integer}}}",
+			target: "/?filter[age]=3",
+			want:   map[string]interface{}{"age": int64(3)},
+		},
+		{
+			name:   "label path array",
+			param:  openapi.Parameter{Name: "ids", In: InPath, Style: "label"},
+			schema: "{type: array}",
+			target: "/",
+			path:   map[string]string{"ids": ".a,b"},
+			want:   []interface{}{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.param.Schema = schemaOf(t, tt.schema)
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+
+			got, present, violations := v.Parameter(r, &tt.param, tt.path)
+			if !present || len(violations) > 0 {
+				t.Fatalf("Parameter() present = %v, violations = %v", present, violations)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parameter() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+const testSpec = `
+paths:
+  /pets/{id}:
+    put:
+      parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                id:
+                  type: integer
+                  readOnly: true
+                name:
+                  type: string
+                  maxLength: 3
+`
+
+func TestRequest(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	v := New(&spec)
+	item := spec.Paths["/pets/{id}"]
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+		wantCount  int
+	}{
+		{"valid", "1", `{"name": "tom"}`, 0, 0},
+		{"invalid path parameter", "x", `{"name": "tom"}`, http.StatusBadRequest, 1},
+		{"malformed body", "1", `{`, http.StatusBadRequest, 1},
+		{"invalid body", "1", `{"id": 1, "name": "jerry"}`, http.StatusUnprocessableEntity, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/pets/"+tt.id, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+
+			_, err := v.Request(r, item, item.Put, map[string]string{"id": tt.id})
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("Request() error = %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Request() error = %v, want *Error", err)
+			}
+			if verr.Status() != tt.wantStatus || len(verr.Violations) != tt.wantCount {
+				t.Errorf("Request() status = %d, violations = %v, want %d and %d violations",
+					verr.Status(), verr.Violations, tt.wantStatus, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var handled *Input
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = InputFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	var reported error
+	mw := Middleware(&spec,
+		WithResponseValidation(true),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) { reported = err }),
+	)(handler)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		wantErr error
+	}{
+		{"unknown path", http.MethodPut, "/cats/1", "", ErrNotFound},
+		{"unknown method", http.MethodGet, "/pets/1", "", ErrMethodNotAllowed},
+		{"invalid request", http.MethodPut, "/pets/1", `{}`, &Error{}},
+		{"undocumented response", http.MethodPut, "/pets/1", `{"name": "tom"}`, &ResponseError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled, reported = nil, nil
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			mw.ServeHTTP(httptest.NewRecorder(), r)
+
+			switch tt.wantErr.(type) {
+			case *Error, *ResponseError:
+				if reflect.TypeOf(reported) != reflect.TypeOf(tt.wantErr) {
+					t.Errorf("reported %T(%v), want %T", reported, reported, tt.wantErr)
+				}
+			default:
+				if reported != tt.wantErr {
+					t.Errorf("reported %v, want %v", reported, tt.wantErr)
+				}
+			}
+		})
+	}
+	if handled == nil || handled.Path["id"] != int64(1) {
+		t.Errorf("InputFromContext() = %v, want the decoded request", handled)
+	}
+}
+
+func TestMiddleware_Servers(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec+`
+servers:
+- url: https://api.example.com/v1
+- url: https://api.example.com/{version}/beta
+  variables:
+    version:
+      default: v2
+      enum: [v2]
+`), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var handled *Input
+	var reported error
+	mw := Middleware(&spec,
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) { reported = err }),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = InputFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		path    string
+		wantErr error
+	}{
+		{"/v1/pets/1", nil},
+		{"/v2/beta/pets/1", nil},
+		{"/v3/beta/pets/1", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			handled, reported = nil, nil
+			r := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(`{"name": "tom"}`))
+			r.Header.Set("Content-Type", "application/json")
+			mw.ServeHTTP(httptest.NewRecorder(), r)
+
+			if reported != tt.wantErr {
+				t.Errorf("reported %v, want %v", reported, tt.wantErr)
+			}
+			if tt.wantErr == nil && (handled == nil || handled.Path["id"] != int64(1)) {
+				t.Errorf("InputFromContext() = %v, want the decoded request", handled)
+			}
+		})
+	}
+}
+
+func TestMiddleware_MaxBodySize(t *testing.T) {
+	var spec openapi.OpenAPI
+	if err := yaml.Unmarshal([]byte(testSpec), &spec); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		size       int64
+		wantStatus int
+	}{
+		{"below the limit", 15, http.StatusOK},
+		{"above the limit", 8, http.StatusRequestEntityTooLarge},
+		{"no limit", 0, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/pets/1", strings.NewReader(`{"name": "tom"}`))
+			r.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			Middleware(&spec, WithMaxBodySize(tt.size))(next).ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the events changing the files of a directory, or the
+// directory itself.
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// inotify notifies changes with the inotify API. It watches the directories
+// under the path, or the directory of a file, as inotify does not watch
+// directories recursively and loses files replaced by editors.
+type inotify struct {
+	fd     int
+	file   *os.File
+	root   string
+	events chan struct{}
+
+	mu   sync.Mutex
+	dirs map[int32]string
+}
+
+func newNotifier(path string) (notifier, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	root := path
+	if !fi.IsDir() {
+		root = filepath.Dir(path)
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	n := &inotify{
+		fd: fd,
+		// non-blocking so that Close interrupts reads
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		root:   root,
+		events: make(chan struct{}, 1),
+		dirs:   map[int32]string{},
+	}
+	if err := n.add(root); err != nil {
+		n.file.Close()
+		return nil, err
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) Events() <-chan struct{} {
+	return n.events
+}
+
+func (n *inotify) Close() error {
+	return n.file.Close()
+}
+
+// add watches dir and the directories under it, skipping those Take skips.
+func (n *inotify) add(dir string) error {
+	return filepath.Walk(dir, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && name != dir {
+				return nil
+			}
+			return err
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		if name != n.root && (strings.HasPrefix(fi.Name(), ".") || strings.HasSuffix(fi.Name(), "~")) {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, name, inotifyMask)
+		if err != nil {
+			if err == syscall.ENOENT {
+				// removed while walking
+				return nil
+			}
+			return os.NewSyscallError("inotify_add_watch", err)
+		}
+		n.mu.Lock()
+		n.dirs[int32(wd)] = name
+		n.mu.Unlock()
+		return nil
+	})
+}
+
+// read reads the events until the notifier is closed or fails, watching the
+// directories created meanwhile.
+func (n *inotify) read() {
+	defer close(n.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		size, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= size; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := strings.TrimRight(string(buf[offset+syscall.SizeofInotifyEvent:offset+syscall.SizeofInotifyEvent+int(event.Len)]), "\x00")
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			n.mu.Lock()
+			dir, ok := n.dirs[event.Wd]
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(n.dirs, event.Wd)
+			}
+			n.mu.Unlock()
+			switch {
+			case event.Mask&syscall.IN_Q_OVERFLOW != 0:
+				// events are lost but the snapshot finds the changes
+			case !ok:
+			case event.Mask&(syscall.IN_IGNORED|syscall.IN_MOVE_SELF) != 0 && dir == n.root:
+				// the root is gone or moved, which polling handles
+				return
+			case event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				if err := n.add(filepath.Join(dir, name)); err != nil {
+					return
+				}
+			}
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package watch
+
+import "errors"
+
+func newNotifier(path string) (notifier, error) {
+	return nil, errors.New("notifications are not supported")
+}
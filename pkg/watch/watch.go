@@ -0,0 +1,166 @@
+// Package watch detects changes of files, notified by the system where it
+// is supported and by polling them otherwise.
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot records the size and modification time of files.
+type Snapshot map[string]state
+
+type state struct {
+	size    int64
+	modTime time.Time
+}
+
+// Take takes a snapshot of path, a file or the regular files under a
+// directory. Hidden files and backup files ending with ~ are skipped, as
+// editors keep their temporary files there.
+func Take(path string) (Snapshot, error) {
+	snapshot := Snapshot{}
+	err := filepath.Walk(path, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && name != path {
+				// removed while walking
+				return nil
+			}
+			return err
+		}
+		if name != path && (strings.HasPrefix(fi.Name(), ".") || strings.HasSuffix(fi.Name(), "~")) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Mode().IsRegular() {
+			snapshot[name] = state{size: fi.Size(), modTime: fi.ModTime()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Equal tells whether two snapshots record the same files, unchanged.
+func (s Snapshot) Equal(other Snapshot) bool {
+	return len(s.Changes(other)) == 0
+}
+
+// Changes lists the files created, modified or removed from s to other,
+// sorted.
+func (s Snapshot) Changes(other Snapshot) []string {
+	var changes []string
+	for name, st := range s {
+		if o, ok := other[name]; !ok || o.size != st.size || !o.modTime.Equal(st.modTime) {
+			changes = append(changes, name)
+		}
+	}
+	for name := range other {
+		if _, ok := s[name]; !ok {
+			changes = append(changes, name)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// Watch watches path until ctx is done, and calls changed once its files
+// have changed and then stayed the same for an interval, so that saving
+// several files at once calls it once, with all of them. Changes are notified
+// by the system where it is supported, and path is polled every interval
+// otherwise, or once notifications fail.
+func Watch(ctx context.Context, path string, interval time.Duration, changed func(files []string)) error {
+	n, err := newNotifier(path)
+	if err != nil {
+		base, err := Take(path)
+		if err != nil {
+			return err
+		}
+		return poll(ctx, path, interval, base, changed)
+	}
+	defer n.Close()
+
+	// the snapshot is taken once notified so that no change is missed
+	base, err := Take(path)
+	if err != nil {
+		return err
+	}
+	// quiet fires once no change has been notified for an interval
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-n.Events():
+			if !ok {
+				return poll(ctx, path, interval, base, changed)
+			}
+			quiet = time.After(interval)
+			continue
+		case <-quiet:
+			quiet = nil
+		}
+		current, err := Take(path)
+		if err != nil {
+			// the path is being replaced, try again later
+			quiet = time.After(interval)
+			continue
+		}
+		changes := base.Changes(current)
+		base = current
+		if len(changes) > 0 {
+			changed(changes)
+		}
+	}
+}
+
+// poll polls path every interval, calling changed as Watch does, from the
+// snapshot base.
+func poll(ctx context.Context, path string, interval time.Duration, base Snapshot, changed func(files []string)) error {
+	// base is the snapshot changed was last called with
+	last := base
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		current, err := Take(path)
+		if err != nil {
+			// the path is being replaced, try again later
+			continue
+		}
+		if !current.Equal(last) {
+			last, pending = current, true
+			continue
+		}
+		if pending {
+			pending = false
+			changes := base.Changes(current)
+			base = current
+			if len(changes) > 0 {
+				changed(changes)
+			}
+		}
+	}
+}
+
+// notifier notifies changes of the files under a path.
+type notifier interface {
+	// Events receives a value after changes, and is closed once changes
+	// can no longer be notified.
+	Events() <-chan struct{}
+	Close() error
+}
@@ -0,0 +1,103 @@
+package watch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"info.yml", "paths/index.yml", ".git/HEAD", "info.yml~"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("a"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before, err := Take(dir)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if len(before) != 2 {
+		t.Errorf("Take() = %v, want info.yml and paths/index.yml", before)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".git/HEAD"), []byte("ab"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := Take(dir); !after.Equal(before) {
+		t.Errorf("Take() = %v after changing a hidden file, want %v", after, before)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "info.yml"), []byte("ab"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := Take(dir); after.Equal(before) {
+		t.Errorf("Take() = %v after changing info.yml, want a different snapshot", after)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		watch func(ctx context.Context, dir string, changed func([]string)) error
+	}{
+		{"notified", func(ctx context.Context, dir string, changed func([]string)) error {
+			return Watch(ctx, dir, 10*time.Millisecond, changed)
+		}},
+		{"polled", func(ctx context.Context, dir string, changed func([]string)) error {
+			return poll(ctx, dir, 10*time.Millisecond, Snapshot{}, changed)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			changed := make(chan []string, 10)
+			go tt.watch(ctx, dir, func(files []string) { changed <- files })
+			time.Sleep(30 * time.Millisecond)
+
+			// files of new directories are found as well
+			if err := os.MkdirAll(filepath.Join(dir, "paths", "pets"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"a.yml", "paths/pets/index.yml"} {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("a"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			select {
+			case files := <-changed:
+				if want := []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "paths/pets/index.yml")}; !reflect.DeepEqual(files, want) {
+					t.Errorf("changed files = %v, want %v", files, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("changed is not called")
+			}
+			time.Sleep(50 * time.Millisecond)
+			if len(changed) != 0 {
+				t.Errorf("changed is called %d more times, want once", len(changed))
+			}
+
+			if err := ioutil.WriteFile(filepath.Join(dir, "paths/pets/index.yml"), []byte("ab"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case files := <-changed:
+				if want := []string{filepath.Join(dir, "paths/pets/index.yml")}; !reflect.DeepEqual(files, want) {
+					t.Errorf("changed files = %v, want %v", files, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("changed is not called after a change in a new directory")
+			}
+		})
+	}
+}